@@ -0,0 +1,10 @@
+data "bitwarden_export_file" "backup" {
+  path     = "${path.module}/backups/vault.json"
+  password = var.export_password
+}
+
+# Example of usage of the data source:
+output "exported_logins" {
+  value     = [for login in data.bitwarden_export_file.backup.logins : login.name]
+  sensitive = true
+}
@@ -0,0 +1,10 @@
+data "bitwarden_send_content" "partner_api_key" {
+  url      = var.partner_send_url
+  password = var.partner_send_password
+}
+
+# Example of usage of the data source:
+resource "bitwarden_item_login" "partner_api" {
+  name     = "Partner API"
+  password = data.bitwarden_send_content.partner_api_key.text
+}
@@ -0,0 +1,4 @@
+resource "bitwarden_account_master_password" "service_account" {
+  master_password = var.new_master_password
+  kdf_iterations  = 600000
+}
@@ -0,0 +1 @@
+$ terraform import bitwarden_emergency_access.example <emergency_access_id>
@@ -0,0 +1,5 @@
+resource "bitwarden_emergency_access" "break_glass" {
+  email     = "backup-admin@example.com"
+  type      = "takeover"
+  wait_days = 2
+}
@@ -0,0 +1,9 @@
+resource "bitwarden_vault_export" "backup" {
+  output_path = "${path.module}/backups/vault.json"
+  format      = "encrypted_json"
+  password    = var.export_password
+
+  triggers = {
+    day = formatdate("YYYY-MM-DD", timestamp())
+  }
+}
@@ -0,0 +1,7 @@
+resource "bitwarden_vault_import" "keepass" {
+  file   = "${path.module}/legacy/keepass.xml"
+  format = "keepass2xml"
+
+  organization_id = var.organization_id
+  collection_id   = var.collection_id
+}
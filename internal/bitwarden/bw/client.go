@@ -6,21 +6,43 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/hashicorp/go-version"
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
 )
 
+const (
+	// LockFileName is the file in the CLI's data directory, that commands
+	// lock to avoid concurrent modifications of the CLI's data file.
+	LockFileName = "terraform-provider-bitwarden.lock"
+)
+
+// readOnlyCommands don't modify the CLI's data file, and can run while other
+// read-only commands do.
+var readOnlyCommands = map[string]bool{
+	"export": true,
+	"get":    true,
+	"list":   true,
+	"status": true,
+}
+
 type Client interface {
 	CreateAttachment(ctx context.Context, itemId, filePath string) (*Object, error)
 	CreateObject(context.Context, Object) (*Object, error)
+	EditItemCollections(ctx context.Context, itemId string, collectionIds []string) (*Object, error)
 	EditObject(context.Context, Object) (*Object, error)
+	Export(ctx context.Context, filePath string, format ExportFormat, options ...ExportOption) error
 	GetAttachment(ctx context.Context, itemId, attachmentId string) ([]byte, error)
 	GetObject(context.Context, Object) (*Object, error)
 	GetSessionKey() string
+	GetVersion() *version.Version
 	HasSessionKey() bool
+	Import(ctx context.Context, format, filePath string, options ...ImportOption) error
 	ListObjects(ctx context.Context, objType string, options ...ListObjectsOption) ([]Object, error)
 	LoginWithAPIKey(ctx context.Context, password, clientId, clientSecret string) error
-	LoginWithPassword(ctx context.Context, username, password string) error
+	LoginWithPassword(ctx context.Context, username, password string, options ...LoginOption) error
 	Logout(context.Context) error
 	DeleteAttachment(ctx context.Context, itemId, attachmentId string) error
 	DeleteObject(context.Context, Object) error
@@ -40,19 +62,34 @@ func NewClient(execPath string, opts ...Options) Client {
 		o(c)
 	}
 
-	c.newCommand = command.NewWithRetries(&retryHandler{disableRetryBackoff: c.disableRetryBackoff})
+	c.newCommand = command.NewWithRetries(newRetryHandler(c.retryMaxAttempts, c.retryMaxDelay, c.disableRetryBackoff))
+	if len(c.appDataDir) > 0 || c.maxParallelCommands > 0 {
+		lockPath := ""
+		if len(c.appDataDir) > 0 {
+			lockPath = filepath.Join(c.appDataDir, LockFileName)
+		}
+
+		// Retries happen with the lock held, as other commands would likely
+		// be rate limited as well.
+		c.newCommand = command.NewWithLimiter(c.newCommand, command.NewLimiter(lockPath, c.maxParallelCommands), isExclusiveCommand)
+	}
 
 	return c
 }
 
 type client struct {
 	appDataDir          string
+	commandTimeout      time.Duration
 	disableSync         bool
 	disableRetryBackoff bool
 	execPath            string
 	extraCACertsPath    string
+	maxParallelCommands int
 	newCommand          command.NewFn
+	retryMaxAttempts    int
+	retryMaxDelay       time.Duration
 	sessionKey          string
+	version             *version.Version
 }
 
 type Options func(c Client)
@@ -69,6 +106,48 @@ func WithExtraCACertsPath(extraCACertsPath string) Options {
 	}
 }
 
+// WithCommandTimeout kills commands which didn't finish in time, along with
+// the processes they started.
+func WithCommandTimeout(timeout time.Duration) Options {
+	return func(c Client) {
+		c.(*client).commandTimeout = timeout
+	}
+}
+
+// WithMaxParallelCommands limits how many commands run concurrently. By
+// default, only commands modifying the CLI's data file are serialized.
+func WithMaxParallelCommands(maxParallelCommands int) Options {
+	return func(c Client) {
+		c.(*client).maxParallelCommands = maxParallelCommands
+	}
+}
+
+// WithRetryMaxAttempts limits how many times a command runs when it keeps
+// failing with transient errors (default: 3).
+func WithRetryMaxAttempts(maxAttempts int) Options {
+	return func(c Client) {
+		c.(*client).retryMaxAttempts = maxAttempts
+	}
+}
+
+// WithRetryMaxDelay caps the delay between two runs of a failing command
+// (default: 30s).
+func WithRetryMaxDelay(maxDelay time.Duration) Options {
+	return func(c Client) {
+		c.(*client).retryMaxDelay = maxDelay
+	}
+}
+
+// WithVersion records the version of the CLI, which is then mentioned in
+// errors.
+func WithVersion(v *version.Version) Options {
+	return func(c Client) {
+		c.(*client).version = v
+	}
+}
+
+// DisableSync skips the syncs following edits, which aren't needed when
+// nothing else modifies the Vault. Explicit syncs still happen.
 func DisableSync() Options {
 	return func(c Client) {
 		c.(*client).disableSync = true
@@ -90,20 +169,21 @@ func (c *client) CreateObject(ctx context.Context, obj Object) (*Object, error)
 	args := []string{
 		"create",
 		string(obj.Object),
-		objEncoded,
 	}
 
 	if obj.Object == ObjectTypeOrgCollection {
 		args = append(args, "--organizationid", obj.OrganizationID)
 	}
 
-	out, err := c.cmdWithSession(args...).Run(ctx)
+	// The encoded object is passed through stdin, as arguments can be seen by
+	// other users.
+	out, err := c.cmdWithSession(args...).WithStdin(objEncoded).Run(ctx)
 	if err != nil {
 		return nil, err
 	}
 	err = json.Unmarshal(out, &obj)
 	if err != nil {
-		return nil, newUnmarshallError(err, args[0:2], out)
+		return nil, c.newUnmarshallError(err, args[0:2], out)
 	}
 
 	// NOTE(maxime): there is no need to sync after creating an item
@@ -138,25 +218,83 @@ func (c *client) EditObject(ctx context.Context, obj Object) (*Object, error) {
 		"edit",
 		string(obj.Object),
 		obj.ID,
-		objEncoded,
 	}
 
-	out, err := c.cmdWithSession(args...).Run(ctx)
+	out, err := c.cmdWithSession(args...).WithStdin(objEncoded).Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(out, &obj)
+	if err != nil {
+		return nil, c.newUnmarshallError(err, args[0:2], out)
+	}
+	err = c.syncAfterEdit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error syncing: %v, %v", err, command.Redact(string(out)))
+	}
+
+	return &obj, nil
+}
+
+// EditItemCollections sets the collections of an organization's item, which
+// 'edit item' leaves unchanged.
+func (c *client) EditItemCollections(ctx context.Context, itemId string, collectionIds []string) (*Object, error) {
+	payload, err := json.Marshal(collectionIds)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling error: %v", err)
+	}
+
+	args := []string{
+		"edit",
+		"item-collections",
+		itemId,
+	}
+
+	out, err := c.cmdWithSession(args...).WithStdin(base64.RawStdEncoding.EncodeToString(payload)).Run(ctx)
 	if err != nil {
 		return nil, err
 	}
+
+	var obj Object
 	err = json.Unmarshal(out, &obj)
 	if err != nil {
-		return nil, newUnmarshallError(err, args[0:2], out)
+		return nil, c.newUnmarshallError(err, args[0:2], out)
 	}
-	err = c.Sync(ctx)
+	err = c.syncAfterEdit(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error syncing: %v, %v", err, string(out))
+		return nil, fmt.Errorf("error syncing: %v, %v", err, command.Redact(string(out)))
 	}
 
 	return &obj, nil
 }
 
+// Export writes the content of the Vault, or of an organization's Vault, to a
+// file.
+func (c *client) Export(ctx context.Context, filePath string, format ExportFormat, options ...ExportOption) error {
+	args := []string{
+		"export",
+		"--output",
+		filePath,
+		"--format",
+		string(format),
+	}
+
+	opts := exportOptions{}
+	for _, applyOption := range options {
+		applyOption(&opts)
+	}
+	args = append(args, opts.args...)
+
+	if opts.password == nil {
+		_, err := c.cmdWithSession(args...).Run(ctx)
+		return err
+	}
+
+	// Without a value, '--password' makes the CLI prompt for the password.
+	_, err := c.cmdWithSession(append(args, "--password")...).WithStdin(*opts.password + "\n").Run(ctx)
+	return err
+}
+
 func (c *client) GetObject(ctx context.Context, obj Object) (*Object, error) {
 	args := []string{
 		"get",
@@ -175,7 +313,7 @@ func (c *client) GetObject(ctx context.Context, obj Object) (*Object, error) {
 
 	err = json.Unmarshal(out, &obj)
 	if err != nil {
-		return nil, newUnmarshallError(err, args[0:2], out)
+		return nil, c.newUnmarshallError(err, args[0:2], out)
 	}
 
 	return &obj, nil
@@ -190,6 +328,29 @@ func (c *client) GetAttachment(ctx context.Context, itemId, attachmentId string)
 	return out, nil
 }
 
+// Import imports the content of a file, in one of the formats supported by
+// the CLI, into the Vault or into an organization's Vault.
+func (c *client) Import(ctx context.Context, format, filePath string, options ...ImportOption) error {
+	args := []string{
+		"import",
+		format,
+		filePath,
+	}
+
+	for _, applyOption := range options {
+		applyOption(&args)
+	}
+
+	_, err := c.cmdWithSession(args...).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The import is done through the API, which isn't reflected locally
+	// until the next sync.
+	return c.Sync(ctx)
+}
+
 func (c *client) GetSessionKey() string {
 	return c.sessionKey
 }
@@ -213,7 +374,7 @@ func (c *client) ListObjects(ctx context.Context, objType string, options ...Lis
 	var obj []Object
 	err = json.Unmarshal(out, &obj)
 	if err != nil {
-		return nil, newUnmarshallError(err, args[0:2], out)
+		return nil, c.newUnmarshallError(err, args[0:2], out)
 	}
 
 	return obj, nil
@@ -221,8 +382,14 @@ func (c *client) ListObjects(ctx context.Context, objType string, options ...Lis
 
 // LoginWithPassword logs in using a password and retrieves the session key,
 // allowing authenticated requests using the client.
-func (c *client) LoginWithPassword(ctx context.Context, username, password string) error {
-	out, err := c.cmd("login", username, "--raw", "--passwordenv", "BW_PASSWORD").AppendEnv([]string{fmt.Sprintf("BW_PASSWORD=%s", password)}).Run(ctx)
+func (c *client) LoginWithPassword(ctx context.Context, username, password string, options ...LoginOption) error {
+	args := []string{"login", username, "--raw", "--passwordenv", "BW_PASSWORD"}
+	for _, applyOption := range options {
+		applyOption(&args)
+	}
+
+	command.RegisterSecret(password)
+	out, err := c.cmd(args...).AppendEnv([]string{fmt.Sprintf("BW_PASSWORD=%s", password)}).WithSecretOutput().Run(ctx)
 	if err != nil {
 		return err
 	}
@@ -233,6 +400,7 @@ func (c *client) LoginWithPassword(ctx context.Context, username, password strin
 // LoginWithPassword logs in using an API key and unlock the Vault in order to retrieve a session key,
 // allowing authenticated requests using the client.
 func (c *client) LoginWithAPIKey(ctx context.Context, password, clientId, clientSecret string) error {
+	command.RegisterSecret(clientSecret)
 	_, err := c.cmd("login", "--apikey").AppendEnv([]string{fmt.Sprintf("BW_CLIENTID=%s", clientId), fmt.Sprintf("BW_CLIENTSECRET=%s", clientSecret)}).Run(ctx)
 	if err != nil {
 		return err
@@ -279,14 +447,15 @@ func (c *client) Status(ctx context.Context) (*Status, error) {
 	var status Status
 	err = json.Unmarshal(out, &status)
 	if err != nil {
-		return nil, newUnmarshallError(err, []string{"status"}, out)
+		return nil, c.newUnmarshallError(err, []string{"status"}, out)
 	}
 
 	return &status, nil
 }
 
 func (c *client) Unlock(ctx context.Context, password string) error {
-	out, err := c.cmd("unlock", "--raw", "--passwordenv", "BW_PASSWORD").AppendEnv([]string{fmt.Sprintf("BW_PASSWORD=%s", password)}).Run(ctx)
+	command.RegisterSecret(password)
+	out, err := c.cmd("unlock", "--raw", "--passwordenv", "BW_PASSWORD").AppendEnv([]string{fmt.Sprintf("BW_PASSWORD=%s", password)}).WithSecretOutput().Run(ctx)
 	if err != nil {
 		return err
 	}
@@ -295,24 +464,36 @@ func (c *client) Unlock(ctx context.Context, password string) error {
 	return nil
 }
 
+func (c *client) GetVersion() *version.Version {
+	return c.version
+}
+
 func (c *client) HasSessionKey() bool {
 	return len(c.sessionKey) > 0
 }
 
 func (c *client) SetSessionKey(sessionKey string) {
+	command.RegisterSecret(sessionKey)
 	c.sessionKey = sessionKey
 }
 
+// Sync pulls changes made outside of the CLI, like through the API.
 func (c *client) Sync(ctx context.Context) error {
+	_, err := c.cmdWithSession("sync").Run(ctx)
+	return err
+}
+
+// syncAfterEdit refreshes the local copy of edited objects, which can be
+// skipped with DisableSync.
+func (c *client) syncAfterEdit(ctx context.Context) error {
 	if c.disableSync {
 		return nil
 	}
-	_, err := c.cmdWithSession("sync").Run(ctx)
-	return err
+	return c.Sync(ctx)
 }
 
 func (c *client) cmd(args ...string) command.Command {
-	return c.newCommand(c.execPath, args...).AppendEnv(c.env())
+	return c.newCommand(c.execPath, args...).AppendEnv(c.env()).WithTimeout(c.commandTimeout)
 }
 
 func (c *client) cmdWithSession(args ...string) command.Command {
@@ -331,6 +512,15 @@ func (c *client) env() []string {
 	return defaultEnv
 }
 
+func isExclusiveCommand(args []string) bool {
+	if len(args) == 0 || !readOnlyCommands[args[0]] {
+		return true
+	}
+	// Downloading an attachment talks to the server, and can refresh and save
+	// the access token to the CLI's data file.
+	return args[0] == "get" && len(args) > 1 && args[1] == "attachment"
+}
+
 func (c *client) encode(item Object) (string, error) {
 	newOut, err := json.Marshal(item)
 	if err != nil {
@@ -1,5 +1,11 @@
 package bw
 
+import (
+	"strconv"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+)
+
 type ListObjectsOption func(args *[]string)
 type ListObjectsOptionGenerator func(id string) ListObjectsOption
 
@@ -32,3 +38,45 @@ func WithUrl(url string) ListObjectsOption {
 		*args = append(*args, "--url", url)
 	}
 }
+
+type exportOptions struct {
+	args     []string
+	password *string
+}
+
+type ExportOption func(opts *exportOptions)
+
+func WithExportOrganizationID(id string) ExportOption {
+	return func(opts *exportOptions) {
+		opts.args = append(opts.args, "--organizationid", id)
+	}
+}
+
+// WithExportPassword encrypts an 'encrypted_json' export with a password
+// instead of the account's key. It is written to the CLI's password prompt,
+// to keep it out of the command line.
+func WithExportPassword(password string) ExportOption {
+	command.RegisterSecret(password)
+	return func(opts *exportOptions) {
+		opts.password = &password
+	}
+}
+
+type ImportOption func(args *[]string)
+
+func WithImportOrganizationID(id string) ImportOption {
+	return func(args *[]string) {
+		*args = append(*args, "--organizationid", id)
+	}
+}
+
+type LoginOption func(args *[]string)
+
+// WithTwoFactor provides the second factor of accounts with two-step login,
+// as the CLI can't prompt for it.
+func WithTwoFactor(method TwoFactorMethod, code string) LoginOption {
+	command.RegisterSecret(code)
+	return func(args *[]string) {
+		*args = append(*args, "--method", strconv.Itoa(int(method)), "--code", code)
+	}
+}
@@ -2,6 +2,9 @@ package bw
 
 import (
 	"context"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	test_command "github.com/maxlaverse/terraform-provider-bitwarden/internal/command/test"
@@ -10,7 +13,7 @@ import (
 
 func TestCreateObjectEncoding(t *testing.T) {
 	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
-		"create item eyJncm91cHMiOm51bGwsImxvZ2luIjp7fSwib2JqZWN0IjoiaXRlbSIsInNlY3VyZU5vdGUiOnt9LCJ0eXBlIjoxLCJmaWVsZHMiOlt7Im5hbWUiOiJ0ZXN0IiwidmFsdWUiOiJwYXNzZWQiLCJ0eXBlIjowLCJsaW5rZWRJZCI6bnVsbH1dfQ": `{}`,
+		"create item": `{}`,
 	})
 	defer removeMocks(t)
 
@@ -29,7 +32,7 @@ func TestCreateObjectEncoding(t *testing.T) {
 
 	assert.NoError(t, err)
 	if assert.Len(t, commandsExecuted(), 1) {
-		assert.Equal(t, "create item eyJncm91cHMiOm51bGwsImxvZ2luIjp7fSwib2JqZWN0IjoiaXRlbSIsInNlY3VyZU5vdGUiOnt9LCJ0eXBlIjoxLCJmaWVsZHMiOlt7Im5hbWUiOiJ0ZXN0IiwidmFsdWUiOiJwYXNzZWQiLCJ0eXBlIjowLCJsaW5rZWRJZCI6bnVsbH1dfQ", commandsExecuted()[0])
+		assert.Equal(t, "eyJncm91cHMiOm51bGwsImxvZ2luIjp7fSwib2JqZWN0IjoiaXRlbSIsInNlY3VyZU5vdGUiOnt9LCJ0eXBlIjoxLCJmaWVsZHMiOlt7Im5hbWUiOiJ0ZXN0IiwidmFsdWUiOiJwYXNzZWQiLCJ0eXBlIjowLCJsaW5rZWRJZCI6bnVsbH1dfQ:/:create item", commandsExecuted()[0])
 	}
 }
 
@@ -91,3 +94,155 @@ func TestErrorContainsCommand(t *testing.T) {
 		assert.ErrorContains(t, err, "unable to parse result of 'list org-collection', error: 'unexpected end of JSON input', output: ''")
 	}
 }
+
+func TestExport(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"export --output /tmp/export.json --format encrypted_json --organizationid org-id --password": ``,
+	})
+	defer removeMocks(t)
+
+	b := NewClient("dummy")
+	err := b.Export(context.Background(), "/tmp/export.json", ExportFormatEncryptedJSON, WithExportOrganizationID("org-id"), WithExportPassword("export-password"))
+
+	assert.NoError(t, err)
+	if assert.Len(t, commandsExecuted(), 1) {
+		// The password is only written to the CLI's prompt.
+		assert.Equal(t, "export-password\n:/:export --output /tmp/export.json --format encrypted_json --organizationid org-id --password", commandsExecuted()[0])
+		assert.NotContains(t, strings.Split(commandsExecuted()[0], ":/:")[1], "export-password")
+	}
+}
+
+func TestEditItemCollections(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"edit item-collections item-id": `{"id":"item-id","collectionIds":["collection-1","collection-2"]}`,
+		"sync":                          ``,
+	})
+	defer removeMocks(t)
+
+	b := NewClient("dummy")
+	obj, err := b.EditItemCollections(context.Background(), "item-id", []string{"collection-1", "collection-2"})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"collection-1", "collection-2"}, obj.CollectionIds)
+	}
+	payload := base64.RawStdEncoding.EncodeToString([]byte(`["collection-1","collection-2"]`))
+	assert.Equal(t, []string{payload + ":/:edit item-collections item-id", "sync"}, commandsExecuted())
+}
+
+func TestDisableSyncOnlySkipsSyncsAfterEdits(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"edit item-collections item-id": `{"id":"item-id","collectionIds":["collection-1"]}`,
+		"sync":                          ``,
+	})
+	defer removeMocks(t)
+
+	b := NewClient("dummy", DisableSync())
+	_, err := b.EditItemCollections(context.Background(), "item-id", []string{"collection-1"})
+	assert.NoError(t, err)
+	assert.NoError(t, b.Sync(context.Background()))
+
+	payload := base64.RawStdEncoding.EncodeToString([]byte(`["collection-1"]`))
+	assert.Equal(t, []string{payload + ":/:edit item-collections item-id", "sync"}, commandsExecuted())
+}
+
+func TestImport(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"import bitwardenjson /tmp/import.json --organizationid org-id": `Imported 3 items.`,
+		"sync": ``,
+	})
+	defer removeMocks(t)
+
+	b := NewClient("dummy")
+	err := b.Import(context.Background(), "bitwardenjson", "/tmp/import.json", WithImportOrganizationID("org-id"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"import bitwardenjson /tmp/import.json --organizationid org-id", "sync"}, commandsExecuted())
+}
+
+func TestLoginWithPasswordTwoFactor(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"login test@example.com --raw --passwordenv BW_PASSWORD --method 0 --code 123456": `session-key`,
+	})
+	defer removeMocks(t)
+
+	b := NewClient("dummy")
+	err := b.LoginWithPassword(context.Background(), "test@example.com", "password", WithTwoFactor(TwoFactorMethodAuthenticator, "123456"))
+
+	assert.NoError(t, err)
+	assert.True(t, b.HasSessionKey())
+	assert.Equal(t, []string{"login test@example.com --raw --passwordenv BW_PASSWORD --method 0 --code 123456"}, commandsExecuted())
+}
+
+func TestCommandsLockAppDataDir(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"list item": `[]`,
+	})
+	defer removeMocks(t)
+
+	appDataDir := t.TempDir()
+	b := NewClient("dummy", WithAppDataDir(appDataDir), WithMaxParallelCommands(1))
+	_, err := b.ListObjects(context.Background(), "item")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"list item"}, commandsExecuted())
+	assert.FileExists(t, filepath.Join(appDataDir, LockFileName))
+}
+
+func TestIsExclusiveCommand(t *testing.T) {
+	assert.False(t, isExclusiveCommand([]string{"get", "item", "object-id"}))
+	assert.False(t, isExclusiveCommand([]string{"list", "item"}))
+	assert.True(t, isExclusiveCommand([]string{"get", "attachment", "attachment-id", "--itemid", "item-id"}))
+	assert.True(t, isExclusiveCommand([]string{"sync"}))
+	assert.True(t, isExclusiveCommand([]string{"login", "--apikey"}))
+	assert.True(t, isExclusiveCommand([]string{"edit", "item", "object-id"}))
+}
+
+func TestSecretsNeverInArguments(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"create item":       `{"id": "item-id"}`,
+		"edit item item-id": `{"id": "item-id"}`,
+		"create org-collection --organizationid org-id": `{"id": "collection-id"}`,
+		"sync": ``,
+	})
+	defer removeMocks(t)
+
+	secrets := []string{"secret-password", "secret-notes", "secret-field", "secret-totp", "secret-collection"}
+	item := Object{
+		ID:     "item-id",
+		Object: ObjectTypeItem,
+		Type:   ItemTypeLogin,
+		Notes:  "secret-notes",
+		Login: Login{
+			Username: "username",
+			Password: "secret-password",
+			Totp:     "secret-totp",
+		},
+		Fields: []Field{{Name: "hidden", Value: "secret-field", Type: FieldTypeHidden}},
+	}
+
+	b := NewClient("dummy")
+	_, err := b.CreateObject(context.Background(), item)
+	assert.NoError(t, err)
+	_, err = b.EditObject(context.Background(), item)
+	assert.NoError(t, err)
+	_, err = b.CreateObject(context.Background(), Object{Object: ObjectTypeOrgCollection, OrganizationID: "org-id", Name: "secret-collection"})
+	assert.NoError(t, err)
+
+	assert.Len(t, commandsExecuted(), 4)
+	for _, executed := range commandsExecuted() {
+		// Commands with a stdin are recorded as '<stdin>:/:<args>'.
+		args := executed
+		if i := strings.LastIndex(executed, ":/:"); i >= 0 {
+			args = executed[i+len(":/:"):]
+		}
+		for _, arg := range strings.Split(args, " ") {
+			decoded, err := base64.RawStdEncoding.DecodeString(arg)
+			for _, secret := range secrets {
+				assert.NotContains(t, arg, secret)
+				if err == nil {
+					assert.NotContains(t, string(decoded), secret)
+				}
+			}
+		}
+	}
+}
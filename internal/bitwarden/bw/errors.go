@@ -16,8 +16,14 @@ var (
 	attachmentNotFoundRegexp = regexp.MustCompile(`^Attachment .* was not found.$`)
 )
 
-func newUnmarshallError(err error, args []string, out []byte) error {
-	return fmt.Errorf("unable to parse result of '%s', error: '%v', output: '%v'", strings.Join(args, " "), err, string(out))
+// newUnmarshallError mentions the version of the CLI, as its outputs can
+// change between versions.
+func (c *client) newUnmarshallError(err error, args []string, out []byte) error {
+	msg := fmt.Sprintf("unable to parse result of '%s', error: '%v', output: '%v'", strings.Join(args, " "), err, command.Redact(string(out)))
+	if c.version != nil {
+		msg = fmt.Sprintf("%s (Bitwarden CLI %s)", msg, c.version)
+	}
+	return errors.New(msg)
 }
 
 func remapError(err error) error {
@@ -1,5 +1,10 @@
 package bw
 
+import (
+	"fmt"
+	"strings"
+)
+
 func FilterObjectsByType(objs []Object, itemType ItemType) []Object {
 	if itemType == 0 {
 		return objs
@@ -13,3 +18,110 @@ func FilterObjectsByType(objs []Object, itemType ItemType) []Object {
 	}
 	return filtered
 }
+
+// ListFilters are the filters of the CLI's 'list' command.
+type ListFilters struct {
+	CollectionID   string
+	FolderID       string
+	OrganizationID string
+	Search         string
+	URL            string
+}
+
+func NewListFilters(options ...ListObjectsOption) (ListFilters, error) {
+	args := []string{}
+	for _, applyOption := range options {
+		applyOption(&args)
+	}
+
+	filters := ListFilters{}
+	for i := 0; i+1 < len(args); i += 2 {
+		switch args[i] {
+		case "--collectionid":
+			filters.CollectionID = args[i+1]
+		case "--folderid":
+			filters.FolderID = args[i+1]
+		case "--organizationid":
+			filters.OrganizationID = args[i+1]
+		case "--search":
+			filters.Search = args[i+1]
+		case "--url":
+			filters.URL = args[i+1]
+		default:
+			return filters, fmt.Errorf("unsupported list option '%s'", args[i])
+		}
+	}
+	if len(args)%2 != 0 {
+		return filters, fmt.Errorf("unsupported list options: %v", args)
+	}
+	return filters, nil
+}
+
+// Match returns whether the CLI would list the object with these filters,
+// trash aside. Like in the CLI, objects matching any of the folder,
+// organization or collection filters are kept, and then searched.
+func (f ListFilters) Match(obj Object) bool {
+	if len(f.FolderID) > 0 || len(f.OrganizationID) > 0 || len(f.CollectionID) > 0 {
+		if !matchID(f.FolderID, obj.FolderID) && !matchID(f.OrganizationID, obj.OrganizationID) && !matchCollectionID(f.CollectionID, obj.CollectionIds) {
+			return false
+		}
+	}
+
+	return len(f.Search) == 0 || matchSearch(f.Search, obj)
+}
+
+// matchID compares an object's reference to another object with a filter
+// which, like in the CLI, can also be 'null' or 'notnull'. Empty filters
+// match nothing.
+func matchID(filter, id string) bool {
+	switch filter {
+	case "":
+		return false
+	case "null":
+		return len(id) == 0
+	case "notnull":
+		return len(id) > 0
+	}
+	return filter == id
+}
+
+func matchCollectionID(filter string, collectionIds []string) bool {
+	switch filter {
+	case "":
+		return false
+	case "null":
+		return len(collectionIds) == 0
+	case "notnull":
+		return len(collectionIds) > 0
+	}
+	for _, collectionID := range collectionIds {
+		if collectionID == filter {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSearch is a simplified version of the CLI's basic search, which looks
+// at the name, the beginning of the ID, the username and the URIs.
+func matchSearch(search string, obj Object) bool {
+	search = strings.ToLower(strings.TrimSpace(search))
+	if strings.Contains(strings.ToLower(obj.Name), search) {
+		return true
+	}
+	if len(search) >= 8 && strings.HasPrefix(obj.ID, search) {
+		return true
+	}
+	if obj.Object != ObjectTypeItem {
+		return false
+	}
+	if strings.Contains(strings.ToLower(obj.Login.Username), search) {
+		return true
+	}
+	for _, uri := range obj.Login.URIs {
+		if strings.Contains(strings.ToLower(uri.URI), search) {
+			return true
+		}
+	}
+	return false
+}
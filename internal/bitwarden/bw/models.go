@@ -35,6 +35,51 @@ const (
 	ObjectTypeOrganization  ObjectType = "organization"
 )
 
+type ExportFormat string
+
+const (
+	ExportFormatCSV           ExportFormat = "csv"
+	ExportFormatJSON          ExportFormat = "json"
+	ExportFormatEncryptedJSON ExportFormat = "encrypted_json"
+)
+
+// ImportFormats are the formats 'bw import --formats' lists.
+var ImportFormats = []string{
+	"1password1pif", "1password1pux", "1passwordmaccsv", "1passwordwincsv",
+	"ascendocsv", "avastcsv", "avastjson", "aviracsv",
+	"bitwardencsv", "bitwardenjson", "bitwardenpasswordprotected", "blackberrycsv", "blurcsv", "buttercupcsv",
+	"chromecsv", "clipperzhtml", "codebookcsv",
+	"dashlanecsv", "dashlanejson",
+	"edgecsv", "encryptrcsv", "enpasscsv", "enpassjson",
+	"firefoxcsv", "fsecurefsk",
+	"gnomejson",
+	"kasperskytxt", "keepass2xml", "keepassxcsv", "keepercsv", "keeperjson",
+	"lastpasscsv", "logmeoncecsv",
+	"meldiumcsv", "msecurecsv", "mykicsv",
+	"netwrixpasswordsecure", "nordpasscsv",
+	"operacsv",
+	"padlockcsv", "passboltcsv", "passkeepcsv", "passmanjson", "passpackcsv", "passwordagentcsv",
+	"passwordbossjson", "passworddeluxecsv", "passworddragonxml", "passwordsafexml", "passwordwallettxt",
+	"protonpass", "psonojson",
+	"rememberearcsv", "roboformcsv",
+	"safariandmaccsv", "safeincloudxml", "saferpasscsv", "securesafecsv", "splashidcsv", "stickypasswordxml",
+	"truekeycsv",
+	"upmcsv",
+	"vivaldicsv",
+	"yoticsv",
+	"zohovaultcsv",
+}
+
+// TwoFactorMethod is a two-step login method, as numbered by the CLI's
+// '--method' flag.
+type TwoFactorMethod int
+
+const (
+	TwoFactorMethodAuthenticator TwoFactorMethod = 0
+	TwoFactorMethodEmail         TwoFactorMethod = 1
+	TwoFactorMethodYubiKey       TwoFactorMethod = 3
+)
+
 type VaultStatus string
 
 const (
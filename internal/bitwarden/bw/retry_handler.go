@@ -1,34 +1,122 @@
 package bw
 
 import (
-	"math"
+	"math/rand/v2"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
 )
 
 const (
-	rateLimitExceededError = "Rate limit exceeded."
+	defaultRetryMaxAttempts = 3
+	defaultRetryMaxDelay    = 30 * time.Second
+	retryBaseDelay          = time.Second
 )
 
+// rejectedRequestErrors are reported by the CLI when the server didn't
+// process the request, like when it's rate limited or couldn't be reached.
+// Any command can safely be run again after them.
+var rejectedRequestErrors = []string{
+	"Rate limit exceeded",
+	"getaddrinfo ENOTFOUND",
+	"getaddrinfo EAI_AGAIN",
+	"connect ECONNREFUSED",
+	"before secure TLS connection was established",
+	"TLS handshake timeout",
+	"connect: connection refused",
+}
+
+// transientErrors may be reported after the server processed the request, so
+// only idempotent commands are run again after them.
+var transientErrors = []string{
+	"Request failed",
+	"ECONNRESET",
+	"ETIMEDOUT",
+	"socket hang up",
+	"connection reset by peer",
+	"Internal Server Error",
+	"Bad Gateway",
+	"Service Unavailable",
+	"Gateway Timeout",
+}
+
+var serverErrorStatusRegexp = regexp.MustCompile(`\bstatus(?: code)?:? 5\d\d\b`)
+
+// idempotentCommands have the same effect when run more than once.
+var idempotentCommands = map[string]bool{
+	"config": true,
+	"edit":   true,
+	"export": true,
+	"get":    true,
+	"list":   true,
+	"status": true,
+	"sync":   true,
+	"unlock": true,
+}
+
 type retryHandler struct {
 	disableRetryBackoff bool
+	maxAttempts         int
+	maxDelay            time.Duration
+}
+
+// NewRetryHandler returns the retry handler of CLI commands, for other
+// clients to retry their requests the same way.
+func NewRetryHandler(maxAttempts int, maxDelay time.Duration, disableRetryBackoff bool) command.RetryHandler {
+	return newRetryHandler(maxAttempts, maxDelay, disableRetryBackoff)
+}
+
+func newRetryHandler(maxAttempts int, maxDelay time.Duration, disableRetryBackoff bool) *retryHandler {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryMaxAttempts
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+	return &retryHandler{
+		disableRetryBackoff: disableRetryBackoff,
+		maxAttempts:         maxAttempts,
+		maxDelay:            maxDelay,
+	}
 }
 
-func (r *retryHandler) IsRetryable(err error, attempt int) bool {
-	return strings.Contains(err.Error(), rateLimitExceededError) && attempt < 3
+func (r *retryHandler) IsRetryable(args []string, err error, attempt int) bool {
+	if attempt >= r.maxAttempts {
+		return false
+	}
+
+	msg := err.Error()
+	if containsAny(msg, rejectedRequestErrors) {
+		return true
+	}
+	return isIdempotentCommand(args) && (containsAny(msg, transientErrors) || serverErrorStatusRegexp.MatchString(msg))
 }
 
+// Backoff grows exponentially up to the maximum delay, half of which is
+// randomized to spread the retries of concurrent commands.
 func (r *retryHandler) Backoff(attempt int) time.Duration {
 	if r.disableRetryBackoff {
 		return 0
 	}
 
-	maxInterval := 30 * time.Second
-	delay := time.Duration(math.Pow(2, float64(attempt))) * time.Second
-	if delay > maxInterval {
-		delay = maxInterval
+	delay := r.maxDelay
+	if attempt < 32 {
+		delay = min(retryBaseDelay<<attempt, r.maxDelay)
 	}
+	return delay/2 + rand.N(delay/2+1)
+}
 
-	time.Sleep(delay)
-	return delay
+func isIdempotentCommand(args []string) bool {
+	return len(args) > 0 && idempotentCommands[args[0]]
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
 }
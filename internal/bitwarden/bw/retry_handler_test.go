@@ -0,0 +1,75 @@
+package bw
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	test_command "github.com/maxlaverse/terraform-provider-bitwarden/internal/command/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryHandlerIsRetryable(t *testing.T) {
+	r := newRetryHandler(0, 0, true)
+
+	tests := []struct {
+		args      []string
+		err       string
+		retryable bool
+	}{
+		{[]string{"create", "item"}, "Rate limit exceeded. Try again later.", true},
+		{[]string{"create", "item"}, "request to https://vault.bitwarden.com/api/ciphers failed, reason: getaddrinfo EAI_AGAIN vault.bitwarden.com", true},
+		{[]string{"create", "item"}, "request to https://vault.bitwarden.com/api/ciphers failed, reason: read ECONNRESET", false},
+		{[]string{"get", "item", "id"}, "request to https://vault.bitwarden.com/api/sync failed, reason: read ECONNRESET", true},
+		{[]string{"sync"}, "Request failed with status code 502", true},
+		{[]string{"list", "items"}, "Service Unavailable", true},
+		{[]string{"delete", "item", "id"}, "Gateway Timeout", false},
+		{[]string{"get", "item", "id"}, "Not found.", false},
+		{[]string{"get", "item", "id"}, "Invalid status code 404", false},
+		{[]string{"create"}, "Post \"http://127.0.0.1:8087/object/item\": dial tcp 127.0.0.1:8087: connect: connection refused", true},
+		{[]string{"get"}, "Get \"http://127.0.0.1:8087/sync\": read tcp 127.0.0.1:51234->127.0.0.1:8087: read: connection reset by peer", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.retryable, r.IsRetryable(tt.args, errors.New(tt.err), 1), "%v: %s", tt.args, tt.err)
+	}
+}
+
+func TestRetryHandlerMaxAttempts(t *testing.T) {
+	r := newRetryHandler(2, 0, true)
+	err := errors.New("Rate limit exceeded.")
+
+	assert.True(t, r.IsRetryable([]string{"status"}, err, 1))
+	assert.False(t, r.IsRetryable([]string{"status"}, err, 2))
+}
+
+func TestRetryHandlerBackoff(t *testing.T) {
+	r := newRetryHandler(0, 10*time.Second, false)
+
+	for attempt := 1; attempt < 100; attempt++ {
+		expected := min(retryBaseDelay<<min(attempt, 32), 10*time.Second)
+		delay := r.Backoff(attempt)
+		assert.GreaterOrEqual(t, delay, expected/2)
+		assert.LessOrEqual(t, delay, expected)
+	}
+
+	assert.Zero(t, newRetryHandler(0, 0, true).Backoff(1))
+}
+
+func TestClientRetriesOnlyIdempotentCommands(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"create item @error": `read ECONNRESET`,
+		"sync @error":        `read ECONNRESET`,
+	})
+	defer removeMocks(t)
+
+	b := NewClient("dummy", DisableRetryBackoff(), WithRetryMaxAttempts(4))
+	_, err := b.CreateObject(context.Background(), Object{Object: ObjectTypeItem})
+	assert.Error(t, err)
+
+	err = b.Sync(context.Background())
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"eyJncm91cHMiOm51bGwsImxvZ2luIjp7fSwib2JqZWN0IjoiaXRlbSIsInNlY3VyZU5vdGUiOnt9fQ:/:create item", "sync", "sync", "sync", "sync"}, commandsExecuted())
+}
@@ -0,0 +1,65 @@
+package bw
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+)
+
+// MinimumVersion is the oldest CLI supported, as passwords are passed through
+// '--passwordenv' which was added in 1.12.0.
+var MinimumVersion = version.Must(version.NewVersion("1.12.0"))
+
+// Capability is a command or option of the CLI some features rely on, which
+// older versions don't have.
+type Capability struct {
+	Name           string
+	MinimumVersion *version.Version
+}
+
+// CapabilityServe is the 'serve' command, which runs the CLI as a local API.
+var CapabilityServe = Capability{Name: "'bw serve'", MinimumVersion: version.Must(version.NewVersion("1.22.0"))}
+
+// defaultVersionTimeout bounds 'bw --version' when no command timeout is
+// configured, as a CLI which hangs would otherwise block the provider forever.
+const defaultVersionTimeout = time.Minute
+
+// DetectVersion returns the version of the CLI at execPath. The command is
+// killed after the given timeout, or defaultVersionTimeout if it's zero.
+func DetectVersion(ctx context.Context, execPath string, timeout time.Duration) (*version.Version, error) {
+	if timeout <= 0 {
+		timeout = defaultVersionTimeout
+	}
+
+	out, err := command.New(execPath, "--version").WithTimeout(timeout).Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := version.NewVersion(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse version '%s': %w", strings.TrimSpace(string(out)), err)
+	}
+	return v, nil
+}
+
+// CheckVersion returns an error if the version of the CLI isn't supported.
+func CheckVersion(v *version.Version) error {
+	if v.LessThan(MinimumVersion) {
+		return fmt.Errorf("Bitwarden CLI %s isn't supported, the minimum version is %s", v, MinimumVersion)
+	}
+	return nil
+}
+
+// CheckCapability returns an error if the version of the CLI doesn't have the
+// capability. Unknown versions are assumed to have it.
+func CheckCapability(v *version.Version, c Capability) error {
+	if v == nil || !v.LessThan(c.MinimumVersion) {
+		return nil
+	}
+	return fmt.Errorf("Bitwarden CLI %s doesn't support %s, which requires version %s or later", v, c.Name, c.MinimumVersion)
+}
@@ -0,0 +1,57 @@
+package bw
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	test_command "github.com/maxlaverse/terraform-provider-bitwarden/internal/command/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectVersion(t *testing.T) {
+	removeMocks, _ := test_command.MockCommands(t, map[string]string{
+		"--version": "2024.2.0\n",
+	})
+	defer removeMocks(t)
+
+	v, err := DetectVersion(context.Background(), "dummy", 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2024.2.0", v.String())
+		assert.NoError(t, CheckVersion(v))
+	}
+}
+
+func TestDetectVersionUnparsable(t *testing.T) {
+	removeMocks, _ := test_command.MockCommands(t, map[string]string{
+		"--version": "You are not logged in.",
+	})
+	defer removeMocks(t)
+
+	_, err := DetectVersion(context.Background(), "dummy", 0)
+	assert.ErrorContains(t, err, "unable to parse version 'You are not logged in.'")
+}
+
+func TestCheckVersionTooOld(t *testing.T) {
+	err := CheckVersion(version.Must(version.NewVersion("1.11.1")))
+	assert.EqualError(t, err, "Bitwarden CLI 1.11.1 isn't supported, the minimum version is 1.12.0")
+}
+
+func TestCheckCapability(t *testing.T) {
+	assert.NoError(t, CheckCapability(version.Must(version.NewVersion("2024.2.0")), CapabilityServe))
+	assert.NoError(t, CheckCapability(nil, CapabilityServe))
+
+	err := CheckCapability(version.Must(version.NewVersion("1.21.0")), CapabilityServe)
+	assert.EqualError(t, err, "Bitwarden CLI 1.21.0 doesn't support 'bw serve', which requires version 1.22.0 or later")
+}
+
+func TestUnmarshallErrorMentionsVersion(t *testing.T) {
+	removeMocks, _ := test_command.MockCommands(t, map[string]string{
+		"status": "not json",
+	})
+	defer removeMocks(t)
+
+	b := NewClient("dummy", WithVersion(version.Must(version.NewVersion("2024.2.0"))))
+	_, err := b.Status(context.Background())
+	assert.EqualError(t, err, "unable to parse result of 'status', error: 'invalid character 'o' in literal null (expecting 'u')', output: 'not json' (Bitwarden CLI 2024.2.0)")
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package bw
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectVersionTimeout(t *testing.T) {
+	execPath := filepath.Join(t.TempDir(), "bw")
+	assert.NoError(t, os.WriteFile(execPath, []byte("#!/bin/sh\nexec sleep 30\n"), 0700))
+
+	start := time.Now()
+	_, err := DetectVersion(context.Background(), execPath, 200*time.Millisecond)
+
+	assert.True(t, command.IsTimeout(err))
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
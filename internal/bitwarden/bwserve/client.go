@@ -0,0 +1,424 @@
+package bwserve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+)
+
+/*
+* This is a bw.Client running object operations through the Vault Management
+* API of a 'bw serve' process, which is started once and kept running for the
+* provider's lifetime. This avoids booting the CLI and decrypting the Vault
+* for every operation.
+*
+* Logins, configuration changes, imports and exports are still delegated to
+* the CLI. The process is restarted whenever the session changes.
+ */
+
+// Config of the 'bw serve' process. Requests are bounded by CommandTimeout
+// and retried by RetryHandler, like CLI commands are.
+type Config struct {
+	ExecPath         string
+	AppDataDir       string
+	ExtraCACertsPath string
+	CommandTimeout   time.Duration
+	RetryHandler     command.RetryHandler
+}
+
+func NewClient(bwClient bw.Client, cfg Config) bw.Client {
+	lockPath := ""
+	if len(cfg.AppDataDir) > 0 {
+		lockPath = filepath.Join(cfg.AppDataDir, bw.LockFileName)
+	}
+	if cfg.RetryHandler == nil {
+		cfg.RetryHandler = bw.NewRetryHandler(0, 0, false)
+	}
+
+	return &client{
+		Client:     bwClient,
+		cfg:        cfg,
+		httpClient: &http.Client{},
+		limiter:    command.NewLimiter(lockPath, 0),
+	}
+}
+
+type client struct {
+	bw.Client
+
+	cfg        Config
+	httpClient *http.Client
+	limiter    *command.Limiter
+	server     *server
+	serverMu   sync.Mutex
+}
+
+// response is the envelope of every JSON answer of the API.
+type response struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type listResponse struct {
+	Data []bw.Object `json:"data"`
+}
+
+func (c *client) CreateAttachment(ctx context.Context, itemId, filePath string) (*bw.Object, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filepath.Base(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing attachment upload: %w", err)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening attachment: %w", err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(part, f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading attachment: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error preparing attachment upload: %w", err)
+	}
+
+	var obj bw.Object
+	err = c.callJSON(ctx, "POST", "/attachment", url.Values{"itemid": {itemId}}, w.FormDataContentType(), body.Bytes(), &obj)
+	if err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+func (c *client) CreateObject(ctx context.Context, obj bw.Object) (*bw.Object, error) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling object: %w", err)
+	}
+
+	err = c.callJSON(ctx, "POST", objectPath(obj.Object, ""), objectQuery(obj), "application/json", body, &obj)
+	if err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+func (c *client) EditObject(ctx context.Context, obj bw.Object) (*bw.Object, error) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling object: %w", err)
+	}
+
+	err = c.callJSON(ctx, "PUT", objectPath(obj.Object, obj.ID), objectQuery(obj), "application/json", body, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Sync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error syncing: %w", err)
+	}
+	return &obj, nil
+}
+
+func (c *client) GetAttachment(ctx context.Context, itemId, attachmentId string) ([]byte, error) {
+	return c.call(ctx, "GET", objectPath(bw.ObjectTypeAttachment, attachmentId), url.Values{"itemid": {itemId}}, "", nil)
+}
+
+func (c *client) GetObject(ctx context.Context, obj bw.Object) (*bw.Object, error) {
+	err := c.callJSON(ctx, "GET", objectPath(obj.Object, obj.ID), objectQuery(obj), "", nil, &obj)
+	if err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+func (c *client) ListObjects(ctx context.Context, objType string, options ...bw.ListObjectsOption) ([]bw.Object, error) {
+	args := []string{}
+	for _, applyOption := range options {
+		applyOption(&args)
+	}
+
+	// List options are the CLI's flags, which the API accepts as query
+	// parameters.
+	query := url.Values{}
+	for i := 0; i+1 < len(args); i += 2 {
+		query.Set(strings.TrimPrefix(args[i], "--"), args[i+1])
+	}
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("unsupported list options: %v", args)
+	}
+
+	var list listResponse
+	err := c.callJSON(ctx, "GET", fmt.Sprintf("/list/object/%s", objType), query, "", nil, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
+func (c *client) DeleteAttachment(ctx context.Context, itemId, attachmentId string) error {
+	_, err := c.call(ctx, "DELETE", objectPath(bw.ObjectTypeAttachment, attachmentId), url.Values{"itemid": {itemId}}, "", nil)
+	return err
+}
+
+func (c *client) DeleteObject(ctx context.Context, obj bw.Object) error {
+	_, err := c.call(ctx, "DELETE", objectPath(obj.Object, obj.ID), objectQuery(obj), "", nil)
+	return err
+}
+
+func (c *client) Sync(ctx context.Context) error {
+	_, err := c.call(ctx, "POST", "/sync", nil, "", nil)
+	return err
+}
+
+// Import is delegated to the CLI, after which the server needs to sync to
+// see the imported objects.
+func (c *client) Import(ctx context.Context, format, filePath string, options ...bw.ImportOption) error {
+	err := c.Client.Import(ctx, format, filePath, options...)
+	if err != nil {
+		return err
+	}
+	return c.Sync(ctx)
+}
+
+// EditItemCollections is delegated to the CLI, after which the server needs
+// to sync to see the item's new collections.
+func (c *client) EditItemCollections(ctx context.Context, itemId string, collectionIds []string) (*bw.Object, error) {
+	obj, err := c.Client.EditItemCollections(ctx, itemId, collectionIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Sync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error syncing: %w", err)
+	}
+	return obj, nil
+}
+
+func (c *client) LoginWithAPIKey(ctx context.Context, password, clientId, clientSecret string) error {
+	c.stopServer()
+	return c.Client.LoginWithAPIKey(ctx, password, clientId, clientSecret)
+}
+
+func (c *client) LoginWithPassword(ctx context.Context, username, password string, options ...bw.LoginOption) error {
+	c.stopServer()
+	return c.Client.LoginWithPassword(ctx, username, password, options...)
+}
+
+func (c *client) Logout(ctx context.Context) error {
+	c.stopServer()
+	return c.Client.Logout(ctx)
+}
+
+func (c *client) SetServer(ctx context.Context, server string) error {
+	c.stopServer()
+	return c.Client.SetServer(ctx, server)
+}
+
+func (c *client) Unlock(ctx context.Context, password string) error {
+	c.stopServer()
+	return c.Client.Unlock(ctx, password)
+}
+
+func (c *client) callJSON(ctx context.Context, method, path string, query url.Values, contentType string, body []byte, out interface{}) error {
+	data, err := c.call(ctx, method, path, query, contentType, body)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("unable to parse result of '%s %s': %w", method, path, err)
+	}
+	return nil
+}
+
+// call returns the 'data' field of JSON answers, or the raw body of others,
+// like attachments. Failed calls are retried like the CLI command they're
+// equivalent to.
+func (c *client) call(ctx context.Context, method, path string, query url.Values, contentType string, body []byte) ([]byte, error) {
+	args := []string{serveCommand(method, path)}
+	attempts := 0
+	for {
+		attempts = attempts + 1
+		data, err := c.callOnce(ctx, method, path, query, contentType, body)
+		if err == nil || ctx.Err() != nil || !c.cfg.RetryHandler.IsRetryable(args, err, attempts) {
+			return data, err
+		}
+
+		delay := c.cfg.RetryHandler.Backoff(attempts)
+		tflog.Error(ctx, "Retrying 'bw serve' call after error", map[string]interface{}{"error": err, "attempt": attempts, "delay": delay.String()})
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *client) callOnce(ctx context.Context, method, path string, query url.Values, contentType string, body []byte) ([]byte, error) {
+	s, err := c.ensureServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The server writes to the CLI's data directory like the CLI does.
+	release, err := c.limiter.Acquire(ctx, method != "GET")
+	if err != nil {
+		return nil, fmt.Errorf("error waiting to call 'bw serve': %w", err)
+	}
+	defer release()
+
+	reqCtx := ctx
+	if c.cfg.CommandTimeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, c.cfg.CommandTimeout)
+		defer cancel()
+	}
+
+	reqURL := s.baseURL + path
+	if len(query) > 0 {
+		reqURL = fmt.Sprintf("%s?%s", reqURL, query.Encode())
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(reqCtx, method, reqURL, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing '%s %s' request: %w", method, path, err)
+	}
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	tflog.Debug(ctx, "Calling 'bw serve'", map[string]interface{}{"method": method, "path": path})
+	resp, err := c.httpClient.Do(req)
+	if err != nil && errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+		return nil, command.NewTimeoutError([]string{method, path})
+	} else if err != nil {
+		return nil, fmt.Errorf("error calling '%s %s': %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil && errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+		return nil, command.NewTimeoutError([]string{method, path})
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading '%s %s' response: %w", method, path, err)
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error calling '%s %s': status code %d", method, path, resp.StatusCode)
+		}
+		return respBody, nil
+	}
+
+	var envelope response
+	err = json.Unmarshal(respBody, &envelope)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse result of '%s %s': %w", method, path, err)
+	}
+	if !envelope.Success {
+		return nil, remapError(method, path, envelope.Message)
+	}
+	return envelope.Data, nil
+}
+
+// ensureServer returns the running server, after starting it if needed. A
+// server started with another session key is replaced.
+func (c *client) ensureServer(ctx context.Context) (*server, error) {
+	c.serverMu.Lock()
+	defer c.serverMu.Unlock()
+
+	sessionKey := c.Client.GetSessionKey()
+	if c.server != nil && c.server.running() && c.server.sessionKey == sessionKey {
+		return c.server, nil
+	}
+
+	if c.server != nil {
+		c.server.stop()
+	}
+
+	s, err := startServer(ctx, c.cfg, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	c.server = s
+	return s, nil
+}
+
+func (c *client) stopServer() {
+	c.serverMu.Lock()
+	defer c.serverMu.Unlock()
+
+	if c.server != nil {
+		c.server.stop()
+		c.server = nil
+	}
+}
+
+// serveCommand is the CLI command a call is equivalent to, which decides
+// whether it's idempotent.
+func serveCommand(method, path string) string {
+	switch {
+	case path == "/sync":
+		return "sync"
+	case method == "GET":
+		return "get"
+	case method == "PUT":
+		return "edit"
+	case method == "DELETE":
+		return "delete"
+	}
+	return "create"
+}
+
+func objectPath(objType bw.ObjectType, id string) string {
+	if len(id) == 0 {
+		return fmt.Sprintf("/object/%s", objType)
+	}
+	return fmt.Sprintf("/object/%s/%s", objType, url.PathEscape(id))
+}
+
+func objectQuery(obj bw.Object) url.Values {
+	if obj.Object == bw.ObjectTypeOrgCollection {
+		return url.Values{"organizationid": {obj.OrganizationID}}
+	}
+	return nil
+}
+
+func remapError(method, path, message string) error {
+	switch {
+	case message == "Not found.":
+		return bw.ErrObjectNotFound
+	case strings.HasPrefix(message, "Attachment ") && strings.HasSuffix(message, " was not found."):
+		return bw.ErrAttachmentNotFound
+	}
+	return fmt.Errorf("error calling '%s %s': %s", method, path, command.Redact(message))
+}
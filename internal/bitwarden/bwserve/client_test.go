@@ -0,0 +1,227 @@
+package bwserve
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetObject(t *testing.T) {
+	c, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/object/item/item-id":
+			writeJSON(w, true, "", bw.Object{ID: "item-id", Object: bw.ObjectTypeItem, Name: "Item"})
+		default:
+			writeJSON(w, false, "Not found.", nil)
+		}
+	})
+
+	obj, err := c.GetObject(context.Background(), bw.Object{ID: "item-id", Object: bw.ObjectTypeItem})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Item", obj.Name)
+	}
+
+	_, err = c.GetObject(context.Background(), bw.Object{ID: "missing-id", Object: bw.ObjectTypeItem})
+	assert.ErrorIs(t, err, bw.ErrObjectNotFound)
+
+	assert.Equal(t, []string{"GET /object/item/item-id", "GET /object/item/missing-id"}, *requests)
+}
+
+func TestListObjects(t *testing.T) {
+	c, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, true, "", listResponse{Data: []bw.Object{{ID: "folder-id", Object: bw.ObjectTypeFolder}}})
+	})
+
+	objs, err := c.ListObjects(context.Background(), "folders", bw.WithSearch("name"), bw.WithOrganizationID("org-id"))
+	if assert.NoError(t, err) {
+		assert.Len(t, objs, 1)
+	}
+	assert.Equal(t, []string{"GET /list/object/folders?organizationid=org-id&search=name"}, *requests)
+}
+
+func TestEditObjectSyncs(t *testing.T) {
+	c, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sync" {
+			writeJSON(w, true, "", nil)
+			return
+		}
+
+		var obj bw.Object
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&obj))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		writeJSON(w, true, "", obj)
+	})
+
+	obj, err := c.EditObject(context.Background(), bw.Object{ID: "collection-id", Object: bw.ObjectTypeOrgCollection, OrganizationID: "org-id", Name: "New"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "New", obj.Name)
+	}
+	assert.Equal(t, []string{"PUT /object/org-collection/collection-id?organizationid=org-id", "POST /sync"}, *requests)
+}
+
+func TestAttachments(t *testing.T) {
+	c, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			f, header, err := r.FormFile("file")
+			if assert.NoError(t, err) {
+				content, _ := io.ReadAll(f)
+				assert.Equal(t, "content", string(content))
+				assert.Equal(t, "attachment.txt", header.Filename)
+			}
+			writeJSON(w, true, "", bw.Object{ID: "item-id", Object: bw.ObjectTypeItem})
+		case "GET":
+			if r.URL.Path == "/object/attachment/missing-id" {
+				writeJSON(w, false, "Attachment missing-id was not found.", nil)
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("content"))
+		}
+	})
+
+	filePath := filepath.Join(t.TempDir(), "attachment.txt")
+	assert.NoError(t, os.WriteFile(filePath, []byte("content"), 0600))
+
+	_, err := c.CreateAttachment(context.Background(), "item-id", filePath)
+	assert.NoError(t, err)
+
+	content, err := c.GetAttachment(context.Background(), "item-id", "attachment-id")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "content", string(content))
+	}
+
+	_, err = c.GetAttachment(context.Background(), "item-id", "missing-id")
+	assert.ErrorIs(t, err, bw.ErrAttachmentNotFound)
+
+	assert.Equal(t, []string{
+		"POST /attachment?itemid=item-id",
+		"GET /object/attachment/attachment-id?itemid=item-id",
+		"GET /object/attachment/missing-id?itemid=item-id",
+	}, *requests)
+}
+
+func TestCallsTimeOut(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+	})
+	c.cfg.CommandTimeout = 50 * time.Millisecond
+	c.cfg.RetryHandler = bw.NewRetryHandler(0, 0, true)
+
+	_, err := c.GetObject(context.Background(), bw.Object{ID: "item-id", Object: bw.ObjectTypeItem})
+	assert.True(t, command.IsTimeout(err))
+	assert.ErrorContains(t, err, "timeout while running 'GET /object/item/item-id'")
+}
+
+func TestCallsRetried(t *testing.T) {
+	creations := 0
+	c, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			creations++
+		}
+		switch {
+		case r.Method == "POST" && creations == 1:
+			writeJSON(w, false, "Rate limit exceeded. Try again later.", nil)
+		case r.Method == "POST":
+			var obj bw.Object
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&obj))
+			writeJSON(w, true, "", obj)
+		case r.URL.Path == "/object/item/item-id":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	c.cfg.RetryHandler = bw.NewRetryHandler(3, 0, true)
+
+	// Server errors are only retried for idempotent calls.
+	_, err := c.GetObject(context.Background(), bw.Object{ID: "item-id", Object: bw.ObjectTypeItem})
+	assert.ErrorContains(t, err, "status code 503")
+
+	// Rate limited calls weren't processed, and are always retried.
+	obj, err := c.CreateObject(context.Background(), bw.Object{Object: bw.ObjectTypeItem, Name: "Item"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Item", obj.Name)
+	}
+
+	assert.Equal(t, []string{
+		"GET /object/item/item-id",
+		"GET /object/item/item-id",
+		"GET /object/item/item-id",
+		"POST /object/item",
+		"POST /object/item",
+	}, *requests)
+}
+
+func TestServerRestartedWhenSessionChanges(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {})
+	running := c.server
+
+	c.Client.SetSessionKey("new-session-key")
+	c.cfg.ExecPath = filepath.Join(t.TempDir(), "missing-bw")
+	_, err := c.ensureServer(context.Background())
+
+	assert.ErrorContains(t, err, "error starting 'bw serve'")
+	assert.False(t, running.running())
+}
+
+func TestServerOutputBounded(t *testing.T) {
+	output := &boundedBuffer{max: 8}
+
+	output.Write([]byte("0123456"))
+	output.Write([]byte("789"))
+
+	assert.Equal(t, "23456789", output.String())
+}
+
+// newTestClient returns a client whose server is already running, and
+// records the requests it receives.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*client, *[]string) {
+	requests := []string{}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := r.Method + " " + r.URL.Path
+		if len(r.URL.RawQuery) > 0 {
+			request += "?" + r.URL.RawQuery
+		}
+		requests = append(requests, request)
+		handler(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+
+	// The test binary stands in for the process, until it's interrupted.
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+
+	c := NewClient(bw.NewClient("dummy"), Config{AppDataDir: t.TempDir()}).(*client)
+	c.server = &server{baseURL: httpServer.URL, cmd: cmd, done: make(chan struct{})}
+	assert.NoError(t, c.server.start())
+	t.Cleanup(StopAll)
+	return c, &requests
+}
+
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func writeJSON(w http.ResponseWriter, success bool, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !success {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	rawData, _ := json.Marshal(data)
+	json.NewEncoder(w).Encode(response{Success: success, Message: message, Data: rawData})
+}
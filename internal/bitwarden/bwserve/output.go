@@ -0,0 +1,34 @@
+package bwserve
+
+import (
+	"sync"
+)
+
+// maxOutputSize is how much of the output of 'bw serve' is kept to explain
+// why it failed to start.
+const maxOutputSize = 64 * 1024
+
+// boundedBuffer keeps the last bytes written to it, as the process can run
+// and log for the provider's whole lifetime.
+type boundedBuffer struct {
+	buf []byte
+	max int
+	mu  sync.Mutex
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = append([]byte(nil), b.buf[len(b.buf)-b.max:]...)
+	}
+	return len(p), nil
+}
+
+func (b *boundedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
@@ -0,0 +1,184 @@
+package bwserve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+)
+
+const (
+	serverStartTimeout = 2 * time.Minute
+	serverStopTimeout  = 10 * time.Second
+	serverPollInterval = 200 * time.Millisecond
+)
+
+var (
+	runningServers   = map[*server]struct{}{}
+	runningServersMu sync.Mutex
+)
+
+// StopAll stops the 'bw serve' processes which are still running. It's meant
+// to be called when the plugin exits.
+func StopAll() {
+	runningServersMu.Lock()
+	servers := make([]*server, 0, len(runningServers))
+	for s := range runningServers {
+		servers = append(servers, s)
+	}
+	runningServersMu.Unlock()
+
+	for _, s := range servers {
+		s.stop()
+	}
+}
+
+// server is a 'bw serve' process, listening on a random port of the loopback
+// interface.
+type server struct {
+	baseURL    string
+	cmd        *exec.Cmd
+	done       chan struct{}
+	output     *boundedBuffer
+	sessionKey string
+}
+
+func startServer(ctx context.Context, cfg Config, sessionKey string) (*server, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, fmt.Errorf("error finding a free port for 'bw serve': %w", err)
+	}
+
+	s := &server{
+		baseURL:    fmt.Sprintf("http://127.0.0.1:%d", port),
+		done:       make(chan struct{}),
+		output:     &boundedBuffer{max: maxOutputSize},
+		sessionKey: sessionKey,
+	}
+
+	// The process outlives the request it's started for, and can't be bound
+	// to its context. It listens on TCP, as '--hostname' and '--port' are the
+	// only listen options 'bw serve' documents, and none of them accepts a
+	// Unix socket whose permissions would restrict access to the user.
+	s.cmd = exec.Command(cfg.ExecPath, "serve", "--hostname", "127.0.0.1", "--port", strconv.Itoa(port))
+	s.cmd.Env = []string{
+		fmt.Sprintf("PATH=%s", os.Getenv("PATH")),
+		fmt.Sprintf("BITWARDENCLI_APPDATA_DIR=%s", cfg.AppDataDir),
+		fmt.Sprintf("BW_SESSION=%s", sessionKey),
+		"BW_NOINTERACTION=true",
+	}
+	if len(cfg.ExtraCACertsPath) > 0 {
+		s.cmd.Env = append(s.cmd.Env, fmt.Sprintf("NODE_EXTRA_CA_CERTS=%s", cfg.ExtraCACertsPath))
+	}
+	s.cmd.Stdout = s.output
+	s.cmd.Stderr = s.output
+	stopWithParent(s.cmd)
+
+	tflog.Debug(ctx, "Starting 'bw serve'", map[string]interface{}{"url": s.baseURL})
+	err = s.start()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.waitReady(ctx)
+	if err != nil {
+		s.stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *server) start() error {
+	err := s.cmd.Start()
+	if err != nil {
+		return fmt.Errorf("error starting 'bw serve': %w", err)
+	}
+
+	runningServersMu.Lock()
+	runningServers[s] = struct{}{}
+	runningServersMu.Unlock()
+
+	go func() {
+		s.cmd.Wait()
+		close(s.done)
+
+		runningServersMu.Lock()
+		delete(runningServers, s)
+		runningServersMu.Unlock()
+	}()
+	return nil
+}
+
+func (s *server) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, serverStartTimeout)
+	defer cancel()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/status", nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-s.done:
+			return fmt.Errorf("'bw serve' exited before being ready: %s", command.Redact(s.output.String()))
+		case <-ctx.Done():
+			return fmt.Errorf("'bw serve' wasn't ready in time: %w", ctx.Err())
+		case <-time.After(serverPollInterval):
+		}
+	}
+}
+
+func (s *server) running() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// stop interrupts the process, and kills it if it didn't exit in time.
+func (s *server) stop() {
+	if !s.running() {
+		return
+	}
+
+	// Windows doesn't support sending interrupts to processes.
+	if runtime.GOOS == "windows" || s.cmd.Process.Signal(os.Interrupt) != nil {
+		s.cmd.Process.Kill()
+	}
+
+	select {
+	case <-s.done:
+	case <-time.After(serverStopTimeout):
+		s.cmd.Process.Kill()
+		<-s.done
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,14 @@
+//go:build linux
+
+package bwserve
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// stopWithParent has the kernel terminate the process when the plugin exits,
+// even if it's killed before it could stop it.
+func stopWithParent(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGTERM}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package bwserve
+
+import (
+	"os/exec"
+)
+
+// stopWithParent does nothing on platforms without a parent death signal,
+// where the process is only stopped when the plugin exits normally.
+func stopWithParent(cmd *exec.Cmd) {}
@@ -0,0 +1,131 @@
+package exportfile
+
+import (
+	"time"
+)
+
+// File is the content of a Bitwarden JSON export, either of a Vault or of an
+// organization's Vault. When Encrypted is set, all the names and secrets are
+// encrypted with the key of the account, or of the organization, it was
+// exported from.
+type File struct {
+	Encrypted        bool         `json:"encrypted"`
+	EncKeyValidation string       `json:"encKeyValidation_DO_NOT_EDIT,omitempty"`
+	Folders          []Folder     `json:"folders,omitempty"`
+	Collections      []Collection `json:"collections,omitempty"`
+	Items            []Item       `json:"items"`
+}
+
+// passwordProtectedFile wraps a File encrypted with a key derived from a
+// password, with the settings needed to derive it again.
+type passwordProtectedFile struct {
+	Encrypted         bool   `json:"encrypted"`
+	PasswordProtected bool   `json:"passwordProtected"`
+	Salt              string `json:"salt"`
+	KdfType           int    `json:"kdfType"`
+	KdfIterations     int    `json:"kdfIterations"`
+	KdfMemory         *int   `json:"kdfMemory"`
+	KdfParallelism    *int   `json:"kdfParallelism"`
+	EncKeyValidation  string `json:"encKeyValidation_DO_NOT_EDIT"`
+	Data              string `json:"data"`
+}
+
+type Folder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Collection struct {
+	ID             string `json:"id"`
+	OrganizationID string `json:"organizationId"`
+	Name           string `json:"name"`
+	ExternalID     string `json:"externalId,omitempty"`
+}
+
+// Item is an item of an export. Exports don't include attachments.
+type Item struct {
+	ID              string            `json:"id"`
+	OrganizationID  string            `json:"organizationId,omitempty"`
+	FolderID        string            `json:"folderId,omitempty"`
+	Type            int               `json:"type"`
+	Reprompt        int               `json:"reprompt"`
+	Key             string            `json:"key,omitempty"`
+	Name            string            `json:"name"`
+	Notes           string            `json:"notes,omitempty"`
+	Favorite        bool              `json:"favorite"`
+	Login           *Login            `json:"login,omitempty"`
+	SecureNote      *SecureNote       `json:"secureNote,omitempty"`
+	Card            *Card             `json:"card,omitempty"`
+	Identity        *Identity         `json:"identity,omitempty"`
+	SSHKey          *SSHKey           `json:"sshKey,omitempty"`
+	Fields          []Field           `json:"fields,omitempty"`
+	PasswordHistory []PasswordHistory `json:"passwordHistory,omitempty"`
+	CollectionIds   []string          `json:"collectionIds,omitempty"`
+	CreationDate    *time.Time        `json:"creationDate,omitempty"`
+	RevisionDate    *time.Time        `json:"revisionDate,omitempty"`
+	DeletedDate     *time.Time        `json:"deletedDate,omitempty"`
+}
+
+type Login struct {
+	Uris     []LoginUri `json:"uris,omitempty"`
+	Username string     `json:"username,omitempty"`
+	Password string     `json:"password,omitempty"`
+	Totp     string     `json:"totp,omitempty"`
+}
+
+type LoginUri struct {
+	Match *int   `json:"match"`
+	Uri   string `json:"uri"`
+}
+
+type SecureNote struct {
+	Type int `json:"type"`
+}
+
+type Card struct {
+	CardholderName string `json:"cardholderName,omitempty"`
+	Brand          string `json:"brand,omitempty"`
+	Number         string `json:"number,omitempty"`
+	ExpMonth       string `json:"expMonth,omitempty"`
+	ExpYear        string `json:"expYear,omitempty"`
+	Code           string `json:"code,omitempty"`
+}
+
+type Identity struct {
+	Title          string `json:"title,omitempty"`
+	FirstName      string `json:"firstName,omitempty"`
+	MiddleName     string `json:"middleName,omitempty"`
+	LastName       string `json:"lastName,omitempty"`
+	Address1       string `json:"address1,omitempty"`
+	Address2       string `json:"address2,omitempty"`
+	Address3       string `json:"address3,omitempty"`
+	City           string `json:"city,omitempty"`
+	State          string `json:"state,omitempty"`
+	PostalCode     string `json:"postalCode,omitempty"`
+	Country        string `json:"country,omitempty"`
+	Company        string `json:"company,omitempty"`
+	Email          string `json:"email,omitempty"`
+	Phone          string `json:"phone,omitempty"`
+	SSN            string `json:"ssn,omitempty"`
+	Username       string `json:"username,omitempty"`
+	PassportNumber string `json:"passportNumber,omitempty"`
+	LicenseNumber  string `json:"licenseNumber,omitempty"`
+}
+
+type SSHKey struct {
+	PrivateKey     string `json:"privateKey,omitempty"`
+	PublicKey      string `json:"publicKey,omitempty"`
+	KeyFingerprint string `json:"keyFingerprint,omitempty"`
+}
+
+type PasswordHistory struct {
+	LastUsedDate *time.Time `json:"lastUsedDate,omitempty"`
+	Password     string     `json:"password"`
+}
+
+type Field struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Type     int    `json:"type"`
+	LinkedId *int   `json:"linkedId"`
+}
@@ -0,0 +1,155 @@
+package exportfile
+
+import (
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+)
+
+// Objects returns the content of a decrypted export in the same format as the
+// one the CLI returns.
+func (f *File) Objects() []bw.Object {
+	objs := []bw.Object{}
+	for _, folder := range f.Folders {
+		objs = append(objs, bw.Object{
+			ID:     folder.ID,
+			Name:   folder.Name,
+			Object: bw.ObjectTypeFolder,
+		})
+	}
+
+	for _, collection := range f.Collections {
+		objs = append(objs, bw.Object{
+			ExternalID:     collection.ExternalID,
+			ID:             collection.ID,
+			Name:           collection.Name,
+			Object:         bw.ObjectTypeOrgCollection,
+			OrganizationID: collection.OrganizationID,
+		})
+	}
+
+	for _, item := range f.Items {
+		objs = append(objs, itemToObject(item))
+	}
+	return objs
+}
+
+// NewFile builds an unencrypted export out of folders, collections and items.
+// Other objects are ignored.
+func NewFile(objs []bw.Object) *File {
+	f := &File{Items: []Item{}}
+	for _, obj := range objs {
+		switch obj.Object {
+		case bw.ObjectTypeFolder:
+			f.Folders = append(f.Folders, Folder{
+				ID:   obj.ID,
+				Name: obj.Name,
+			})
+		case bw.ObjectTypeOrgCollection:
+			f.Collections = append(f.Collections, Collection{
+				ExternalID:     obj.ExternalID,
+				ID:             obj.ID,
+				Name:           obj.Name,
+				OrganizationID: obj.OrganizationID,
+			})
+		case bw.ObjectTypeItem:
+			f.Items = append(f.Items, objectToItem(obj))
+		}
+	}
+	return f
+}
+
+// itemToObject only maps the details of logins and secure notes, as bw.Object
+// has no fields for the other item types. Those keep their type, for callers
+// to tell them apart.
+func itemToObject(item Item) bw.Object {
+	obj := bw.Object{
+		CollectionIds:  item.CollectionIds,
+		CreationDate:   item.CreationDate,
+		DeletedDate:    item.DeletedDate,
+		Favorite:       item.Favorite,
+		FolderID:       item.FolderID,
+		ID:             item.ID,
+		Name:           item.Name,
+		Notes:          item.Notes,
+		Object:         bw.ObjectTypeItem,
+		OrganizationID: item.OrganizationID,
+		Reprompt:       item.Reprompt,
+		RevisionDate:   item.RevisionDate,
+		Type:           bw.ItemType(item.Type),
+	}
+
+	if item.Login != nil {
+		obj.Login = bw.Login{
+			Username: item.Login.Username,
+			Password: item.Login.Password,
+			Totp:     item.Login.Totp,
+		}
+		for _, uri := range item.Login.Uris {
+			loginURI := bw.LoginURI{URI: uri.Uri}
+			if uri.Match != nil {
+				match := bw.URIMatch(*uri.Match)
+				loginURI.Match = &match
+			}
+			obj.Login.URIs = append(obj.Login.URIs, loginURI)
+		}
+	}
+
+	if item.SecureNote != nil {
+		obj.SecureNote.Type = item.SecureNote.Type
+	}
+
+	for _, field := range item.Fields {
+		obj.Fields = append(obj.Fields, bw.Field{
+			LinkedId: field.LinkedId,
+			Name:     field.Name,
+			Type:     bw.FieldType(field.Type),
+			Value:    field.Value,
+		})
+	}
+	return obj
+}
+
+func objectToItem(obj bw.Object) Item {
+	item := Item{
+		CollectionIds:  obj.CollectionIds,
+		CreationDate:   obj.CreationDate,
+		DeletedDate:    obj.DeletedDate,
+		Favorite:       obj.Favorite,
+		FolderID:       obj.FolderID,
+		ID:             obj.ID,
+		Name:           obj.Name,
+		Notes:          obj.Notes,
+		OrganizationID: obj.OrganizationID,
+		Reprompt:       obj.Reprompt,
+		RevisionDate:   obj.RevisionDate,
+		Type:           int(obj.Type),
+	}
+
+	switch obj.Type {
+	case bw.ItemTypeLogin:
+		item.Login = &Login{
+			Username: obj.Login.Username,
+			Password: obj.Login.Password,
+			Totp:     obj.Login.Totp,
+		}
+		for _, uri := range obj.Login.URIs {
+			loginUri := LoginUri{Uri: uri.URI}
+			if uri.Match != nil {
+				match := int(*uri.Match)
+				loginUri.Match = &match
+			}
+			item.Login.Uris = append(item.Login.Uris, loginUri)
+		}
+	case bw.ItemTypeSecureNote:
+		item.SecureNote = &SecureNote{Type: obj.SecureNote.Type}
+	}
+
+	for _, field := range obj.Fields {
+		item.Fields = append(item.Fields, Field{
+			LinkedId: field.LinkedId,
+			Name:     field.Name,
+			Type:     int(field.Type),
+			Value:    field.Value,
+		})
+	}
+	return item
+}
@@ -0,0 +1,205 @@
+package exportfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+var (
+	ErrPasswordRequired = errors.New("export is password protected")
+	ErrWrongKey         = errors.New("export can't be decrypted with the given password or key")
+)
+
+// Read parses a JSON export. Password-protected exports are decrypted with the
+// password. Account-encrypted exports are returned as is, and need to be
+// decrypted with Decrypt.
+func Read(raw []byte, password string) (*File, error) {
+	var header struct {
+		PasswordProtected bool `json:"passwordProtected"`
+	}
+	err := json.Unmarshal(raw, &header)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling export: %w", err)
+	}
+
+	if header.PasswordProtected {
+		raw, err = decryptPasswordProtected(raw, password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var file File
+	err = json.Unmarshal(raw, &file)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling export: %w", err)
+	}
+	return &file, nil
+}
+
+// Decrypt decrypts an account-encrypted export in place, with the key of the
+// account or organization it was exported from.
+func (f *File) Decrypt(key symmetrickey.Key) error {
+	if !f.Encrypted {
+		return nil
+	}
+
+	_, err := crypto.DecryptString(f.EncKeyValidation, key)
+	if err != nil {
+		return ErrWrongKey
+	}
+
+	err = f.transform(key, func(value string, key symmetrickey.Key) (string, error) {
+		decrypted, err := crypto.DecryptString(value, key)
+		return string(decrypted), err
+	}, decryptItemKey)
+	if err != nil {
+		return fmt.Errorf("error decrypting export: %w", err)
+	}
+
+	f.Encrypted = false
+	f.EncKeyValidation = ""
+	return nil
+}
+
+func decryptItemKey(item *Item, key symmetrickey.Key) (symmetrickey.Key, error) {
+	if len(item.Key) == 0 {
+		return key, nil
+	}
+
+	itemKey, err := crypto.DecryptSymmetricKey(item.Key, key)
+	if err != nil {
+		return key, fmt.Errorf("error decrypting key of item '%s': %w", item.ID, err)
+	}
+
+	// Once decrypted, the item's content doesn't depend on its key anymore.
+	item.Key = ""
+	return *itemKey, nil
+}
+
+func decryptPasswordProtected(raw []byte, password string) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, ErrPasswordRequired
+	}
+
+	var wrapper passwordProtectedFile
+	err := json.Unmarshal(raw, &wrapper)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling password protected export: %w", err)
+	}
+
+	key, err := passwordProtectionKey(password, wrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = crypto.DecryptString(wrapper.EncKeyValidation, *key)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+
+	data, err := crypto.DecryptString(wrapper.Data, *key)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting password protected export: %w", err)
+	}
+	return data, nil
+}
+
+// passwordProtectionKey derives the key of a password-protected export, the
+// same way PIN keys are.
+func passwordProtectionKey(password string, wrapper passwordProtectedFile) (*symmetrickey.Key, error) {
+	kdf := keybuilder.KdfConfig{
+		Type:       wrapper.KdfType,
+		Iterations: wrapper.KdfIterations,
+	}
+	if wrapper.KdfMemory != nil {
+		kdf.Memory = *wrapper.KdfMemory
+	}
+	if wrapper.KdfParallelism != nil {
+		kdf.Parallelism = *wrapper.KdfParallelism
+	}
+
+	key, err := keybuilder.BuildKey(password, wrapper.Salt, kdf)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving export key: %w", err)
+	}
+
+	stretchedKey, err := key.StretchKey()
+	if err != nil {
+		return nil, fmt.Errorf("error stretching export key: %w", err)
+	}
+	return stretchedKey, nil
+}
+
+// transform applies a function to every name and secret of the export. Items
+// can have their own key, which is obtained through itemKey.
+func (f *File) transform(key symmetrickey.Key, fn func(string, symmetrickey.Key) (string, error), itemKey func(*Item, symmetrickey.Key) (symmetrickey.Key, error)) error {
+	apply := func(value *string, key symmetrickey.Key) error {
+		if len(*value) == 0 {
+			return nil
+		}
+		var err error
+		*value, err = fn(*value, key)
+		return err
+	}
+
+	for i := range f.Folders {
+		err := apply(&f.Folders[i].Name, key)
+		if err != nil {
+			return fmt.Errorf("folder '%s': %w", f.Folders[i].ID, err)
+		}
+	}
+
+	for i := range f.Collections {
+		err := apply(&f.Collections[i].Name, key)
+		if err != nil {
+			return fmt.Errorf("collection '%s': %w", f.Collections[i].ID, err)
+		}
+	}
+
+	for i := range f.Items {
+		item := &f.Items[i]
+		key, err := itemKey(item, key)
+		if err != nil {
+			return err
+		}
+
+		values := []*string{&item.Name, &item.Notes}
+		if item.Login != nil {
+			values = append(values, &item.Login.Username, &item.Login.Password, &item.Login.Totp)
+			for j := range item.Login.Uris {
+				values = append(values, &item.Login.Uris[j].Uri)
+			}
+		}
+		if c := item.Card; c != nil {
+			values = append(values, &c.CardholderName, &c.Brand, &c.Number, &c.ExpMonth, &c.ExpYear, &c.Code)
+		}
+		if id := item.Identity; id != nil {
+			values = append(values, &id.Title, &id.FirstName, &id.MiddleName, &id.LastName,
+				&id.Address1, &id.Address2, &id.Address3, &id.City, &id.State, &id.PostalCode, &id.Country,
+				&id.Company, &id.Email, &id.Phone, &id.SSN, &id.Username, &id.PassportNumber, &id.LicenseNumber)
+		}
+		if k := item.SSHKey; k != nil {
+			values = append(values, &k.PrivateKey, &k.PublicKey, &k.KeyFingerprint)
+		}
+		for j := range item.Fields {
+			values = append(values, &item.Fields[j].Name, &item.Fields[j].Value)
+		}
+		for j := range item.PasswordHistory {
+			values = append(values, &item.PasswordHistory[j].Password)
+		}
+
+		for _, value := range values {
+			err = apply(value, key)
+			if err != nil {
+				return fmt.Errorf("item '%s': %w", item.ID, err)
+			}
+		}
+	}
+	return nil
+}
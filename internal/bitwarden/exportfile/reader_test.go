@@ -0,0 +1,189 @@
+package exportfile
+
+import (
+	"crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadUnencrypted(t *testing.T) {
+	raw, err := os.ReadFile("fixtures/export.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f, err := Read(raw, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	objs := f.Objects()
+	if !assert.Len(t, objs, 3) {
+		return
+	}
+	assert.Equal(t, bw.ObjectTypeFolder, objs[0].Object)
+	assert.Equal(t, "folder-1", objs[0].Name)
+
+	login := objs[1]
+	assert.Equal(t, bw.ObjectTypeItem, login.Object)
+	assert.Equal(t, bw.ItemTypeLogin, login.Type)
+	assert.Equal(t, "5a3bb2d0-5d4b-4f5f-8a0a-2f8e1f6a1c01", login.FolderID)
+	assert.Equal(t, "username-1", login.Login.Username)
+	assert.Equal(t, "password-1", login.Login.Password)
+	assert.True(t, login.Favorite)
+	if assert.Len(t, login.Login.URIs, 1) {
+		assert.Equal(t, "https://example.com", login.Login.URIs[0].URI)
+		assert.Equal(t, bw.URIMatchExact, *login.Login.URIs[0].Match)
+	}
+	if assert.Len(t, login.Fields, 1) {
+		assert.Equal(t, bw.FieldTypeHidden, login.Fields[0].Type)
+		assert.Equal(t, "value-1", login.Fields[0].Value)
+	}
+
+	note := objs[2]
+	assert.Equal(t, bw.ItemTypeSecureNote, note.Type)
+	assert.Equal(t, "notes-1", note.Notes)
+}
+
+func TestWriteReadAccountEncrypted(t *testing.T) {
+	f := testFile(t)
+	key := testKey(t)
+
+	err := f.Encrypt(*key)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, f.Encrypted)
+	assert.NotEqual(t, "login-1", f.Items[0].Name)
+	assert.NotEqual(t, "password-1", f.Items[0].Login.Password)
+
+	raw, err := Write(f)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	readFile, err := Read(raw, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = readFile.Decrypt(*testKey(t))
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	err = readFile.Decrypt(*key)
+	if assert.NoError(t, err) {
+		assert.Equal(t, testFile(t), readFile)
+	}
+}
+
+func TestWriteReadPasswordProtected(t *testing.T) {
+	kdfs := map[string]keybuilder.KdfConfig{
+		"pbkdf2":   {Type: keybuilder.PBKDF2_SHA256, Iterations: 1000},
+		"argon2id": {Type: keybuilder.ARGON2ID, Iterations: 1, Memory: 1, Parallelism: 1},
+	}
+
+	for name, kdf := range kdfs {
+		t.Run(name, func(t *testing.T) {
+			raw, err := WritePasswordProtected(testFile(t), "export-password", kdf)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NotContains(t, string(raw), "password-1")
+
+			_, err = Read(raw, "")
+			assert.ErrorIs(t, err, ErrPasswordRequired)
+
+			_, err = Read(raw, "wrong-password")
+			assert.ErrorIs(t, err, ErrWrongKey)
+
+			f, err := Read(raw, "export-password")
+			if assert.NoError(t, err) {
+				assert.Equal(t, testFile(t), f)
+			}
+		})
+	}
+}
+
+func TestWriteReadCardAndIdentity(t *testing.T) {
+	f := readFixture(t, "fixtures/export-card-identity.json")
+	if !assert.Len(t, f.Items, 3) {
+		return
+	}
+	assert.Equal(t, "4111111111111111", f.Items[0].Card.Number)
+	assert.Equal(t, "old-password-1", f.Items[0].PasswordHistory[0].Password)
+	assert.Equal(t, "passport-1", f.Items[1].Identity.PassportNumber)
+	assert.Equal(t, "private-key-1", f.Items[2].SSHKey.PrivateKey)
+
+	key := testKey(t)
+	err := f.Encrypt(*key)
+	if !assert.NoError(t, err) {
+		return
+	}
+	raw, err := Write(f)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, secret := range []string{"4111111111111111", "old-password-1", "passport-1", "ssn-1", "private-key-1"} {
+		assert.NotContains(t, string(raw), secret)
+	}
+
+	readFile, err := Read(raw, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = readFile.Decrypt(*key)
+	if assert.NoError(t, err) {
+		assert.Equal(t, readFixture(t, "fixtures/export-card-identity.json"), readFile)
+	}
+
+	raw, err = WritePasswordProtected(readFile, "export-password", keybuilder.KdfConfig{Type: keybuilder.PBKDF2_SHA256, Iterations: 1000})
+	if !assert.NoError(t, err) {
+		return
+	}
+	readFile, err = Read(raw, "export-password")
+	if assert.NoError(t, err) {
+		assert.Equal(t, readFixture(t, "fixtures/export-card-identity.json"), readFile)
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	f := testFile(t)
+
+	assert.Equal(t, f, NewFile(f.Objects()))
+}
+
+func testFile(t *testing.T) *File {
+	return readFixture(t, "fixtures/export.json")
+}
+
+func readFixture(t *testing.T, path string) *File {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Read(raw, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func testKey(t *testing.T) *symmetrickey.Key {
+	rawKey := make([]byte, 64)
+	_, err := rand.Read(rawKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := symmetrickey.NewFromRawBytes(rawKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -0,0 +1,110 @@
+package exportfile
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+// Write serializes an export the same way Bitwarden clients do.
+func Write(f *File) ([]byte, error) {
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling export: %w", err)
+	}
+	return raw, nil
+}
+
+// WritePasswordProtected serializes an export and encrypts it with a key
+// derived from a password.
+func WritePasswordProtected(f *File, password string, kdf keybuilder.KdfConfig) ([]byte, error) {
+	data, err := Write(f)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("error generating salt: %w", err)
+	}
+
+	wrapper := passwordProtectedFile{
+		Encrypted:         true,
+		PasswordProtected: true,
+		Salt:              base64.StdEncoding.EncodeToString(salt),
+		KdfType:           kdf.Type,
+		KdfIterations:     kdf.Iterations,
+	}
+	if kdf.Type == keybuilder.ARGON2ID {
+		wrapper.KdfMemory = &kdf.Memory
+		wrapper.KdfParallelism = &kdf.Parallelism
+	}
+
+	key, err := passwordProtectionKey(password, wrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper.EncKeyValidation, err = encKeyValidation(*key)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper.Data, err = crypto.Encrypt(data, *key)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting export: %w", err)
+	}
+
+	raw, err := json.MarshalIndent(wrapper, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling password protected export: %w", err)
+	}
+	return raw, nil
+}
+
+// Encrypt turns an export into an account-encrypted one, in place, with the
+// key of the account or organization it belongs to.
+func (f *File) Encrypt(key symmetrickey.Key) error {
+	if f.Encrypted {
+		return nil
+	}
+
+	err := f.transform(key, func(value string, key symmetrickey.Key) (string, error) {
+		return crypto.Encrypt([]byte(value), key)
+	}, func(_ *Item, key symmetrickey.Key) (symmetrickey.Key, error) {
+		return key, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error encrypting export: %w", err)
+	}
+
+	f.EncKeyValidation, err = encKeyValidation(key)
+	if err != nil {
+		return err
+	}
+	f.Encrypted = true
+	return nil
+}
+
+// encKeyValidation encrypts a random value, which is only used to check that
+// a key is correct before decrypting an export with it.
+func encKeyValidation(key symmetrickey.Key) (string, error) {
+	value := make([]byte, 16)
+	_, err := rand.Read(value)
+	if err != nil {
+		return "", fmt.Errorf("error generating key validation value: %w", err)
+	}
+
+	guid := fmt.Sprintf("%x-%x-%x-%x-%x", value[0:4], value[4:6], value[6:8], value[8:10], value[10:16])
+	encrypted, err := crypto.Encrypt([]byte(guid), key)
+	if err != nil {
+		return "", fmt.Errorf("error encrypting key validation value: %w", err)
+	}
+	return encrypted, nil
+}
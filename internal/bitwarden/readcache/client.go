@@ -0,0 +1,275 @@
+package readcache
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+)
+
+/*
+* This is a bw.Client serving reads from memory for the duration of a run.
+* Items, folders and the collections of an organization are listed once with
+* the CLI when they're first read, and GetObject and ListObjects then filter
+* those lists the way the CLI would.
+*
+* Objects are copied before being returned, so that callers modifying them
+* don't modify the lists.
+*
+* Writes invalidate the lists of the objects they touch, which are listed
+* again on the next read. Reads which can't be answered from a list, like
+* objects in the trash or filters on URLs, are delegated to the CLI.
+ */
+
+func NewClient(bwClient bw.Client) bw.Client {
+	return &client{
+		Client:   bwClient,
+		listings: map[listingKey]*listing{},
+	}
+}
+
+type client struct {
+	bw.Client
+
+	listings map[listingKey]*listing
+	hits     int
+	misses   int
+	mu       sync.Mutex
+}
+
+// listingKey identifies a list of objects. Collections can only be listed per
+// organization.
+type listingKey struct {
+	objType        bw.ObjectType
+	organizationID string
+}
+
+// listing is loaded once, by the first read which needs it.
+type listing struct {
+	objects []bw.Object
+	loaded  bool
+	mu      sync.Mutex
+}
+
+func (c *client) GetObject(ctx context.Context, obj bw.Object) (*bw.Object, error) {
+	// Collections returned by 'get' have details their list doesn't.
+	if obj.Object != bw.ObjectTypeItem && obj.Object != bw.ObjectTypeFolder {
+		return c.Client.GetObject(ctx, obj)
+	}
+
+	objs, listed, err := c.list(ctx, listingKey{objType: obj.Object})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cached := range objs {
+		if cached.ID == obj.ID {
+			c.record(ctx, obj.Object, !listed)
+			found := copyObject(cached)
+			return &found, nil
+		}
+	}
+
+	// The object might be in the trash, or created by someone else since.
+	c.record(ctx, obj.Object, false)
+	return c.Client.GetObject(ctx, obj)
+}
+
+func (c *client) ListObjects(ctx context.Context, objType string, options ...bw.ListObjectsOption) ([]bw.Object, error) {
+	filters, err := bw.NewListFilters(options...)
+	key, ok := listingKeyFor(objType, filters)
+	if err != nil || !ok {
+		c.record(ctx, bw.ObjectType(strings.TrimSuffix(objType, "s")), false)
+		return c.Client.ListObjects(ctx, objType, options...)
+	}
+
+	objs, listed, err := c.list(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.record(ctx, key.objType, !listed)
+
+	filtered := []bw.Object{}
+	for _, obj := range objs {
+		if filters.Match(obj) {
+			filtered = append(filtered, copyObject(obj))
+		}
+	}
+	return filtered, nil
+}
+
+func (c *client) CreateAttachment(ctx context.Context, itemId, filePath string) (*bw.Object, error) {
+	defer c.invalidate(listingKey{objType: bw.ObjectTypeItem})
+	return c.Client.CreateAttachment(ctx, itemId, filePath)
+}
+
+func (c *client) CreateObject(ctx context.Context, obj bw.Object) (*bw.Object, error) {
+	defer c.invalidate(listingKeyOf(obj))
+	return c.Client.CreateObject(ctx, obj)
+}
+
+func (c *client) EditItemCollections(ctx context.Context, itemId string, collectionIds []string) (*bw.Object, error) {
+	defer c.invalidate(listingKey{objType: bw.ObjectTypeItem})
+	return c.Client.EditItemCollections(ctx, itemId, collectionIds)
+}
+
+func (c *client) EditObject(ctx context.Context, obj bw.Object) (*bw.Object, error) {
+	defer c.invalidate(listingKeyOf(obj))
+	return c.Client.EditObject(ctx, obj)
+}
+
+func (c *client) DeleteAttachment(ctx context.Context, itemId, attachmentId string) error {
+	defer c.invalidate(listingKey{objType: bw.ObjectTypeItem})
+	return c.Client.DeleteAttachment(ctx, itemId, attachmentId)
+}
+
+func (c *client) DeleteObject(ctx context.Context, obj bw.Object) error {
+	defer c.invalidate(listingKeyOf(obj))
+	return c.Client.DeleteObject(ctx, obj)
+}
+
+func (c *client) Import(ctx context.Context, format, filePath string, options ...bw.ImportOption) error {
+	defer c.invalidateAll()
+	return c.Client.Import(ctx, format, filePath, options...)
+}
+
+func (c *client) LoginWithAPIKey(ctx context.Context, password, clientId, clientSecret string) error {
+	defer c.invalidateAll()
+	return c.Client.LoginWithAPIKey(ctx, password, clientId, clientSecret)
+}
+
+func (c *client) LoginWithPassword(ctx context.Context, username, password string, options ...bw.LoginOption) error {
+	defer c.invalidateAll()
+	return c.Client.LoginWithPassword(ctx, username, password, options...)
+}
+
+func (c *client) Logout(ctx context.Context) error {
+	defer c.invalidateAll()
+	return c.Client.Logout(ctx)
+}
+
+func (c *client) SetServer(ctx context.Context, server string) error {
+	defer c.invalidateAll()
+	return c.Client.SetServer(ctx, server)
+}
+
+func (c *client) Sync(ctx context.Context) error {
+	defer c.invalidateAll()
+	return c.Client.Sync(ctx)
+}
+
+func (c *client) Unlock(ctx context.Context, password string) error {
+	defer c.invalidateAll()
+	return c.Client.Unlock(ctx, password)
+}
+
+// list returns the objects of a listing, and whether they had to be listed
+// with the CLI.
+func (c *client) list(ctx context.Context, key listingKey) ([]bw.Object, bool, error) {
+	c.mu.Lock()
+	l, exists := c.listings[key]
+	if !exists {
+		l = &listing{}
+		c.listings[key] = l
+	}
+	c.mu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.loaded {
+		return l.objects, false, nil
+	}
+
+	options := []bw.ListObjectsOption{}
+	if len(key.organizationID) > 0 {
+		options = append(options, bw.WithOrganizationID(key.organizationID))
+	}
+	objs, err := c.Client.ListObjects(ctx, string(key.objType)+"s", options...)
+	if err != nil {
+		return nil, false, err
+	}
+	l.objects = objs
+	l.loaded = true
+	return l.objects, true, nil
+}
+
+// invalidate drops a listing. Reads already waiting for it still get it, but
+// later ones list the objects again.
+func (c *client) invalidate(key listingKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.listings, key)
+}
+
+func (c *client) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listings = map[listingKey]*listing{}
+}
+
+func (c *client) record(ctx context.Context, objType bw.ObjectType, hit bool) {
+	c.mu.Lock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	fields := map[string]interface{}{"object": objType, "hit": hit, "hits": c.hits, "misses": c.misses}
+	c.mu.Unlock()
+	tflog.Debug(ctx, "Read cache lookup", fields)
+}
+
+// listingKeyFor returns the listing ListObjects can be served from, if any.
+func listingKeyFor(objType string, filters bw.ListFilters) (listingKey, bool) {
+	if len(filters.URL) > 0 {
+		return listingKey{}, false
+	}
+
+	switch t := bw.ObjectType(strings.TrimSuffix(objType, "s")); t {
+	case bw.ObjectTypeItem, bw.ObjectTypeFolder:
+		return listingKey{objType: t}, true
+	case bw.ObjectTypeOrgCollection:
+		return listingKey{objType: t, organizationID: filters.OrganizationID}, len(filters.OrganizationID) > 0
+	}
+	return listingKey{}, false
+}
+
+func listingKeyOf(obj bw.Object) listingKey {
+	if obj.Object == bw.ObjectTypeOrgCollection {
+		return listingKey{objType: obj.Object, organizationID: obj.OrganizationID}
+	}
+	return listingKey{objType: obj.Object}
+}
+
+// copyObject returns a copy of the object which shares no slice or pointer
+// with it.
+func copyObject(obj bw.Object) bw.Object {
+	obj.CollectionIds = slices.Clone(obj.CollectionIds)
+	obj.CreationDate = copyPointer(obj.CreationDate)
+	obj.DeletedDate = copyPointer(obj.DeletedDate)
+	obj.RevisionDate = copyPointer(obj.RevisionDate)
+	obj.Groups = slices.Clone(obj.Groups)
+	obj.Attachments = slices.Clone(obj.Attachments)
+
+	obj.Login.URIs = slices.Clone(obj.Login.URIs)
+	for i := range obj.Login.URIs {
+		obj.Login.URIs[i].Match = copyPointer(obj.Login.URIs[i].Match)
+	}
+
+	obj.Fields = slices.Clone(obj.Fields)
+	for i := range obj.Fields {
+		obj.Fields[i].LinkedId = copyPointer(obj.Fields[i].LinkedId)
+	}
+	return obj
+}
+
+func copyPointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
@@ -0,0 +1,272 @@
+package readcache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetObjectListsOnce(t *testing.T) {
+	bwClient := newTestCLIClient()
+	c := NewClient(bwClient)
+
+	obj, err := c.GetObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Login", obj.Name)
+	}
+
+	obj, err = c.GetObject(context.Background(), bw.Object{ID: "note-id", Object: bw.ObjectTypeItem})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Note", obj.Name)
+	}
+
+	obj, err = c.GetObject(context.Background(), bw.Object{ID: "folder-id", Object: bw.ObjectTypeFolder})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Folder", obj.Name)
+	}
+
+	assert.Equal(t, []string{"list items", "list folders"}, bwClient.commands())
+}
+
+func TestGetObjectDelegatesUnlistedObjects(t *testing.T) {
+	bwClient := newTestCLIClient()
+	c := NewClient(bwClient)
+
+	_, err := c.GetObject(context.Background(), bw.Object{ID: "trashed-id", Object: bw.ObjectTypeItem})
+	assert.NoError(t, err)
+
+	_, err = c.GetObject(context.Background(), bw.Object{ID: "collection-id", Object: bw.ObjectTypeOrgCollection, OrganizationID: "org-id"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"list items", "get item trashed-id", "get org-collection collection-id"}, bwClient.commands())
+}
+
+func TestListObjectsFilters(t *testing.T) {
+	bwClient := newTestCLIClient()
+	c := NewClient(bwClient)
+
+	objs, err := c.ListObjects(context.Background(), "items", bw.WithSearch("login"))
+	if assert.NoError(t, err) && assert.Len(t, objs, 1) {
+		assert.Equal(t, "login-id", objs[0].ID)
+	}
+
+	objs, err = c.ListObjects(context.Background(), "items", bw.WithFolderID("folder-id"))
+	if assert.NoError(t, err) && assert.Len(t, objs, 1) {
+		assert.Equal(t, "note-id", objs[0].ID)
+	}
+
+	objs, err = c.ListObjects(context.Background(), "items", bw.WithOrganizationID("null"))
+	if assert.NoError(t, err) {
+		assert.Len(t, objs, 2)
+	}
+
+	objs, err = c.ListObjects(context.Background(), "org-collections", bw.WithOrganizationID("org-id"), bw.WithSearch("collection"))
+	if assert.NoError(t, err) {
+		assert.Len(t, objs, 1)
+	}
+
+	_, err = c.ListObjects(context.Background(), "items", bw.WithUrl("https://example.com"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"list items", "list org-collections --organizationid org-id", "list items --url https://example.com"}, bwClient.commands())
+}
+
+func TestListObjectsCombinedFilters(t *testing.T) {
+	bwClient := newTestCLIClient()
+	c := NewClient(bwClient)
+
+	objs, err := c.ListObjects(context.Background(), "items", bw.WithFolderID("folder-id"), bw.WithOrganizationID("org-id"))
+	if assert.NoError(t, err) {
+		assert.ElementsMatch(t, []string{"note-id", "org-note-id"}, objectIDs(objs))
+	}
+
+	objs, err = c.ListObjects(context.Background(), "items", bw.WithFolderID("folder-id"), bw.WithCollectionID("collection-id"), bw.WithSearch("organization"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"org-note-id"}, objectIDs(objs))
+	}
+
+	objs, err = c.ListObjects(context.Background(), "items", bw.WithFolderID("null"), bw.WithCollectionID("collection-id"))
+	if assert.NoError(t, err) {
+		assert.ElementsMatch(t, []string{"login-id", "org-note-id"}, objectIDs(objs))
+	}
+
+	assert.Equal(t, []string{"list items"}, bwClient.commands())
+}
+
+func TestReturnedObjectsAreCopies(t *testing.T) {
+	bwClient := newTestCLIClient()
+	c := NewClient(bwClient)
+
+	obj, err := c.GetObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem})
+	if !assert.NoError(t, err) {
+		return
+	}
+	obj.Fields[0].Value = "modified"
+	obj.Login.URIs[0].URI = "https://modified.example.com"
+
+	objs, err := c.ListObjects(context.Background(), "items", bw.WithOrganizationID("org-id"))
+	if !assert.NoError(t, err) || !assert.Len(t, objs, 1) {
+		return
+	}
+	objs[0].CollectionIds[0] = "modified"
+
+	obj, err = c.GetObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "value", obj.Fields[0].Value)
+		assert.Equal(t, "https://example.com", obj.Login.URIs[0].URI)
+	}
+	obj, err = c.GetObject(context.Background(), bw.Object{ID: "org-note-id", Object: bw.ObjectTypeItem})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"collection-id"}, obj.CollectionIds)
+	}
+}
+
+func TestWritesInvalidateLists(t *testing.T) {
+	bwClient := newTestCLIClient()
+	c := NewClient(bwClient)
+
+	_, err := c.GetObject(context.Background(), bw.Object{ID: "folder-id", Object: bw.ObjectTypeFolder})
+	assert.NoError(t, err)
+	_, err = c.GetObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem})
+	assert.NoError(t, err)
+
+	_, err = c.EditObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem, Name: "Renamed"})
+	assert.NoError(t, err)
+
+	obj, err := c.GetObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Renamed", obj.Name)
+	}
+	_, err = c.GetObject(context.Background(), bw.Object{ID: "folder-id", Object: bw.ObjectTypeFolder})
+	assert.NoError(t, err)
+
+	_, err = c.CreateAttachment(context.Background(), "login-id", "attachment.txt")
+	assert.NoError(t, err)
+	_, err = c.GetObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem})
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Sync(context.Background()))
+	_, err = c.GetObject(context.Background(), bw.Object{ID: "folder-id", Object: bw.ObjectTypeFolder})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"list folders",
+		"list items",
+		"edit item login-id",
+		"list items",
+		"create attachment login-id",
+		"list items",
+		"sync",
+		"list folders",
+	}, bwClient.commands())
+}
+
+func TestConcurrentReadsListOnce(t *testing.T) {
+	bwClient := newTestCLIClient()
+	c := NewClient(bwClient)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"list items"}, bwClient.commands())
+}
+
+func objectIDs(objs []bw.Object) []string {
+	ids := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		ids = append(ids, obj.ID)
+	}
+	return ids
+}
+
+// testCLIClient keeps a small Vault in memory, and records the commands the
+// CLI would have run.
+type testCLIClient struct {
+	bw.Client
+
+	objects map[string]bw.Object
+	cmds    []string
+	mu      sync.Mutex
+}
+
+func newTestCLIClient() *testCLIClient {
+	objs := []bw.Object{
+		{ID: "login-id", Object: bw.ObjectTypeItem, Type: bw.ItemTypeLogin, Name: "Login", Login: bw.Login{URIs: []bw.LoginURI{{URI: "https://example.com"}}}, Fields: []bw.Field{{Name: "field", Value: "value"}}},
+		{ID: "note-id", Object: bw.ObjectTypeItem, Type: bw.ItemTypeSecureNote, Name: "Note", FolderID: "folder-id"},
+		{ID: "org-note-id", Object: bw.ObjectTypeItem, Type: bw.ItemTypeSecureNote, Name: "Organization Note", OrganizationID: "org-id", CollectionIds: []string{"collection-id"}},
+		{ID: "folder-id", Object: bw.ObjectTypeFolder, Name: "Folder"},
+		{ID: "collection-id", Object: bw.ObjectTypeOrgCollection, Name: "Collection", OrganizationID: "org-id"},
+	}
+
+	c := &testCLIClient{objects: map[string]bw.Object{}}
+	for _, obj := range objs {
+		c.objects[obj.ID] = obj
+	}
+	return c
+}
+
+func (c *testCLIClient) commands() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cmds
+}
+
+func (c *testCLIClient) run(args ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cmds = append(c.cmds, strings.Join(args, " "))
+}
+
+func (c *testCLIClient) CreateAttachment(_ context.Context, itemId, _ string) (*bw.Object, error) {
+	c.run("create", "attachment", itemId)
+	obj := c.objects[itemId]
+	return &obj, nil
+}
+
+func (c *testCLIClient) EditObject(_ context.Context, obj bw.Object) (*bw.Object, error) {
+	c.run("edit", string(obj.Object), obj.ID)
+	c.mu.Lock()
+	c.objects[obj.ID] = obj
+	c.mu.Unlock()
+	return &obj, nil
+}
+
+func (c *testCLIClient) GetObject(_ context.Context, obj bw.Object) (*bw.Object, error) {
+	c.run("get", string(obj.Object), obj.ID)
+	return &obj, nil
+}
+
+func (c *testCLIClient) ListObjects(_ context.Context, objType string, options ...bw.ListObjectsOption) ([]bw.Object, error) {
+	args := []string{"list", objType}
+	for _, applyOption := range options {
+		applyOption(&args)
+	}
+	c.run(args...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	objs := []bw.Object{}
+	for _, obj := range c.objects {
+		if string(obj.Object)+"s" == objType {
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
+
+func (c *testCLIClient) Sync(_ context.Context) error {
+	c.run("sync")
+	return nil
+}
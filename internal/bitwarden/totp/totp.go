@@ -0,0 +1,125 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDigits = 6
+	defaultPeriod = 30
+)
+
+// Generator computes time-based one-time passwords (RFC 6238).
+type Generator struct {
+	secret    []byte
+	algorithm func() hash.Hash
+	digits    int
+	period    int64
+}
+
+// NewGenerator accepts either a base32 encoded secret, or an 'otpauth://'
+// URI as shown in QR codes and stored in Bitwarden items.
+func NewGenerator(seed string) (*Generator, error) {
+	g := &Generator{
+		algorithm: sha1.New,
+		digits:    defaultDigits,
+		period:    defaultPeriod,
+	}
+
+	secret := seed
+	if strings.HasPrefix(seed, "otpauth://") {
+		u, err := url.Parse(seed)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing otpauth URI: %w", err)
+		}
+		if u.Host != "totp" {
+			return nil, fmt.Errorf("unsupported otpauth type: %s", u.Host)
+		}
+
+		params := u.Query()
+		secret = params.Get("secret")
+
+		switch strings.ToUpper(params.Get("algorithm")) {
+		case "", "SHA1":
+		case "SHA256":
+			g.algorithm = sha256.New
+		case "SHA512":
+			g.algorithm = sha512.New
+		default:
+			return nil, fmt.Errorf("unsupported TOTP algorithm: %s", params.Get("algorithm"))
+		}
+
+		if v := params.Get("digits"); len(v) > 0 {
+			g.digits, err = strconv.Atoi(v)
+			if err != nil || g.digits < 1 || g.digits > 10 {
+				return nil, fmt.Errorf("invalid TOTP digits: %s", v)
+			}
+		}
+
+		if v := params.Get("period"); len(v) > 0 {
+			g.period, err = strconv.ParseInt(v, 10, 64)
+			if err != nil || g.period < 1 {
+				return nil, fmt.Errorf("invalid TOTP period: %s", v)
+			}
+		}
+	}
+
+	// Secrets are often displayed in groups, in lower case and without
+	// padding.
+	secret = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(secret))
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding TOTP secret: %w", err)
+	}
+	if len(decoded) == 0 {
+		return nil, fmt.Errorf("empty TOTP secret")
+	}
+	g.secret = decoded
+	return g, nil
+}
+
+// Code returns the code valid at the given time.
+func (g *Generator) Code(t time.Time) string {
+	return g.code(g.Step(t))
+}
+
+// Step returns the time step the given time falls in. Codes are the same
+// during a whole step.
+func (g *Generator) Step(t time.Time) int64 {
+	return t.Unix() / g.period
+}
+
+// NextStep returns the time at which the step following the given time's
+// begins.
+func (g *Generator) NextStep(t time.Time) time.Time {
+	return time.Unix((g.Step(t)+1)*g.period, 0)
+}
+
+func (g *Generator) code(step int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(g.algorithm, g.secret)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := int64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+
+	mod := int64(1)
+	for i := 0; i < g.digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", g.digits, value%mod)
+}
@@ -0,0 +1,67 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors from RFC 6238, Appendix B.
+func TestGeneratorCode(t *testing.T) {
+	tests := map[string]struct {
+		seed     string
+		time     int64
+		expected string
+	}{
+		"sha1-59": {
+			seed:     "otpauth://totp/test?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&digits=8",
+			time:     59,
+			expected: "94287082",
+		},
+		"sha1-1111111109": {
+			seed:     "otpauth://totp/test?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&digits=8",
+			time:     1111111109,
+			expected: "07081804",
+		},
+		"sha1-20000000000": {
+			seed:     "otpauth://totp/test?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&digits=8&algorithm=SHA1",
+			time:     20000000000,
+			expected: "65353130",
+		},
+		"sha256-59": {
+			seed:     "otpauth://totp/test?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA&digits=8&algorithm=SHA256",
+			time:     59,
+			expected: "46119246",
+		},
+		"raw-secret": {
+			seed:     "gezd gnbv gy3t qojq gezd gnbv gy3t qojq",
+			time:     59,
+			expected: "287082",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g, err := NewGenerator(tc.seed)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, g.Code(time.Unix(tc.time, 0)))
+			}
+		})
+	}
+}
+
+func TestGeneratorSteps(t *testing.T) {
+	g, err := NewGenerator("otpauth://totp/test?secret=GEZDGNBVGY3TQOJQ&period=60")
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(1), g.Step(time.Unix(119, 0)))
+	assert.Equal(t, time.Unix(120, 0), g.NextStep(time.Unix(61, 0)))
+}
+
+func TestNewGeneratorInvalidSeed(t *testing.T) {
+	for _, seed := range []string{"", "not-base32!", "otpauth://hotp/test?secret=GEZDGNBV", "otpauth://totp/test?secret=GEZDGNBV&algorithm=MD5"} {
+		_, err := NewGenerator(seed)
+		assert.Error(t, err, seed)
+	}
+}
@@ -0,0 +1,153 @@
+package vaultcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+// cacheFile is what is persisted on disk. Besides what's needed to identify
+// the Vault and derive its key from the master password, everything is
+// encrypted with the Vault's key.
+type cacheFile struct {
+	ServerURL     string `json:"serverUrl"`
+	Email         string `json:"email"`
+	KdfIterations int    `json:"kdfIterations"`
+	ProtectedKey  string `json:"protectedKey"`
+	Payload       string `json:"payload"`
+}
+
+type cachePayload struct {
+	RevisionDate time.Time            `json:"revisionDate"`
+	Tokens       webapi.SessionTokens `json:"tokens"`
+	Sync         webapi.SyncResponse  `json:"sync"`
+}
+
+// cacheEntry is a decrypted cacheFile.
+type cacheEntry struct {
+	cachePayload
+
+	kdfIterations int
+	protectedKey  string
+	userKey       symmetrickey.Key
+}
+
+// cachePath returns the path of the cache file of a user on a server.
+func cachePath(cacheDir, serverURL, email string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s", serverURL, email)))
+	return filepath.Join(cacheDir, fmt.Sprintf("vault-%s.json", hex.EncodeToString(hash[:16])))
+}
+
+// loadCache reads and decrypts a cache file. It returns nil if there is
+// no cache file for this user and server.
+func loadCache(path, serverURL, email, masterPassword string) (*cacheEntry, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading cache file: %w", err)
+	}
+
+	var file cacheFile
+	err = json.Unmarshal(raw, &file)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling cache file: %w", err)
+	}
+
+	if file.ServerURL != serverURL || file.Email != email {
+		return nil, fmt.Errorf("cache file belongs to another user or server")
+	}
+
+	userKey, err := decryptUserKey(file.ProtectedKey, email, masterPassword, file.KdfIterations)
+	if err != nil {
+		return nil, err
+	}
+
+	rawPayload, err := crypto.DecryptString(file.Payload, userKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting cache payload: %w", err)
+	}
+
+	entry := &cacheEntry{
+		kdfIterations: file.KdfIterations,
+		protectedKey:  file.ProtectedKey,
+		userKey:       *userKey,
+	}
+	err = json.Unmarshal(rawPayload, &entry.cachePayload)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling cache payload: %w", err)
+	}
+	return entry, nil
+}
+
+// saveCache encrypts and writes a cache file, readable only by the current
+// user.
+func saveCache(path, serverURL, email string, entry cacheEntry) error {
+	rawPayload, err := json.Marshal(entry.cachePayload)
+	if err != nil {
+		return fmt.Errorf("error marshalling cache payload: %w", err)
+	}
+
+	payload, err := crypto.Encrypt(rawPayload, entry.userKey)
+	if err != nil {
+		return fmt.Errorf("error encrypting cache payload: %w", err)
+	}
+
+	raw, err := json.Marshal(cacheFile{
+		ServerURL:     serverURL,
+		Email:         email,
+		KdfIterations: entry.kdfIterations,
+		ProtectedKey:  entry.protectedKey,
+		Payload:       payload,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling cache file: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	// Write to a temporary file first, so that concurrent readers never see
+	// a partially written cache.
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("error creating cache file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(raw)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing cache file: %w", err)
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// decryptUserKey derives the master key from the master password and uses it
+// to decrypt the user's key.
+func decryptUserKey(protectedKey, email, masterPassword string, kdfIterations int) (*symmetrickey.Key, error) {
+	preloginKey, err := keybuilder.BuildPreloginKey(masterPassword, email, kdfIterations)
+	if err != nil {
+		return nil, fmt.Errorf("error building prelogin key: %w", err)
+	}
+
+	userKey, err := crypto.DecryptEncryptionKey(protectedKey, *preloginKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting user key: %w", err)
+	}
+	return userKey, nil
+}
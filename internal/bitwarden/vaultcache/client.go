@@ -0,0 +1,322 @@
+package vaultcache
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+)
+
+/*
+* This is a bw.Client serving reads from a copy of the Vault, which is kept on
+* disk between runs, encrypted with the Vault's key. The copy is only fetched
+* again when the Vault's revision date has changed on the server, which makes
+* reading an unchanged Vault cost a single API call.
+*
+* Everything else is delegated to the CLI, which is only logged in when it's
+* first needed. Once something was written through the CLI, reads are
+* delegated as well until the end of the run.
+ */
+
+type Config struct {
+	CacheDir       string
+	ServerURL      string
+	Email          string
+	MasterPassword string
+
+	// ClientID and ClientSecret are used to log in to the API if set,
+	// instead of the master password.
+	ClientID     string
+	ClientSecret string
+
+	// LoginOptions is called before every password login to the API, e.g.
+	// to compute a two-step login code. LoginError can explain login errors.
+	LoginOptions func(context.Context) ([]webapi.LoginOption, error)
+	LoginError   func(error) error
+
+	// EnsureLoggedIn is called before the first command is delegated to the
+	// CLI.
+	EnsureLoggedIn func(context.Context) error
+}
+
+func NewClient(ctx context.Context, bwClient bw.Client, apiClient webapi.Client, cfg Config) (bw.Client, error) {
+	c := &client{
+		Client:         bwClient,
+		apiClient:      apiClient,
+		cfg:            cfg,
+		ensureLoggedIn: cfg.EnsureLoggedIn,
+		path:           cachePath(cfg.CacheDir, cfg.ServerURL, cfg.Email),
+	}
+
+	v, err := c.openVault(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.vault = v
+	return c, nil
+}
+
+type client struct {
+	bw.Client
+
+	apiClient      webapi.Client
+	cfg            Config
+	ensureLoggedIn func(context.Context) error
+	loggedIn       bool
+	loggedInMu     sync.Mutex
+	path           string
+	vault          *vault
+	vaultMu        sync.Mutex
+}
+
+func (c *client) GetObject(ctx context.Context, obj bw.Object) (*bw.Object, error) {
+	if v := c.cachedVault(); v != nil {
+		tflog.Debug(ctx, "Reading object from offline Vault cache", map[string]interface{}{"object": obj.Object, "id": obj.ID})
+		return v.getObject(obj.Object, obj.ID)
+	}
+
+	err := c.ensureCLILoggedIn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.GetObject(ctx, obj)
+}
+
+func (c *client) ListObjects(ctx context.Context, objType string, options ...bw.ListObjectsOption) ([]bw.Object, error) {
+	if v := c.cachedVault(); v != nil {
+		filters, err := bw.NewListFilters(options...)
+		if err == nil {
+			objs, ok := v.listObjects(bw.ObjectType(strings.TrimSuffix(objType, "s")), filters)
+			if ok {
+				tflog.Debug(ctx, "Listing objects from offline Vault cache", map[string]interface{}{"object": objType})
+				return objs, nil
+			}
+		}
+	}
+
+	err := c.ensureCLILoggedIn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.ListObjects(ctx, objType, options...)
+}
+
+func (c *client) GetAttachment(ctx context.Context, itemId, attachmentId string) ([]byte, error) {
+	err := c.ensureCLILoggedIn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.GetAttachment(ctx, itemId, attachmentId)
+}
+
+func (c *client) Export(ctx context.Context, filePath string, format bw.ExportFormat, options ...bw.ExportOption) error {
+	err := c.ensureCLILoggedIn(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Client.Export(ctx, filePath, format, options...)
+}
+
+func (c *client) CreateAttachment(ctx context.Context, itemId, filePath string) (*bw.Object, error) {
+	err := c.beforeWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.CreateAttachment(ctx, itemId, filePath)
+}
+
+func (c *client) CreateObject(ctx context.Context, obj bw.Object) (*bw.Object, error) {
+	err := c.beforeWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.CreateObject(ctx, obj)
+}
+
+func (c *client) EditObject(ctx context.Context, obj bw.Object) (*bw.Object, error) {
+	err := c.beforeWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.EditObject(ctx, obj)
+}
+
+func (c *client) EditItemCollections(ctx context.Context, itemId string, collectionIds []string) (*bw.Object, error) {
+	err := c.beforeWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.EditItemCollections(ctx, itemId, collectionIds)
+}
+
+func (c *client) Import(ctx context.Context, format, filePath string, options ...bw.ImportOption) error {
+	err := c.beforeWrite(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Client.Import(ctx, format, filePath, options...)
+}
+
+func (c *client) DeleteAttachment(ctx context.Context, itemId, attachmentId string) error {
+	err := c.beforeWrite(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Client.DeleteAttachment(ctx, itemId, attachmentId)
+}
+
+func (c *client) DeleteObject(ctx context.Context, obj bw.Object) error {
+	err := c.beforeWrite(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Client.DeleteObject(ctx, obj)
+}
+
+func (c *client) Sync(ctx context.Context) error {
+	err := c.ensureCLILoggedIn(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Client.Sync(ctx)
+}
+
+func (c *client) cachedVault() *vault {
+	c.vaultMu.Lock()
+	defer c.vaultMu.Unlock()
+	return c.vault
+}
+
+// beforeWrite stops serving reads from the cache, as it's about to become
+// outdated, and makes sure the CLI is logged in.
+func (c *client) beforeWrite(ctx context.Context) error {
+	c.vaultMu.Lock()
+	c.vault = nil
+	c.vaultMu.Unlock()
+
+	return c.ensureCLILoggedIn(ctx)
+}
+
+func (c *client) ensureCLILoggedIn(ctx context.Context) error {
+	c.loggedInMu.Lock()
+	defer c.loggedInMu.Unlock()
+
+	if c.loggedIn || c.ensureLoggedIn == nil {
+		return nil
+	}
+
+	err := c.ensureLoggedIn(ctx)
+	if err != nil {
+		return err
+	}
+	c.loggedIn = true
+	return nil
+}
+
+// openVault returns the cached copy of the Vault if it's still up-to-date, and
+// fetches a new one otherwise.
+func (c *client) openVault(ctx context.Context) (*vault, error) {
+	entry, err := loadCache(c.path, c.cfg.ServerURL, c.cfg.Email, c.cfg.MasterPassword)
+	if err != nil {
+		tflog.Warn(ctx, "Ignoring unreadable offline Vault cache", map[string]interface{}{"error": err})
+	}
+
+	if entry != nil {
+		c.apiClient.SetSessionTokens(entry.Tokens)
+		revisionDate, err := c.apiClient.GetRevisionDate(ctx)
+		if err != nil {
+			tflog.Warn(ctx, "Unable to check offline Vault cache revision", map[string]interface{}{"error": err})
+		} else if revisionDate.Equal(entry.RevisionDate) {
+			tflog.Debug(ctx, "Offline Vault cache is up-to-date", map[string]interface{}{"revision_date": revisionDate})
+
+			// Keep the tokens in case they were refreshed.
+			if tokens := c.apiClient.GetSessionTokens(); tokens != entry.Tokens {
+				entry.Tokens = tokens
+				err = saveCache(c.path, c.cfg.ServerURL, c.cfg.Email, *entry)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return newVault(entry.Sync, entry.userKey)
+		} else {
+			tflog.Debug(ctx, "Offline Vault cache is outdated", map[string]interface{}{"revision_date": revisionDate, "cache_revision_date": entry.RevisionDate})
+		}
+	}
+
+	entry, err = c.fetchVault(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = saveCache(c.path, c.cfg.ServerURL, c.cfg.Email, *entry)
+	if err != nil {
+		return nil, err
+	}
+	return newVault(entry.Sync, entry.userKey)
+}
+
+func (c *client) passwordLogin(ctx context.Context, kdfIterations int) error {
+	var loginOptions []webapi.LoginOption
+	if c.cfg.LoginOptions != nil {
+		var err error
+		loginOptions, err = c.cfg.LoginOptions(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := c.apiClient.Login(ctx, c.cfg.Email, c.cfg.MasterPassword, kdfIterations, loginOptions...)
+	if err != nil && c.cfg.LoginError != nil {
+		return c.cfg.LoginError(err)
+	}
+	return err
+}
+
+// fetchVault logs in to the API and downloads the whole Vault.
+func (c *client) fetchVault(ctx context.Context) (*cacheEntry, error) {
+	prelogin, err := c.apiClient.PreLogin(ctx, c.cfg.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.cfg.ClientID) > 0 && len(c.cfg.ClientSecret) > 0 {
+		err = c.apiClient.LoginWithAPIKey(ctx, c.cfg.Email, c.cfg.MasterPassword, c.cfg.ClientID, c.cfg.ClientSecret)
+	} else {
+		err = c.passwordLogin(ctx, prelogin.KdfIterations)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The revision date is retrieved first, so that changes happening during
+	// the sync result in the cache being considered outdated next time.
+	revisionDate, err := c.apiClient.GetRevisionDate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	syncResp, err := c.apiClient.Sync(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userKey, err := decryptUserKey(syncResp.Profile.Key, c.cfg.Email, c.cfg.MasterPassword, prelogin.KdfIterations)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry{
+		cachePayload: cachePayload{
+			RevisionDate: revisionDate,
+			Tokens:       c.apiClient.GetSessionTokens(),
+			Sync:         *syncResp,
+		},
+		kdfIterations: prelogin.KdfIterations,
+		protectedKey:  syncResp.Profile.Key,
+		userKey:       *userKey,
+	}
+	return entry, nil
+}
@@ -0,0 +1,332 @@
+package vaultcache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testEmail          = "test@example.com"
+	testMasterPassword = "master-password"
+	testKdfIterations  = 1000
+)
+
+func TestClientServesReadsFromCache(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	bwClient := &testCLIClient{}
+	cfg := server.config(t.TempDir(), bwClient)
+
+	c := newTestClient(t, server, bwClient, cfg)
+	assert.Equal(t, 1, server.calls("/api/sync"))
+
+	obj, err := c.GetObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem})
+	assert.NoError(t, err)
+	assert.Equal(t, "Login", obj.Name)
+	assert.Equal(t, "notes", obj.Notes)
+	assert.Equal(t, "username", obj.Login.Username)
+	assert.Equal(t, "password", obj.Login.Password)
+	assert.Equal(t, "https://example.com", obj.Login.URIs[0].URI)
+	assert.Equal(t, bw.URIMatchExact, *obj.Login.URIs[0].Match)
+	assert.Equal(t, []bw.Field{{Name: "field", Value: "value", Type: bw.FieldTypeHidden}}, obj.Fields)
+	assert.Equal(t, "folder-id", obj.FolderID)
+
+	obj, err = c.GetObject(context.Background(), bw.Object{ID: "org-note-id", Object: bw.ObjectTypeItem})
+	assert.NoError(t, err)
+	assert.Equal(t, "Organization Note", obj.Name)
+	assert.Equal(t, "org-id", obj.OrganizationID)
+
+	obj, err = c.GetObject(context.Background(), bw.Object{ID: "collection-id", Object: bw.ObjectTypeOrgCollection})
+	assert.NoError(t, err)
+	assert.Equal(t, "Collection", obj.Name)
+
+	_, err = c.GetObject(context.Background(), bw.Object{ID: "missing-id", Object: bw.ObjectTypeItem})
+	assert.ErrorIs(t, err, bw.ErrObjectNotFound)
+
+	assert.Equal(t, 0, bwClient.calls())
+	assert.False(t, bwClient.loggedIn)
+
+	// A second run with an unchanged Vault only checks the revision date.
+	c = newTestClient(t, server, bwClient, cfg)
+	assert.Equal(t, 1, server.calls("/api/sync"))
+	assert.Equal(t, 1, server.calls("/identity/connect/token"))
+	assert.Equal(t, 2, server.calls("/api/accounts/revision-date"))
+
+	obj, err = c.GetObject(context.Background(), bw.Object{ID: "folder-id", Object: bw.ObjectTypeFolder})
+	assert.NoError(t, err)
+	assert.Equal(t, "Folder", obj.Name)
+
+	// Once the Vault changed, it's fetched again.
+	server.setRevisionDate(time.Now())
+	newTestClient(t, server, bwClient, cfg)
+	assert.Equal(t, 2, server.calls("/api/sync"))
+}
+
+func TestClientListsObjectsFromCache(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	bwClient := &testCLIClient{}
+	c := newTestClient(t, server, bwClient, server.config(t.TempDir(), bwClient))
+
+	testCases := []struct {
+		objType     string
+		options     []bw.ListObjectsOption
+		expectedIDs []string
+	}{
+		{objType: "items", expectedIDs: []string{"login-id", "org-note-id"}},
+		{objType: "items", options: []bw.ListObjectsOption{bw.WithFolderID("folder-id")}, expectedIDs: []string{"login-id"}},
+		{objType: "items", options: []bw.ListObjectsOption{bw.WithOrganizationID("org-id")}, expectedIDs: []string{"org-note-id"}},
+		{objType: "items", options: []bw.ListObjectsOption{bw.WithCollectionID("collection-id")}, expectedIDs: []string{"org-note-id"}},
+		{objType: "items", options: []bw.ListObjectsOption{bw.WithSearch("EXAMPLE.com")}, expectedIDs: []string{"login-id"}},
+		{objType: "items", options: []bw.ListObjectsOption{bw.WithSearch("nothing")}, expectedIDs: []string{}},
+		{objType: "folders", options: []bw.ListObjectsOption{bw.WithSearch("fold")}, expectedIDs: []string{"folder-id"}},
+		{objType: "org-collections", options: []bw.ListObjectsOption{bw.WithOrganizationID("org-id")}, expectedIDs: []string{"collection-id"}},
+		{objType: "organizations", expectedIDs: []string{"org-id"}},
+	}
+
+	for _, tc := range testCases {
+		objs, err := c.ListObjects(context.Background(), tc.objType, tc.options...)
+		assert.NoError(t, err)
+
+		ids := []string{}
+		for _, obj := range objs {
+			ids = append(ids, obj.ID)
+		}
+		assert.ElementsMatch(t, tc.expectedIDs, ids, tc.objType)
+	}
+	assert.Equal(t, 0, bwClient.calls())
+
+	// URL matching isn't supported by the cache.
+	_, err := c.ListObjects(context.Background(), "items", bw.WithUrl("https://example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bwClient.calls())
+	assert.True(t, bwClient.loggedIn)
+}
+
+func TestClientDelegatesToCLIAfterWrite(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	bwClient := &testCLIClient{}
+	c := newTestClient(t, server, bwClient, server.config(t.TempDir(), bwClient))
+
+	_, err := c.CreateObject(context.Background(), bw.Object{Object: bw.ObjectTypeFolder, Name: "New Folder"})
+	assert.NoError(t, err)
+	assert.True(t, bwClient.loggedIn)
+
+	_, err = c.GetObject(context.Background(), bw.Object{ID: "login-id", Object: bw.ObjectTypeItem})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, bwClient.calls())
+}
+
+func TestClientIgnoresCacheOfOtherPassword(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	bwClient := &testCLIClient{}
+	cfg := server.config(t.TempDir(), bwClient)
+	newTestClient(t, server, bwClient, cfg)
+
+	entry, err := loadCache(cachePath(cfg.CacheDir, cfg.ServerURL, cfg.Email), cfg.ServerURL, cfg.Email, "another-password")
+	assert.ErrorContains(t, err, "error decrypting user key")
+	assert.Nil(t, entry)
+}
+
+func newTestClient(t *testing.T, server *testServer, bwClient bw.Client, cfg Config) bw.Client {
+	apiClient, err := webapi.NewClient(server.URL)
+	assert.NoError(t, err)
+
+	c, err := NewClient(context.Background(), bwClient, apiClient, cfg)
+	assert.NoError(t, err)
+	return c
+}
+
+// testCLIClient counts the calls delegated to the CLI.
+type testCLIClient struct {
+	bw.Client
+
+	mu       sync.Mutex
+	count    int
+	loggedIn bool
+}
+
+func (c *testCLIClient) calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count
+}
+
+func (c *testCLIClient) called() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count++
+}
+
+func (c *testCLIClient) CreateObject(_ context.Context, obj bw.Object) (*bw.Object, error) {
+	c.called()
+	return &obj, nil
+}
+
+func (c *testCLIClient) GetObject(_ context.Context, obj bw.Object) (*bw.Object, error) {
+	c.called()
+	return &obj, nil
+}
+
+func (c *testCLIClient) ListObjects(_ context.Context, _ string, _ ...bw.ListObjectsOption) ([]bw.Object, error) {
+	c.called()
+	return nil, nil
+}
+
+type testServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	callCounts   map[string]int
+	revisionDate time.Time
+}
+
+func (s *testServer) calls(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.callCounts[path]
+}
+
+func (s *testServer) setRevisionDate(revisionDate time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revisionDate = revisionDate
+}
+
+func (s *testServer) config(cacheDir string, bwClient *testCLIClient) Config {
+	return Config{
+		CacheDir:       cacheDir,
+		ServerURL:      s.URL,
+		Email:          testEmail,
+		MasterPassword: testMasterPassword,
+		EnsureLoggedIn: func(context.Context) error {
+			bwClient.loggedIn = true
+			return nil
+		},
+	}
+}
+
+// newTestServer simulates a server with a Vault containing a personal login,
+// in a folder, and a secure note in an organization's collection.
+func newTestServer(t *testing.T) *testServer {
+	preloginKey, err := keybuilder.BuildPreloginKey(testMasterPassword, testEmail, testKdfIterations)
+	assert.NoError(t, err)
+	userKey, protectedUserKey, err := keybuilder.GenerateEncryptionKey(*preloginKey)
+	assert.NoError(t, err)
+	_, protectedPrivateKey, err := keybuilder.GenerateKeyPair(*userKey)
+	assert.NoError(t, err)
+	privateKey, err := crypto.DecryptPrivateKey(protectedPrivateKey, *userKey)
+	assert.NoError(t, err)
+	protectedOrgKey, orgKey, err := keybuilder.GenerateShareKey(&privateKey.PublicKey)
+	assert.NoError(t, err)
+	cipherKey, protectedCipherKey, err := keybuilder.GenerateEncryptionKey(*orgKey)
+	assert.NoError(t, err)
+
+	encrypt := func(value string, key symmetrickey.Key) string {
+		encrypted, err := crypto.Encrypt([]byte(value), key)
+		assert.NoError(t, err)
+		return encrypted
+	}
+
+	match := int(bw.URIMatchExact)
+	deletedDate := time.Now()
+	syncResp := webapi.SyncResponse{
+		Profile: webapi.ProfileResponse{
+			Id:            "user-id",
+			Email:         testEmail,
+			Key:           protectedUserKey,
+			PrivateKey:    protectedPrivateKey,
+			Organizations: []webapi.ProfileOrganization{{Id: "org-id", Name: "Organization", Key: protectedOrgKey}},
+		},
+		Folders:     []webapi.Folder{{Id: "folder-id", Name: encrypt("Folder", *userKey)}},
+		Collections: []webapi.CollectionDetails{{Id: "collection-id", OrganizationId: "org-id", Name: encrypt("Collection", *orgKey)}},
+		Ciphers: []webapi.Cipher{
+			{
+				Id:       "login-id",
+				FolderId: "folder-id",
+				Type:     int(bw.ItemTypeLogin),
+				Name:     encrypt("Login", *userKey),
+				Notes:    encrypt("notes", *userKey),
+				Login: &webapi.CipherLogin{
+					Username: encrypt("username", *userKey),
+					Password: encrypt("password", *userKey),
+					Uris:     []webapi.CipherLoginUri{{Uri: encrypt("https://example.com", *userKey), Match: &match}},
+				},
+				Fields: []webapi.CipherField{{Name: encrypt("field", *userKey), Value: encrypt("value", *userKey), Type: int(bw.FieldTypeHidden)}},
+			},
+			{
+				Id:             "org-note-id",
+				OrganizationId: "org-id",
+				CollectionIds:  []string{"collection-id"},
+				Type:           int(bw.ItemTypeSecureNote),
+				Key:            protectedCipherKey,
+				Name:           encrypt("Organization Note", *cipherKey),
+				SecureNote:     &webapi.CipherSecureNote{},
+			},
+			{
+				Id:          "deleted-id",
+				Type:        int(bw.ItemTypeSecureNote),
+				Name:        encrypt("Deleted Note", *userKey),
+				DeletedDate: &deletedDate,
+			},
+		},
+	}
+
+	server := &testServer{
+		callCounts:   map[string]int{},
+		revisionDate: time.Now().Add(-time.Hour),
+	}
+
+	mux := http.NewServeMux()
+	count := func(path string, handler http.HandlerFunc) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			server.mu.Lock()
+			server.callCounts[path]++
+			server.mu.Unlock()
+			handler(w, r)
+		})
+	}
+	count("/identity/accounts/prelogin", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(webapi.PreloginResponse{KdfIterations: testKdfIterations})
+	})
+	count("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(webapi.TokenResponse{
+			AccessToken:   "access-token",
+			RefreshToken:  "refresh-token",
+			ExpireIn:      3600,
+			Key:           protectedUserKey,
+			PrivateKey:    protectedPrivateKey,
+			KdfIterations: testKdfIterations,
+		})
+	})
+	count("/api/accounts/revision-date", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access-token", r.Header.Get("authorization"))
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		json.NewEncoder(w).Encode(server.revisionDate.UnixMilli())
+	})
+	count("/api/sync", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access-token", r.Header.Get("authorization"))
+		json.NewEncoder(w).Encode(syncResp)
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
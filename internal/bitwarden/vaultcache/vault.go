@@ -0,0 +1,241 @@
+package vaultcache
+
+import (
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+// vault is the decrypted content of a sync payload, in the same format as
+// the one the CLI returns.
+type vault struct {
+	objects map[bw.ObjectType][]bw.Object
+}
+
+func newVault(syncResp webapi.SyncResponse, userKey symmetrickey.Key) (*vault, error) {
+	v := &vault{objects: map[bw.ObjectType][]bw.Object{}}
+
+	var privateKey *rsa.PrivateKey
+	var err error
+	if len(syncResp.Profile.PrivateKey) > 0 {
+		privateKey, err = crypto.DecryptPrivateKey(syncResp.Profile.PrivateKey, userKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting private key: %w", err)
+		}
+	}
+
+	orgKeys := map[string]symmetrickey.Key{}
+	for _, org := range syncResp.Profile.Organizations {
+		if privateKey == nil {
+			return nil, fmt.Errorf("no private key to decrypt key of organization '%s'", org.Id)
+		}
+		orgKey, err := crypto.DecryptOrganizationKey(org.Key, privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting key of organization '%s': %w", org.Id, err)
+		}
+		orgKeys[org.Id] = *orgKey
+
+		v.add(bw.Object{
+			ID:     org.Id,
+			Name:   org.Name,
+			Object: bw.ObjectTypeOrganization,
+		})
+	}
+
+	for _, folder := range syncResp.Folders {
+		name, err := decryptString(folder.Name, userKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting folder '%s': %w", folder.Id, err)
+		}
+		v.add(bw.Object{
+			ID:           folder.Id,
+			Name:         name,
+			Object:       bw.ObjectTypeFolder,
+			RevisionDate: folder.RevisionDate,
+		})
+	}
+
+	for _, collection := range syncResp.Collections {
+		orgKey, ok := orgKeys[collection.OrganizationId]
+		if !ok {
+			return nil, fmt.Errorf("no key for organization '%s' of collection '%s'", collection.OrganizationId, collection.Id)
+		}
+		name, err := decryptString(collection.Name, orgKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting collection '%s': %w", collection.Id, err)
+		}
+		v.add(bw.Object{
+			ExternalID:     collection.ExternalId,
+			ID:             collection.Id,
+			Name:           name,
+			Object:         bw.ObjectTypeOrgCollection,
+			OrganizationID: collection.OrganizationId,
+		})
+	}
+
+	for _, cipher := range syncResp.Ciphers {
+		ownerKey := userKey
+		if len(cipher.OrganizationId) > 0 {
+			orgKey, ok := orgKeys[cipher.OrganizationId]
+			if !ok {
+				return nil, fmt.Errorf("no key for organization '%s' of item '%s'", cipher.OrganizationId, cipher.Id)
+			}
+			ownerKey = orgKey
+		}
+
+		obj, err := decryptCipher(cipher, ownerKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting item '%s': %w", cipher.Id, err)
+		}
+		v.add(*obj)
+	}
+
+	return v, nil
+}
+
+func (v *vault) add(obj bw.Object) {
+	v.objects[obj.Object] = append(v.objects[obj.Object], obj)
+}
+
+func (v *vault) getObject(objType bw.ObjectType, id string) (*bw.Object, error) {
+	for _, obj := range v.objects[objType] {
+		if obj.ID == id {
+			return &obj, nil
+		}
+	}
+	return nil, bw.ErrObjectNotFound
+}
+
+// listObjects mimics the CLI's 'list' command. It returns false if one of the
+// filters isn't supported.
+func (v *vault) listObjects(objType bw.ObjectType, filters bw.ListFilters) ([]bw.Object, bool) {
+	if len(filters.URL) > 0 {
+		return nil, false
+	}
+
+	objs := []bw.Object{}
+	for _, obj := range v.objects[objType] {
+		// Items in the trash are only listed with '--trash'.
+		if obj.DeletedDate != nil {
+			continue
+		}
+		if !filters.Match(obj) {
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return objs, true
+}
+
+func decryptCipher(cipher webapi.Cipher, ownerKey symmetrickey.Key) (*bw.Object, error) {
+	key := ownerKey
+	if len(cipher.Key) > 0 {
+		cipherKey, err := crypto.DecryptSymmetricKey(cipher.Key, ownerKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting cipher key: %w", err)
+		}
+		key = *cipherKey
+	}
+
+	obj := &bw.Object{
+		CollectionIds:  cipher.CollectionIds,
+		CreationDate:   cipher.CreationDate,
+		DeletedDate:    cipher.DeletedDate,
+		Favorite:       cipher.Favorite,
+		FolderID:       cipher.FolderId,
+		ID:             cipher.Id,
+		Object:         bw.ObjectTypeItem,
+		OrganizationID: cipher.OrganizationId,
+		Reprompt:       cipher.Reprompt,
+		RevisionDate:   cipher.RevisionDate,
+		Type:           bw.ItemType(cipher.Type),
+	}
+
+	var err error
+	obj.Name, err = decryptString(cipher.Name, key)
+	if err != nil {
+		return nil, err
+	}
+	obj.Notes, err = decryptString(cipher.Notes, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if cipher.Login != nil {
+		obj.Login.Username, err = decryptString(cipher.Login.Username, key)
+		if err != nil {
+			return nil, err
+		}
+		obj.Login.Password, err = decryptString(cipher.Login.Password, key)
+		if err != nil {
+			return nil, err
+		}
+		obj.Login.Totp, err = decryptString(cipher.Login.Totp, key)
+		if err != nil {
+			return nil, err
+		}
+		for _, uri := range cipher.Login.Uris {
+			loginURI := bw.LoginURI{}
+			loginURI.URI, err = decryptString(uri.Uri, key)
+			if err != nil {
+				return nil, err
+			}
+			if uri.Match != nil {
+				match := bw.URIMatch(*uri.Match)
+				loginURI.Match = &match
+			}
+			obj.Login.URIs = append(obj.Login.URIs, loginURI)
+		}
+	}
+
+	if cipher.SecureNote != nil {
+		obj.SecureNote.Type = cipher.SecureNote.Type
+	}
+
+	for _, field := range cipher.Fields {
+		f := bw.Field{
+			Type:     bw.FieldType(field.Type),
+			LinkedId: field.LinkedId,
+		}
+		f.Name, err = decryptString(field.Name, key)
+		if err != nil {
+			return nil, err
+		}
+		f.Value, err = decryptString(field.Value, key)
+		if err != nil {
+			return nil, err
+		}
+		obj.Fields = append(obj.Fields, f)
+	}
+
+	for _, attachment := range cipher.Attachments {
+		fileName, err := decryptString(attachment.FileName, key)
+		if err != nil {
+			return nil, err
+		}
+		obj.Attachments = append(obj.Attachments, bw.Attachment{
+			FileName: fileName,
+			ID:       attachment.Id,
+			Size:     attachment.Size,
+			SizeName: attachment.SizeName,
+			Url:      attachment.Url,
+		})
+	}
+
+	return obj, nil
+}
+
+func decryptString(encryptedStr string, key symmetrickey.Key) (string, error) {
+	if len(encryptedStr) == 0 {
+		return "", nil
+	}
+	decrypted, err := crypto.DecryptString(encryptedStr, key)
+	if err != nil {
+		return "", err
+	}
+	return string(decrypted), nil
+}
@@ -2,17 +2,22 @@ package webapi
 
 import (
 	"bytes"
+	"context"
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
 )
 
 /*
@@ -22,35 +27,97 @@ import (
  */
 
 type Client interface {
-	CreateOrganization(name, label, billingEmail string) (string, error)
-	GetCollections(orgID string) (string, error)
-	Login(username, password string, kdfIterations int) error
-	RegisterUser(name, username, password string, kdfIterations int) error
+	AccessSend(ctx context.Context, accessID string, keyMaterial []byte, password string) (*Send, error)
+	ChangeMasterPassword(ctx context.Context, username, currentPassword, newPassword string, kdfIterations int) error
+	ConfirmEmergencyAccess(ctx context.Context, id string) error
+	CreateAttachment(ctx context.Context, itemId, filePath string) (*Attachment, error)
+	CreateOrganization(ctx context.Context, name, label, billingEmail string) (string, error)
+	DeleteAttachment(ctx context.Context, itemId, attachmentId string) error
+	DeleteEmergencyAccess(ctx context.Context, id string) error
+	DownloadAttachment(ctx context.Context, itemId, attachmentId string, w io.Writer) error
+	GetAttachment(ctx context.Context, itemId, attachmentId string) ([]byte, error)
+	GetCollections(ctx context.Context, orgID string) (string, error)
+	GetEmergencyAccess(ctx context.Context, id string) (*EmergencyAccess, error)
+	GetEmergencyAccesses(ctx context.Context) ([]EmergencyAccess, error)
+	GetRevisionDate(ctx context.Context) (time.Time, error)
+	GetSessionTokens() SessionTokens
+	InviteEmergencyAccess(ctx context.Context, email string, accessType EmergencyAccessType, waitTimeDays int) (*EmergencyAccess, error)
+	Login(ctx context.Context, username, password string, kdfIterations int, options ...LoginOption) error
+	LoginWithAPIKey(ctx context.Context, username, password, clientId, clientSecret string) error
+	PreLogin(ctx context.Context, username string) (*PreloginResponse, error)
+	RegisterUser(ctx context.Context, name, username, password string, kdfIterations int) error
+	SetSessionTokens(tokens SessionTokens)
+	Sync(ctx context.Context) (*SyncResponse, error)
+	UpdateEmergencyAccess(ctx context.Context, id string, accessType EmergencyAccessType, waitTimeDays int) error
 }
 
+const (
+	// tokenRefreshMargin is how long before its actual expiry an access token
+	// is considered expired, to avoid using it while it's about to expire.
+	tokenRefreshMargin = 30 * time.Second
+
+	// webClientID is the client password logins are made with.
+	webClientID = "web"
+)
+
 type session struct {
-	accessToken string
-	privateKey  *rsa.PrivateKey
+	accessToken   string
+	refreshToken  string
+	expiresAt     time.Time
+	encryptionKey *symmetrickey.Key
+	privateKey    *rsa.PrivateKey
+	kdfIterations int
+	grant         sessionGrant
+
+	// organizationKeys are fetched and decrypted on first use.
+	organizationKeys map[string]*symmetrickey.Key
 }
 
-func NewClient(serverURL string) Client {
-	return &client{
+// sessionGrant is what a session's access token is renewed with. Sessions
+// logged in with an API key don't get refresh tokens, and repeat the client
+// credentials grant instead.
+type sessionGrant struct {
+	clientID     string
+	clientSecret string
+}
+
+func NewClient(serverURL string, opts ...Options) (Client, error) {
+	c := &client{
 		deviceIdentifier: "5d90b470-5d1d-452d-935c-b730c177a8d6",
 		deviceName:       "firefox",
 		deviceType:       "10",
 		serverURL:        serverURL,
 	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	httpClient, err := c.buildHTTPClient()
+	if err != nil {
+		return nil, fmt.Errorf("error configuring http client: %w", err)
+	}
+	c.httpClient = httpClient
+
+	return c, nil
 }
 
 type client struct {
+	baseHTTPClient   *http.Client
 	deviceIdentifier string
 	deviceName       string
 	deviceType       string
+	extraCACertsPath string
+	httpClient       *http.Client
+	proxyURL         string
+	requestTimeout   time.Duration
 	serverURL        string
 	session          session
+	sessionMu        sync.RWMutex
+	userAgent        string
 }
 
-func (c *client) RegisterUser(name, username, password string, kdfIterations int) error {
+func (c *client) RegisterUser(ctx context.Context, name, username, password string, kdfIterations int) error {
 	preloginKey, err := keybuilder.BuildPreloginKey(password, username, kdfIterations)
 	if err != nil {
 		return fmt.Errorf("error building prelogin key: %w", err)
@@ -85,7 +152,13 @@ func (c *client) RegisterUser(name, username, password string, kdfIterations int
 		return fmt.Errorf("unable to marshall user registration request: %w", err)
 	}
 
-	resp, err := http.Post(c.signupURL(), "application/json", bytes.NewBuffer(signupRequestBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.signupURL(), bytes.NewBuffer(signupRequestBytes))
+	if err != nil {
+		return fmt.Errorf("error preparing user registration request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("error calling user registration: %w", err)
 	}
@@ -97,12 +170,12 @@ func (c *client) RegisterUser(name, username, password string, kdfIterations int
 	}
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("bad status code in registration response: %d!=200, body: %s", resp.StatusCode, string(body))
+		return newAPIError("registration", resp.StatusCode, body)
 	}
 	return nil
 }
 
-func (c *client) Login(username, password string, kdfIterations int) error {
+func (c *client) Login(ctx context.Context, username, password string, kdfIterations int, options ...LoginOption) error {
 	preloginKey, err := keybuilder.BuildPreloginKey(password, username, kdfIterations)
 	if err != nil {
 		return fmt.Errorf("error building prelogin key: %w", err)
@@ -112,46 +185,96 @@ func (c *client) Login(username, password string, kdfIterations int) error {
 
 	form := url.Values{}
 	form.Add("scope", "api offline_access")
-	form.Add("client_id", "web")
+	form.Add("client_id", webClientID)
 	form.Add("grant_type", "password")
 	form.Add("username", username)
 	form.Add("password", hashedPassword)
+	for _, applyOption := range options {
+		applyOption(form)
+	}
+
+	tokenResp, err := c.requestToken(ctx, form, username)
+	if err != nil {
+		return err
+	}
+
+	return c.unlockSession(*tokenResp, sessionGrant{clientID: webClientID}, *preloginKey, kdfIterations)
+}
+
+// LoginWithAPIKey logs in using a personal API key. As the API key doesn't
+// give access to the Vault's content, the master password is still required
+// to decrypt the user's encryption key.
+func (c *client) LoginWithAPIKey(ctx context.Context, username, password, clientId, clientSecret string) error {
+	grant := sessionGrant{clientID: clientId, clientSecret: clientSecret}
+	tokenResp, err := c.requestToken(ctx, grant.clientCredentialsForm(), "")
+	if err != nil {
+		return err
+	}
+
+	if tokenResp.Kdf != keybuilder.PBKDF2_SHA256 {
+		return fmt.Errorf("unsupported kdf type: %d", tokenResp.Kdf)
+	}
+
+	preloginKey, err := keybuilder.BuildPreloginKey(password, username, tokenResp.KdfIterations)
+	if err != nil {
+		return fmt.Errorf("error building prelogin key: %w", err)
+	}
+
+	return c.unlockSession(*tokenResp, grant, *preloginKey, tokenResp.KdfIterations)
+}
+
+func (g sessionGrant) clientCredentialsForm() url.Values {
+	form := url.Values{}
+	form.Add("scope", "api")
+	form.Add("client_id", g.clientID)
+	form.Add("client_secret", g.clientSecret)
+	form.Add("grant_type", "client_credentials")
+	return form
+}
+
+func (c *client) requestToken(ctx context.Context, form url.Values, username string) (*TokenResponse, error) {
 	form.Add("device_type", c.deviceType)
 	form.Add("device_identifier", c.deviceIdentifier)
 	form.Add("device_name", c.deviceName)
 
-	req, err := http.NewRequest("POST", c.loginURL(), strings.NewReader(form.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.loginURL(), strings.NewReader(form.Encode()))
 	if err != nil {
-		return fmt.Errorf("error preparing login request: %w", err)
+		return nil, fmt.Errorf("error preparing login request: %w", err)
 	}
 
-	req.Header.Add("auth-email", base64.StdEncoding.EncodeToString([]byte(username)))
+	if len(username) > 0 {
+		req.Header.Add("auth-email", base64.StdEncoding.EncodeToString([]byte(username)))
+	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
 	req.Header.Add("device-type", c.deviceType)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return fmt.Errorf("error calling user login: %w", err)
+		return nil, fmt.Errorf("error calling user login: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error during login call body reading: %w", err)
+		return nil, fmt.Errorf("error during login call body reading: %w", err)
 	}
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("bad status code for login call: %d!=200, body:%s", resp.StatusCode, string(body))
+		return nil, newAPIError("login", resp.StatusCode, body)
 	}
 
 	var tokenResp TokenResponse
 	err = json.Unmarshal(body, &tokenResp)
 	if err != nil {
-		return fmt.Errorf("error unmarshalling login response: %w", err)
+		return nil, fmt.Errorf("error unmarshalling login response: %w", err)
 	}
+	return &tokenResp, nil
+}
 
-	encryptionKey, err := crypto.DecryptEncryptionKey(tokenResp.Key, *preloginKey)
+// unlockSession decrypts the protected keys of a token response and stores
+// them in the session, along with the tokens.
+func (c *client) unlockSession(tokenResp TokenResponse, grant sessionGrant, preloginKey symmetrickey.Key, kdfIterations int) error {
+	encryptionKey, err := crypto.DecryptEncryptionKey(tokenResp.Key, preloginKey)
 	if err != nil {
 		return fmt.Errorf("error decrypting encryption key: %w", err)
 	}
@@ -161,13 +284,25 @@ func (c *client) Login(username, password string, kdfIterations int) error {
 		return fmt.Errorf("error decrypting private key: %w", err)
 	}
 
-	c.session.accessToken = tokenResp.AccessToken
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+	c.session.encryptionKey = encryptionKey
 	c.session.privateKey = privateKey
+	c.session.kdfIterations = kdfIterations
+	c.session.grant = grant
+	c.setTokens(tokenResp)
 	return nil
 }
 
-func (c *client) CreateOrganization(organizationName string, label string, billingEmail string) (string, error) {
-	encryptedShareKey, shareKey, err := keybuilder.GenerateShareKey(&c.session.privateKey.PublicKey)
+func (c *client) CreateOrganization(ctx context.Context, organizationName string, label string, billingEmail string) (string, error) {
+	c.sessionMu.RLock()
+	privateKey := c.session.privateKey
+	c.sessionMu.RUnlock()
+	if privateKey == nil {
+		return "", fmt.Errorf("no private key in session, did you login?")
+	}
+
+	encryptedShareKey, shareKey, err := keybuilder.GenerateShareKey(&privateKey.PublicKey)
 	if err != nil {
 		return "", fmt.Errorf("error generating share key: %w", err)
 	}
@@ -199,17 +334,15 @@ func (c *client) CreateOrganization(organizationName string, label string, billi
 		return "", fmt.Errorf("unable to marshall organization creation request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.organizationURL(), bytes.NewBuffer(orgCreationRequestBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.organizationURL(), bytes.NewBuffer(orgCreationRequestBytes))
 	if err != nil {
 		return "", fmt.Errorf("error preparing organization creation request: %w", err)
 	}
 
-	req.Header.Add("authorization", fmt.Sprintf("Bearer %s", c.session.accessToken))
 	req.Header.Add("Content-Type", "application/json; charset=utf-8")
 	req.Header.Add("device-type", c.deviceType)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.doAuthenticatedRequest(req)
 	if err != nil {
 		return "", fmt.Errorf("error calling organization creation: %w", err)
 	}
@@ -221,7 +354,7 @@ func (c *client) CreateOrganization(organizationName string, label string, billi
 	}
 
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("bad status code for organization creation call: %d!=200, body:%s", resp.StatusCode, string(body))
+		return "", newAPIError("organization creation", resp.StatusCode, body)
 	}
 
 	var orgCreationResponse CreateOrganizationResponse
@@ -233,17 +366,15 @@ func (c *client) CreateOrganization(organizationName string, label string, billi
 	return orgCreationResponse.Id, nil
 }
 
-func (c *client) GetCollections(orgID string) (string, error) {
-	req, err := http.NewRequest("GET", c.organizationCollectionURL(orgID), nil)
+func (c *client) GetCollections(ctx context.Context, orgID string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.organizationCollectionURL(orgID), nil)
 	if err != nil {
 		return "", fmt.Errorf("error preparing collection retrieval request: %w", err)
 	}
-	req.Header.Add("authorization", fmt.Sprintf("Bearer %s", c.session.accessToken))
 	req.Header.Add("Content-Type", "application/json; charset=utf-8")
 	req.Header.Add("device-type", c.deviceType)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.doAuthenticatedRequest(req)
 	if err != nil {
 		return "", fmt.Errorf("error calling collection retrieval: %w", err)
 	}
@@ -255,7 +386,7 @@ func (c *client) GetCollections(orgID string) (string, error) {
 	}
 
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("bad status code for collection retrieval call: %d!=200, body:%s", resp.StatusCode, string(body))
+		return "", newAPIError("collection retrieval", resp.StatusCode, body)
 	}
 
 	var collResponse CollectionResponse
@@ -267,9 +398,181 @@ func (c *client) GetCollections(orgID string) (string, error) {
 	return collResponse.Data[0].Id, nil
 }
 
+// doAuthenticatedRequest sends a request with the session's access token,
+// refreshing it beforehand if it's about to expire. If the server still
+// rejects the token, it is refreshed and the request is retried once.
+func (c *client) doAuthenticatedRequest(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	accessToken, err := c.validAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doWithAccessToken(req, accessToken)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	accessToken, err = c.refreshAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	retryReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retryReq.Body, err = req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("error rewinding request body: %w", err)
+		}
+	}
+	return c.doWithAccessToken(retryReq, accessToken)
+}
+
+func (c *client) doWithAccessToken(req *http.Request, accessToken string) (*http.Response, error) {
+	req.Header.Set("authorization", fmt.Sprintf("Bearer %s", accessToken))
+	return c.do(req)
+}
+
+func (c *client) do(req *http.Request) (*http.Response, error) {
+	if len(c.userAgent) > 0 {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	return c.httpClient.Do(req)
+}
+
+// validAccessToken returns the session's access token, refreshing it first if
+// it has expired or is about to.
+func (c *client) validAccessToken(ctx context.Context) (string, error) {
+	c.sessionMu.RLock()
+	accessToken := c.session.accessToken
+	expiresAt := c.session.expiresAt
+	c.sessionMu.RUnlock()
+
+	if len(accessToken) == 0 {
+		return "", fmt.Errorf("no access token in session, did you login?")
+	}
+	// Without a known expiry, the token is only refreshed once the server
+	// rejects it.
+	if expiresAt.IsZero() || time.Now().Add(tokenRefreshMargin).Before(expiresAt) {
+		return accessToken, nil
+	}
+	return c.refreshAccessToken(ctx, accessToken)
+}
+
+// refreshAccessToken exchanges the session's refresh token for a new access
+// token, or repeats the client credentials grant for API key sessions.
+// Concurrent callers holding the same stale token only trigger one refresh:
+// the others get the token obtained by the first one.
+func (c *client) refreshAccessToken(ctx context.Context, staleAccessToken string) (string, error) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	if c.session.accessToken != staleAccessToken {
+		return c.session.accessToken, nil
+	}
+
+	if len(c.session.grant.clientSecret) > 0 {
+		tokenResp, err := c.requestToken(ctx, c.session.grant.clientCredentialsForm(), "")
+		if err != nil {
+			return "", fmt.Errorf("error renewing API key session: %w", err)
+		}
+		c.setTokens(*tokenResp)
+		return c.session.accessToken, nil
+	}
+
+	if len(c.session.refreshToken) == 0 {
+		return "", fmt.Errorf("unable to refresh access token: no refresh token in session")
+	}
+
+	form := url.Values{}
+	form.Add("grant_type", "refresh_token")
+	form.Add("client_id", c.session.grant.clientID)
+	form.Add("refresh_token", c.session.refreshToken)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.loginURL(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error preparing token refresh request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	req.Header.Add("device-type", c.deviceType)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("error calling token refresh: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading token refresh response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return "", newAPIError("token refresh", resp.StatusCode, body)
+	}
+
+	var tokenResp TokenResponse
+	err = json.Unmarshal(body, &tokenResp)
+	if err != nil {
+		return "", fmt.Errorf("error unmarshalling token refresh response: %w", err)
+	}
+
+	c.setTokens(tokenResp)
+	return c.session.accessToken, nil
+}
+
+// setTokens stores the tokens of a token response in the session. The caller
+// must hold the session lock.
+func (c *client) setTokens(tokenResp TokenResponse) {
+	c.session.accessToken = tokenResp.AccessToken
+
+	// Servers omitting 'expires_in' leave the expiry unknown.
+	c.session.expiresAt = time.Time{}
+	if tokenResp.ExpireIn > 0 {
+		c.session.expiresAt = time.Now().Add(time.Duration(tokenResp.ExpireIn) * time.Second)
+	}
+
+	// Refresh token responses don't always include a new refresh token.
+	if len(tokenResp.RefreshToken) > 0 {
+		c.session.refreshToken = tokenResp.RefreshToken
+	}
+}
+
 func (c *client) signupURL() string       { return fmt.Sprintf("%s/api/accounts/register", c.serverURL) }
 func (c *client) loginURL() string        { return fmt.Sprintf("%s/identity/connect/token", c.serverURL) }
 func (c *client) organizationURL() string { return fmt.Sprintf("%s/api/organizations", c.serverURL) }
+func (c *client) profileURL() string      { return fmt.Sprintf("%s/api/accounts/profile", c.serverURL) }
+func (c *client) passwordURL() string     { return fmt.Sprintf("%s/api/accounts/password", c.serverURL) }
+func (c *client) kdfURL() string          { return fmt.Sprintf("%s/api/accounts/kdf", c.serverURL) }
+func (c *client) syncURL() string         { return fmt.Sprintf("%s/api/sync?excludeDomains=true", c.serverURL) }
+func (c *client) preloginURL() string {
+	return fmt.Sprintf("%s/identity/accounts/prelogin", c.serverURL)
+}
+func (c *client) revisionDateURL() string {
+	return fmt.Sprintf("%s/api/accounts/revision-date", c.serverURL)
+}
+func (c *client) cipherURL(itemId string) string {
+	return fmt.Sprintf("%s/api/ciphers/%s", c.serverURL, itemId)
+}
+func (c *client) cipherAttachmentV2URL(itemId string) string {
+	return fmt.Sprintf("%s/api/ciphers/%s/attachment/v2", c.serverURL, itemId)
+}
+func (c *client) cipherAttachmentURL(itemId, attachmentId string) string {
+	return fmt.Sprintf("%s/api/ciphers/%s/attachment/%s", c.serverURL, itemId, attachmentId)
+}
+func (c *client) sendAccessURL(accessID string) string {
+	return fmt.Sprintf("%s/api/sends/access/%s", c.serverURL, accessID)
+}
+func (c *client) sendFileAccessURL(sendID, fileID string) string {
+	return fmt.Sprintf("%s/api/sends/%s/access/file/%s", c.serverURL, sendID, fileID)
+}
+func (c *client) emergencyAccessURL(id string) string {
+	return fmt.Sprintf("%s/api/emergency-access/%s", c.serverURL, id)
+}
+func (c *client) userPublicKeyURL(userID string) string {
+	return fmt.Sprintf("%s/api/users/%s/public-key", c.serverURL, userID)
+}
 func (c *client) organizationCollectionURL(orgID string) string {
 	return fmt.Sprintf("%s/api/organizations/%s/collections", c.serverURL, orgID)
 }
@@ -0,0 +1,105 @@
+package webapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+)
+
+// PreLogin returns the KDF settings of an account, which are needed to derive
+// the keys before logging in.
+func (c *client) PreLogin(ctx context.Context, username string) (*PreloginResponse, error) {
+	preloginRequestBytes, err := json.Marshal(PreloginRequest{Email: username})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshall prelogin request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.preloginURL(), bytes.NewBuffer(preloginRequestBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing prelogin request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+	req.Header.Add("device-type", c.deviceType)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling prelogin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading prelogin response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, newAPIError("prelogin", resp.StatusCode, body)
+	}
+
+	var preloginResp PreloginResponse
+	err = json.Unmarshal(body, &preloginResp)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling prelogin response: %w", err)
+	}
+	return &preloginResp, nil
+}
+
+// ChangeMasterPassword changes the master password of the logged in user and
+// the number of KDF iterations used to derive keys from it. The user's
+// encryption key doesn't change, it is only re-encrypted with the new master
+// key. The server invalidates all existing sessions, including the client's,
+// which callers need to log in again.
+func (c *client) ChangeMasterPassword(ctx context.Context, username, currentPassword, newPassword string, kdfIterations int) error {
+	c.sessionMu.RLock()
+	encryptionKey := c.session.encryptionKey
+	currentKdfIterations := c.session.kdfIterations
+	c.sessionMu.RUnlock()
+	if encryptionKey == nil {
+		return fmt.Errorf("no encryption key in session, did you login?")
+	}
+
+	currentPreloginKey, err := keybuilder.BuildPreloginKey(currentPassword, username, currentKdfIterations)
+	if err != nil {
+		return fmt.Errorf("error building current prelogin key: %w", err)
+	}
+
+	newPreloginKey, err := keybuilder.BuildPreloginKey(newPassword, username, kdfIterations)
+	if err != nil {
+		return fmt.Errorf("error building new prelogin key: %w", err)
+	}
+
+	encryptedEncryptionKey, err := keybuilder.RewrapEncryptionKey(*newPreloginKey, *encryptionKey)
+	if err != nil {
+		return fmt.Errorf("error encrypting encryption key with new master key: %w", err)
+	}
+
+	currentPasswordHash := crypto.HashPassword(currentPassword, *currentPreloginKey, false)
+	newPasswordHash := crypto.HashPassword(newPassword, *newPreloginKey, false)
+
+	// The KDF endpoint also changes the master password, the password one is
+	// only used when the KDF settings remain the same.
+	if kdfIterations != currentKdfIterations {
+		kdfRequest := KdfRequest{
+			Kdf:                   keybuilder.PBKDF2_SHA256,
+			KdfIterations:         kdfIterations,
+			MasterPasswordHash:    currentPasswordHash,
+			NewMasterPasswordHash: newPasswordHash,
+			Key:                   encryptedEncryptionKey,
+		}
+		err = c.callJSON(ctx, "POST", c.kdfURL(), kdfRequest, nil, "kdf change")
+	} else {
+		passwordRequest := PasswordRequest{
+			MasterPasswordHash:    currentPasswordHash,
+			NewMasterPasswordHash: newPasswordHash,
+			Key:                   encryptedEncryptionKey,
+		}
+		err = c.callJSON(ctx, "POST", c.passwordURL(), passwordRequest, nil, "master password change")
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+package webapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeMasterPassword(t *testing.T) {
+	testCases := []struct {
+		name             string
+		kdfIterations    int
+		expectedEndpoint string
+	}{
+		{name: "same kdf iterations", kdfIterations: 1000, expectedEndpoint: "/api/accounts/password"},
+		{name: "new kdf iterations", kdfIterations: 2000, expectedEndpoint: "/api/accounts/kdf"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, account := newTestAccountServer(t, "current-password", 1000)
+			defer server.Close()
+
+			c := newTestClient(t, server.URL)
+			err := c.Login(context.Background(), testAccountEmail, "current-password", 1000)
+			assert.NoError(t, err)
+			encryptionKey := c.session.encryptionKey
+
+			err = c.ChangeMasterPassword(context.Background(), testAccountEmail, "current-password", "new-password", tc.kdfIterations)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedEndpoint, account.lastChangeEndpoint)
+			assert.Equal(t, tc.kdfIterations, account.kdfIterations)
+
+			// The encryption key remains the same, and can be decrypted with
+			// the new master password.
+			newPreloginKey, err := keybuilder.BuildPreloginKey("new-password", testAccountEmail, tc.kdfIterations)
+			assert.NoError(t, err)
+			decryptedKey, err := crypto.DecryptEncryptionKey(account.key, *newPreloginKey)
+			assert.NoError(t, err)
+			assert.Equal(t, encryptionKey.Key, decryptedKey.Key)
+		})
+	}
+}
+
+func TestChangeMasterPasswordWithTwoFactor(t *testing.T) {
+	server, account := newTestAccountServer(t, "current-password", 1000)
+	defer server.Close()
+	account.twoFactorCode = "123456"
+
+	c := newTestClient(t, server.URL)
+	err := c.Login(context.Background(), testAccountEmail, "current-password", 1000, WithTwoFactor(TwoFactorProviderAuthenticator, "123456"))
+	assert.NoError(t, err)
+
+	// The change doesn't log in again, which would require a new code.
+	err = c.ChangeMasterPassword(context.Background(), testAccountEmail, "current-password", "new-password", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/accounts/password", account.lastChangeEndpoint)
+	assert.Equal(t, 1, account.tokenCalls)
+}
+
+func TestChangeMasterPasswordWithWrongCurrentPassword(t *testing.T) {
+	server, account := newTestAccountServer(t, "current-password", 1000)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	err := c.Login(context.Background(), testAccountEmail, "current-password", 1000)
+	assert.NoError(t, err)
+
+	err = c.ChangeMasterPassword(context.Background(), testAccountEmail, "wrong-password", "new-password", 1000)
+	assert.ErrorContains(t, err, "Invalid password")
+	assert.Empty(t, account.lastChangeEndpoint)
+}
+
+func TestPreLogin(t *testing.T) {
+	server, _ := newTestAccountServer(t, "current-password", 1000)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	prelogin, err := c.PreLogin(context.Background(), testAccountEmail)
+	assert.NoError(t, err)
+	assert.Equal(t, keybuilder.PBKDF2_SHA256, prelogin.Kdf)
+	assert.Equal(t, 1000, prelogin.KdfIterations)
+}
+
+const testAccountEmail = "test@example.com"
+
+type testAccount struct {
+	passwordHash       string
+	key                string
+	privateKey         string
+	kdfIterations      int
+	lastChangeEndpoint string
+	twoFactorCode      string
+	tokenCalls         int
+}
+
+// newTestAccountServer simulates the account endpoints of a server, for a
+// single user.
+func newTestAccountServer(t *testing.T, password string, kdfIterations int) (*httptest.Server, *testAccount) {
+	preloginKey, err := keybuilder.BuildPreloginKey(password, testAccountEmail, kdfIterations)
+	assert.NoError(t, err)
+
+	encryptionKey, encryptedEncryptionKey, err := keybuilder.GenerateEncryptionKey(*preloginKey)
+	assert.NoError(t, err)
+
+	_, encryptedPrivateKey, err := keybuilder.GenerateKeyPair(*encryptionKey)
+	assert.NoError(t, err)
+
+	account := &testAccount{
+		passwordHash:  crypto.HashPassword(password, *preloginKey, false),
+		key:           encryptedEncryptionKey,
+		privateKey:    encryptedPrivateKey,
+		kdfIterations: kdfIterations,
+	}
+
+	invalidPassword := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"The model state is invalid.","validationErrors":{"MasterPasswordHash":["Invalid password."]}}`))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/accounts/prelogin", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(PreloginResponse{Kdf: keybuilder.PBKDF2_SHA256, KdfIterations: account.kdfIterations})
+	})
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		account.tokenCalls++
+		if r.PostForm.Get("password") != account.passwordHash {
+			invalidPassword(w)
+			return
+		}
+		if len(account.twoFactorCode) > 0 && r.PostForm.Get("twoFactorToken") != account.twoFactorCode {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Two factor required.","TwoFactorProviders":[0],"TwoFactorProviders2":{"0":null}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken:   "access-token",
+			ExpireIn:      3600,
+			Key:           account.key,
+			PrivateKey:    account.privateKey,
+			KdfIterations: account.kdfIterations,
+		})
+	})
+	mux.HandleFunc("/api/accounts/password", func(w http.ResponseWriter, r *http.Request) {
+		var req PasswordRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.MasterPasswordHash != account.passwordHash {
+			invalidPassword(w)
+			return
+		}
+		account.passwordHash = req.NewMasterPasswordHash
+		account.key = req.Key
+		account.lastChangeEndpoint = r.URL.Path
+	})
+	mux.HandleFunc("/api/accounts/kdf", func(w http.ResponseWriter, r *http.Request) {
+		var req KdfRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.MasterPasswordHash != account.passwordHash {
+			invalidPassword(w)
+			return
+		}
+		account.passwordHash = req.NewMasterPasswordHash
+		account.key = req.Key
+		account.kdfIterations = req.KdfIterations
+		account.lastChangeEndpoint = r.URL.Path
+	})
+	return httptest.NewServer(mux), account
+}
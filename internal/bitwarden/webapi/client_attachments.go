@@ -0,0 +1,390 @@
+package webapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+// CreateAttachment encrypts a file with a new attachment key and uploads it
+// to an item. The file is streamed and never fully loaded in memory.
+func (c *client) CreateAttachment(ctx context.Context, itemId, filePath string) (*Attachment, error) {
+	cipher, err := c.getCipher(ctx, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherKey, err := c.cipherKey(ctx, *cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAttachmentKey := make([]byte, 64)
+	_, err = rand.Read(rawAttachmentKey)
+	if err != nil {
+		return nil, fmt.Errorf("error generating attachment key: %w", err)
+	}
+
+	attachmentKey, err := symmetrickey.NewFromRawBytes(rawAttachmentKey)
+	if err != nil {
+		return nil, fmt.Errorf("error creating attachment key: %w", err)
+	}
+
+	encryptedAttachmentKey, err := crypto.Encrypt(rawAttachmentKey, *cipherKey)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting attachment key: %w", err)
+	}
+
+	fileName := filepath.Base(filePath)
+	encryptedFileName, err := crypto.Encrypt([]byte(fileName), *cipherKey)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting attachment file name: %w", err)
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading attachment file: %w", err)
+	}
+	encryptedFileSize := crypto.EncryptedFileSize(fileInfo.Size())
+
+	uploadRequest := AttachmentUploadRequest{
+		Key:      encryptedAttachmentKey,
+		FileName: encryptedFileName,
+		FileSize: encryptedFileSize,
+	}
+
+	var uploadResponse AttachmentUploadResponse
+	err = c.callJSON(ctx, "POST", c.cipherAttachmentV2URL(itemId), uploadRequest, &uploadResponse, "attachment creation")
+	if err != nil {
+		return nil, err
+	}
+
+	encryptFile := func(w io.Writer) error {
+		return crypto.EncryptFile(w, func() (io.ReadCloser, error) { return os.Open(filePath) }, *attachmentKey)
+	}
+
+	switch uploadResponse.FileUploadType {
+	case FileUploadTypeDirect:
+		err = c.uploadAttachmentDirect(ctx, itemId, uploadResponse.AttachmentId, encryptedFileName, encryptFile)
+	case FileUploadTypeAzure:
+		err = c.uploadAttachmentAzure(ctx, uploadResponse.Url, encryptedFileSize, encryptFile)
+	default:
+		err = fmt.Errorf("unsupported file upload type: %d", uploadResponse.FileUploadType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range uploadResponse.CipherResponse.Attachments {
+		if attachment.Id == uploadResponse.AttachmentId {
+			return decryptAttachment(attachment, *cipherKey)
+		}
+	}
+	return nil, fmt.Errorf("attachment '%s' not found in cipher after creation", uploadResponse.AttachmentId)
+}
+
+// DownloadAttachment downloads and decrypts an attachment into w.
+//
+// The integrity of the attachment can only be verified once it has been
+// fully downloaded: if an error is returned, whatever was written to w must
+// be discarded.
+func (c *client) DownloadAttachment(ctx context.Context, itemId, attachmentId string, w io.Writer) error {
+	cipher, err := c.getCipher(ctx, itemId)
+	if err != nil {
+		return err
+	}
+
+	cipherKey, err := c.cipherKey(ctx, *cipher)
+	if err != nil {
+		return err
+	}
+
+	var attachment AttachmentResponse
+	err = c.callJSON(ctx, "GET", c.cipherAttachmentURL(itemId, attachmentId), nil, &attachment, "attachment retrieval")
+	if err != nil {
+		return err
+	}
+
+	// Legacy attachments don't have their own key and are encrypted with the
+	// cipher's key.
+	attachmentKey := cipherKey
+	if len(attachment.Key) > 0 {
+		attachmentKey, err = crypto.DecryptSymmetricKey(attachment.Key, *cipherKey)
+		if err != nil {
+			return fmt.Errorf("error decrypting attachment key: %w", err)
+		}
+	}
+
+	downloadURL := attachment.Url
+	if strings.HasPrefix(downloadURL, "/") {
+		downloadURL = c.serverURL + downloadURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+	if err != nil {
+		return fmt.Errorf("error preparing attachment download request: %w", err)
+	}
+
+	// The download URL is signed and possibly hosted on a third-party storage,
+	// the access token must not be sent along.
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("error calling attachment download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return newAPIError("attachment download", resp.StatusCode, body)
+	}
+
+	err = crypto.DecryptFile(w, resp.Body, *attachmentKey)
+	if err != nil {
+		return fmt.Errorf("error decrypting attachment: %w", err)
+	}
+	return nil
+}
+
+// GetAttachment downloads and decrypts an attachment in memory.
+func (c *client) GetAttachment(ctx context.Context, itemId, attachmentId string) ([]byte, error) {
+	var content bytes.Buffer
+	err := c.DownloadAttachment(ctx, itemId, attachmentId, &content)
+	if err != nil {
+		return nil, err
+	}
+	return content.Bytes(), nil
+}
+
+func (c *client) DeleteAttachment(ctx context.Context, itemId, attachmentId string) error {
+	return c.callJSON(ctx, "DELETE", c.cipherAttachmentURL(itemId, attachmentId), nil, nil, "attachment deletion")
+}
+
+func (c *client) uploadAttachmentDirect(ctx context.Context, itemId, attachmentId, encryptedFileName string, encryptFile func(io.Writer) error) error {
+	bodyReader, bodyWriter := io.Pipe()
+	defer bodyReader.Close()
+	multipartWriter := multipart.NewWriter(bodyWriter)
+
+	go func() {
+		part, err := multipartWriter.CreateFormFile("data", encryptedFileName)
+		if err == nil {
+			err = encryptFile(part)
+		}
+		if err == nil {
+			err = multipartWriter.Close()
+		}
+		bodyWriter.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.cipherAttachmentURL(itemId, attachmentId), bodyReader)
+	if err != nil {
+		return fmt.Errorf("error preparing attachment upload request: %w", err)
+	}
+	req.Header.Add("Content-Type", multipartWriter.FormDataContentType())
+	req.Header.Add("device-type", c.deviceType)
+
+	// The request body is streamed and can't be replayed, which is why the
+	// token refresh-and-retry logic isn't used here.
+	accessToken, err := c.validAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doWithAccessToken(req, accessToken)
+	if err != nil {
+		return fmt.Errorf("error calling attachment upload: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return newAPIError("attachment upload", resp.StatusCode, body)
+	}
+	return nil
+}
+
+func (c *client) uploadAttachmentAzure(ctx context.Context, uploadURL string, encryptedFileSize int64, encryptFile func(io.Writer) error) error {
+	bodyReader, bodyWriter := io.Pipe()
+	defer bodyReader.Close()
+	go func() {
+		bodyWriter.CloseWithError(encryptFile(bodyWriter))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", uploadURL, bodyReader)
+	if err != nil {
+		return fmt.Errorf("error preparing attachment upload request: %w", err)
+	}
+	req.ContentLength = encryptedFileSize
+	req.Header.Add("x-ms-blob-type", "BlockBlob")
+	req.Header.Add("x-ms-version", "2020-04-08")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("error calling attachment upload: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return newAPIError("attachment upload", resp.StatusCode, body)
+	}
+	return nil
+}
+
+func (c *client) getCipher(ctx context.Context, itemId string) (*Cipher, error) {
+	var cipher Cipher
+	err := c.callJSON(ctx, "GET", c.cipherURL(itemId), nil, &cipher, "cipher retrieval")
+	if err != nil {
+		return nil, err
+	}
+	return &cipher, nil
+}
+
+// cipherKey returns the key protecting a cipher's content: either its own key
+// if it has one, or the key of the organization or user owning it.
+func (c *client) cipherKey(ctx context.Context, cipher Cipher) (*symmetrickey.Key, error) {
+	var ownerKey *symmetrickey.Key
+	var err error
+	if len(cipher.OrganizationId) > 0 {
+		ownerKey, err = c.organizationKey(ctx, cipher.OrganizationId)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		c.sessionMu.RLock()
+		ownerKey = c.session.encryptionKey
+		c.sessionMu.RUnlock()
+		if ownerKey == nil {
+			return nil, fmt.Errorf("no encryption key in session, did you login?")
+		}
+	}
+
+	if len(cipher.Key) == 0 {
+		return ownerKey, nil
+	}
+
+	cipherKey, err := crypto.DecryptSymmetricKey(cipher.Key, *ownerKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting cipher key: %w", err)
+	}
+	return cipherKey, nil
+}
+
+func (c *client) organizationKey(ctx context.Context, orgID string) (*symmetrickey.Key, error) {
+	c.sessionMu.RLock()
+	orgKey, ok := c.session.organizationKeys[orgID]
+	privateKey := c.session.privateKey
+	c.sessionMu.RUnlock()
+	if ok {
+		return orgKey, nil
+	}
+	if privateKey == nil {
+		return nil, fmt.Errorf("no private key in session, did you login?")
+	}
+
+	var profile ProfileResponse
+	err := c.callJSON(ctx, "GET", c.profileURL(), nil, &profile, "profile retrieval")
+	if err != nil {
+		return nil, err
+	}
+
+	orgKeys := map[string]*symmetrickey.Key{}
+	for _, org := range profile.Organizations {
+		key, err := crypto.DecryptOrganizationKey(org.Key, privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting key of organization '%s': %w", org.Id, err)
+		}
+		orgKeys[org.Id] = key
+	}
+
+	c.sessionMu.Lock()
+	c.session.organizationKeys = orgKeys
+	c.sessionMu.Unlock()
+
+	orgKey, ok = orgKeys[orgID]
+	if !ok {
+		return nil, fmt.Errorf("organization '%s' not found in profile", orgID)
+	}
+	return orgKey, nil
+}
+
+func decryptAttachment(attachment AttachmentResponse, cipherKey symmetrickey.Key) (*Attachment, error) {
+	fileName, err := crypto.DecryptString(attachment.FileName, cipherKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting attachment file name: %w", err)
+	}
+
+	return &Attachment{
+		ID:       attachment.Id,
+		FileName: string(fileName),
+		Size:     attachment.Size,
+		SizeName: attachment.SizeName,
+		Url:      attachment.Url,
+	}, nil
+}
+
+// callJSON sends an authenticated request with an optional JSON body, and
+// decodes the JSON response into respBody if it's not nil.
+func (c *client) callJSON(ctx context.Context, method, url string, reqBody, respBody interface{}, description string) error {
+	return c.callJSONWith(ctx, c.doAuthenticatedRequest, method, url, reqBody, respBody, description)
+}
+
+// callAnonymousJSON is like callJSON, for endpoints that don't require to be
+// logged in.
+func (c *client) callAnonymousJSON(ctx context.Context, method, url string, reqBody, respBody interface{}, description string) error {
+	return c.callJSONWith(ctx, c.do, method, url, reqBody, respBody, description)
+}
+
+func (c *client) callJSONWith(ctx context.Context, do func(*http.Request) (*http.Response, error), method, url string, reqBody, respBody interface{}, description string) error {
+	var bodyReader io.Reader
+	if reqBody != nil {
+		reqBodyBytes, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("unable to marshall %s request: %w", description, err)
+		}
+		bodyReader = bytes.NewReader(reqBodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return fmt.Errorf("error preparing %s request: %w", description, err)
+	}
+	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+	req.Header.Add("device-type", c.deviceType)
+
+	resp, err := do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", description, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading %s response: %w", description, err)
+	}
+
+	if resp.StatusCode != 200 {
+		return newAPIError(description, resp.StatusCode, body)
+	}
+
+	if respBody == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(body, respBody)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling %s response: %w", description, err)
+	}
+	return nil
+}
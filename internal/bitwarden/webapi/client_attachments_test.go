@@ -0,0 +1,93 @@
+package webapi
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+	"github.com/stretchr/testify/assert"
+)
+
+var testUserKey = []byte{40, 201, 91, 4, 62, 57, 230, 98, 146, 113, 111, 129, 180, 230, 116, 91, 110, 163, 34, 47, 127, 131, 59, 252, 7, 101, 153, 48, 185, 209, 19, 45, 227, 232, 133, 165, 156, 157, 9, 202, 36, 235, 96, 151, 31, 27, 38, 238, 213, 219, 189, 229, 182, 208, 39, 208, 53, 69, 204, 22, 157, 76, 151, 209}
+
+func TestAttachmentUploadAndDownload(t *testing.T) {
+	var uploadRequest AttachmentUploadRequest
+	var uploadedContent []byte
+	var uploadedFileName string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/ciphers/item-id", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Cipher{Id: "item-id"})
+	})
+	mux.HandleFunc("/api/ciphers/item-id/attachment/v2", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&uploadRequest))
+		json.NewEncoder(w).Encode(AttachmentUploadResponse{
+			AttachmentId:   "attachment-id",
+			FileUploadType: FileUploadTypeDirect,
+			CipherResponse: Cipher{
+				Id: "item-id",
+				Attachments: []AttachmentResponse{
+					{Id: "attachment-id", FileName: uploadRequest.FileName, Key: uploadRequest.Key, Size: "11", SizeName: "11 Bytes"},
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/api/ciphers/item-id/attachment/attachment-id", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			file, header, err := r.FormFile("data")
+			assert.NoError(t, err)
+			uploadedFileName = header.Filename
+			uploadedContent, err = ioutil.ReadAll(file)
+			assert.NoError(t, err)
+		case "GET":
+			json.NewEncoder(w).Encode(AttachmentResponse{
+				Id:  "attachment-id",
+				Key: uploadRequest.Key,
+				Url: "/attachments/item-id/attachment-id",
+			})
+		}
+	})
+	mux.HandleFunc("/attachments/item-id/attachment-id", func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("authorization"))
+		w.Write(uploadedContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "attachment.txt")
+	assert.NoError(t, os.WriteFile(filePath, []byte("hello world"), 0600))
+
+	c := newTestLoggedInClient(t, server.URL)
+	attachment, err := c.CreateAttachment(context.Background(), "item-id", filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, "attachment-id", attachment.ID)
+	assert.Equal(t, "attachment.txt", attachment.FileName)
+	assert.NotEqual(t, "attachment.txt", uploadedFileName)
+	assert.Equal(t, int64(len(uploadedContent)), uploadRequest.FileSize)
+	assert.NotContains(t, string(uploadedContent), "hello world")
+
+	content, err := c.GetAttachment(context.Background(), "item-id", "attachment-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+}
+
+func newTestLoggedInClient(t *testing.T, serverURL string) *client {
+	userKey, err := symmetrickey.NewFromRawBytes(testUserKey)
+	assert.NoError(t, err)
+
+	c := newTestClient(t, serverURL)
+	c.session = session{
+		accessToken:   "access-token",
+		expiresAt:     time.Now().Add(time.Hour),
+		encryptionKey: userKey,
+	}
+	return c
+}
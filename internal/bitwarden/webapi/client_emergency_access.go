@@ -0,0 +1,129 @@
+package webapi
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+)
+
+// InviteEmergencyAccess grants emergency access to the logged in user's Vault
+// to another user. The grantee needs to accept the invitation before it can be
+// confirmed.
+func (c *client) InviteEmergencyAccess(ctx context.Context, email string, accessType EmergencyAccessType, waitTimeDays int) (*EmergencyAccess, error) {
+	inviteReq := EmergencyAccessInviteRequest{
+		Email:        email,
+		Type:         accessType,
+		WaitTimeDays: waitTimeDays,
+	}
+	err := c.callJSON(ctx, "POST", c.emergencyAccessURL("invite"), inviteReq, nil, "emergency access invitation")
+	if err != nil {
+		return nil, err
+	}
+
+	// The invitation's identifier isn't returned, but there can only be one
+	// per grantee.
+	accesses, err := c.GetEmergencyAccesses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, access := range accesses {
+		if strings.EqualFold(access.Email, email) {
+			return &access, nil
+		}
+	}
+	return nil, fmt.Errorf("emergency access for '%s' not found after invitation", email)
+}
+
+// GetEmergencyAccesses lists the emergency accesses granted by the logged in
+// user.
+func (c *client) GetEmergencyAccesses(ctx context.Context) ([]EmergencyAccess, error) {
+	var listResp EmergencyAccessListResponse
+	err := c.callJSON(ctx, "GET", c.emergencyAccessURL("trusted"), nil, &listResp, "emergency access listing")
+	if err != nil {
+		return nil, err
+	}
+	return listResp.Data, nil
+}
+
+func (c *client) GetEmergencyAccess(ctx context.Context, id string) (*EmergencyAccess, error) {
+	var access EmergencyAccess
+	err := c.callJSON(ctx, "GET", c.emergencyAccessURL(id), nil, &access, "emergency access retrieval")
+	if err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
+
+func (c *client) UpdateEmergencyAccess(ctx context.Context, id string, accessType EmergencyAccessType, waitTimeDays int) error {
+	updateReq := EmergencyAccessUpdateRequest{
+		Type:         accessType,
+		WaitTimeDays: waitTimeDays,
+	}
+	return c.callJSON(ctx, "PUT", c.emergencyAccessURL(id), updateReq, nil, "emergency access update")
+}
+
+func (c *client) DeleteEmergencyAccess(ctx context.Context, id string) error {
+	return c.callJSON(ctx, "DELETE", c.emergencyAccessURL(id), nil, nil, "emergency access deletion")
+}
+
+// ConfirmEmergencyAccess confirms an emergency access accepted by its
+// grantee, by sharing the logged in user's key encrypted with the grantee's
+// public key.
+func (c *client) ConfirmEmergencyAccess(ctx context.Context, id string) error {
+	c.sessionMu.RLock()
+	encryptionKey := c.session.encryptionKey
+	c.sessionMu.RUnlock()
+	if encryptionKey == nil {
+		return fmt.Errorf("no encryption key in session, did you login?")
+	}
+
+	access, err := c.GetEmergencyAccess(ctx, id)
+	if err != nil {
+		return err
+	}
+	if access.Status != EmergencyAccessStatusAccepted {
+		return fmt.Errorf("emergency access can only be confirmed once accepted by its grantee (status: %d)", access.Status)
+	}
+
+	publicKey, err := c.userPublicKey(ctx, access.GranteeId)
+	if err != nil {
+		return err
+	}
+
+	encryptedKey, err := crypto.EncryptAsymmetric(encryptionKey.Key, publicKey)
+	if err != nil {
+		return fmt.Errorf("error encrypting key for grantee: %w", err)
+	}
+
+	confirmReq := EmergencyAccessConfirmRequest{Key: encryptedKey}
+	return c.callJSON(ctx, "POST", c.emergencyAccessURL(id+"/confirm"), confirmReq, nil, "emergency access confirmation")
+}
+
+func (c *client) userPublicKey(ctx context.Context, userID string) (*rsa.PublicKey, error) {
+	var publicKeyResp UserPublicKeyResponse
+	err := c.callJSON(ctx, "GET", c.userPublicKeyURL(userID), nil, &publicKeyResp, "user public key retrieval")
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeyBytes, err := base64.StdEncoding.DecodeString(publicKeyResp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding public key: %w", err)
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(publicKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key: %w", err)
+	}
+
+	rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an RSA key")
+	}
+	return rsaPublicKey, nil
+}
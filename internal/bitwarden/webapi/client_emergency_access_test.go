@@ -0,0 +1,135 @@
+package webapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInviteEmergencyAccess(t *testing.T) {
+	server, accesses, _ := newTestEmergencyAccessServer(t)
+	defer server.Close()
+
+	c := newTestLoggedInClient(t, server.URL)
+	access, err := c.InviteEmergencyAccess(context.Background(), "Grantee@example.com", EmergencyAccessTypeTakeover, 7)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "access-id", access.Id)
+		assert.Equal(t, EmergencyAccessStatusInvited, access.Status)
+		assert.Equal(t, EmergencyAccessTypeTakeover, access.Type)
+		assert.Equal(t, 7, access.WaitTimeDays)
+	}
+
+	err = c.UpdateEmergencyAccess(context.Background(), "access-id", EmergencyAccessTypeView, 14)
+	assert.NoError(t, err)
+	assert.Equal(t, EmergencyAccessTypeView, accesses["access-id"].Type)
+	assert.Equal(t, 14, accesses["access-id"].WaitTimeDays)
+
+	err = c.DeleteEmergencyAccess(context.Background(), "access-id")
+	assert.NoError(t, err)
+
+	_, err = c.GetEmergencyAccess(context.Background(), "access-id")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestConfirmEmergencyAccess(t *testing.T) {
+	server, accesses, granteeKey := newTestEmergencyAccessServer(t)
+	defer server.Close()
+
+	c := newTestLoggedInClient(t, server.URL)
+	_, err := c.InviteEmergencyAccess(context.Background(), "grantee@example.com", EmergencyAccessTypeView, 7)
+	assert.NoError(t, err)
+
+	err = c.ConfirmEmergencyAccess(context.Background(), "access-id")
+	assert.ErrorContains(t, err, "can only be confirmed once accepted")
+
+	accesses["access-id"].Status = EmergencyAccessStatusAccepted
+	err = c.ConfirmEmergencyAccess(context.Background(), "access-id")
+	if assert.NoError(t, err) {
+		assert.Equal(t, EmergencyAccessStatusConfirmed, accesses["access-id"].Status)
+
+		// The grantee can decrypt the grantor's key with their private key.
+		sharedKey, err := crypto.DecryptString(accesses["access-id"].key, granteeKey)
+		assert.NoError(t, err)
+		assert.Equal(t, c.session.encryptionKey.Key, sharedKey)
+	}
+}
+
+type testEmergencyAccess struct {
+	EmergencyAccess
+	key string
+}
+
+func newTestEmergencyAccessServer(t *testing.T) (*httptest.Server, map[string]*testEmergencyAccess, *rsa.PrivateKey) {
+	granteeKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	granteePublicKey, err := x509.MarshalPKIXPublicKey(&granteeKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accesses := map[string]*testEmergencyAccess{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/emergency-access/invite", func(w http.ResponseWriter, r *http.Request) {
+		var req EmergencyAccessInviteRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		accesses["access-id"] = &testEmergencyAccess{EmergencyAccess: EmergencyAccess{
+			Id:           "access-id",
+			Email:        "grantee@example.com",
+			GranteeId:    "grantee-id",
+			Status:       EmergencyAccessStatusInvited,
+			Type:         req.Type,
+			WaitTimeDays: req.WaitTimeDays,
+		}}
+	})
+	mux.HandleFunc("/api/emergency-access/trusted", func(w http.ResponseWriter, r *http.Request) {
+		listResp := EmergencyAccessListResponse{Data: []EmergencyAccess{}}
+		for _, access := range accesses {
+			listResp.Data = append(listResp.Data, access.EmergencyAccess)
+		}
+		json.NewEncoder(w).Encode(listResp)
+	})
+	mux.HandleFunc("/api/emergency-access/access-id", func(w http.ResponseWriter, r *http.Request) {
+		access, ok := accesses["access-id"]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+			json.NewEncoder(w).Encode(access.EmergencyAccess)
+		case "PUT":
+			var req EmergencyAccessUpdateRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			access.Type = req.Type
+			access.WaitTimeDays = req.WaitTimeDays
+		case "DELETE":
+			delete(accesses, "access-id")
+		}
+	})
+	mux.HandleFunc("/api/emergency-access/access-id/confirm", func(w http.ResponseWriter, r *http.Request) {
+		var req EmergencyAccessConfirmRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		accesses["access-id"].key = req.Key
+		accesses["access-id"].Status = EmergencyAccessStatusConfirmed
+	})
+	mux.HandleFunc("/api/users/grantee-id/public-key", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(UserPublicKeyResponse{
+			UserId:    "grantee-id",
+			PublicKey: base64.StdEncoding.EncodeToString(granteePublicKey),
+		})
+	})
+	return httptest.NewServer(mux), accesses, granteeKey
+}
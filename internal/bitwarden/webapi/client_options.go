@@ -0,0 +1,128 @@
+package webapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+type Options func(c Client)
+
+// WithHTTPClient sets the HTTP client the other transport options are applied
+// on. It is copied and never modified.
+func WithHTTPClient(httpClient *http.Client) Options {
+	return func(c Client) {
+		c.(*client).baseHTTPClient = httpClient
+	}
+}
+
+// WithExtraCACertsPath extends the system's root CAs with the certificates
+// found in a PEM file.
+func WithExtraCACertsPath(extraCACertsPath string) Options {
+	return func(c Client) {
+		c.(*client).extraCACertsPath = extraCACertsPath
+	}
+}
+
+// WithProxyURL sends all requests through a proxy, instead of the one
+// configured in the environment.
+func WithProxyURL(proxyURL string) Options {
+	return func(c Client) {
+		c.(*client).proxyURL = proxyURL
+	}
+}
+
+// WithRequestTimeout limits the duration of every request, including
+// reading the response body.
+func WithRequestTimeout(timeout time.Duration) Options {
+	return func(c Client) {
+		c.(*client).requestTimeout = timeout
+	}
+}
+
+func WithUserAgent(userAgent string) Options {
+	return func(c Client) {
+		c.(*client).userAgent = userAgent
+	}
+}
+
+type LoginOption func(form url.Values)
+
+// WithTwoFactor provides the second factor of accounts with two-step login.
+// Both Bitwarden and Vaultwarden accept the camel-cased form of the
+// 'two_factor_*' grant parameters.
+func WithTwoFactor(provider TwoFactorProvider, token string) LoginOption {
+	return func(form url.Values) {
+		form.Set("twoFactorProvider", strconv.Itoa(int(provider)))
+		form.Set("twoFactorToken", token)
+		form.Set("twoFactorRemember", "0")
+	}
+}
+
+func (c *client) buildHTTPClient() (*http.Client, error) {
+	httpClient := &http.Client{}
+	if c.baseHTTPClient != nil {
+		*httpClient = *c.baseHTTPClient
+	}
+
+	if c.requestTimeout > 0 {
+		httpClient.Timeout = c.requestTimeout
+	}
+
+	if len(c.extraCACertsPath) == 0 && len(c.proxyURL) == 0 {
+		return httpClient, nil
+	}
+
+	var transport *http.Transport
+	if httpClient.Transport == nil {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	} else if baseTransport, ok := httpClient.Transport.(*http.Transport); ok {
+		transport = baseTransport.Clone()
+	} else {
+		return nil, fmt.Errorf("unable to configure CA certificates or proxy on a transport of type %T", httpClient.Transport)
+	}
+
+	if len(c.extraCACertsPath) > 0 {
+		rootCAs, err := loadCertPool(c.extraCACertsPath)
+		if err != nil {
+			return nil, err
+		}
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.RootCAs = rootCAs
+	}
+
+	if len(c.proxyURL) > 0 {
+		proxyURL, err := url.Parse(c.proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	httpClient.Transport = transport
+	return httpClient, nil
+}
+
+func loadCertPool(extraCACertsPath string) (*x509.CertPool, error) {
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	extraCACerts, err := os.ReadFile(extraCACertsPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading extra CA certificates: %w", err)
+	}
+
+	if !rootCAs.AppendCertsFromPEM(extraCACerts) {
+		return nil, fmt.Errorf("no valid certificate found in '%s'", extraCACertsPath)
+	}
+	return rootCAs, nil
+}
@@ -0,0 +1,86 @@
+package webapi
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientWithExtraCACerts(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"id":"collection-id"}]}`))
+	}))
+	defer server.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caPath, caPEM, 0600))
+
+	c, err := NewClient(server.URL)
+	assert.NoError(t, err)
+	setTestSession(c)
+	_, err = c.GetCollections(context.Background(), "org-id")
+	assert.ErrorContains(t, err, "certificate")
+
+	c, err = NewClient(server.URL, WithExtraCACertsPath(caPath))
+	assert.NoError(t, err)
+	setTestSession(c)
+	collectionID, err := c.GetCollections(context.Background(), "org-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "collection-id", collectionID)
+}
+
+func TestClientWithInvalidExtraCACerts(t *testing.T) {
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caPath, []byte("not a certificate"), 0600))
+
+	_, err := NewClient("https://127.0.0.1", WithExtraCACertsPath(caPath))
+	assert.ErrorContains(t, err, "no valid certificate found")
+}
+
+func TestClientWithUserAgent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "terraform-provider-bitwarden/test", r.Header.Get("User-Agent"))
+		w.Write([]byte(`{"data":[{"id":"collection-id"}]}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL, WithUserAgent("terraform-provider-bitwarden/test"))
+	assert.NoError(t, err)
+	setTestSession(c)
+
+	_, err = c.GetCollections(context.Background(), "org-id")
+	assert.NoError(t, err)
+}
+
+func TestClientHonorsContextCancellation(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	c, err := NewClient(server.URL)
+	assert.NoError(t, err)
+	setTestSession(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = c.GetCollections(ctx, "org-id")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func setTestSession(c Client) {
+	c.(*client).session = session{
+		accessToken: "access-token",
+		expiresAt:   time.Now().Add(time.Hour),
+	}
+}
@@ -0,0 +1,157 @@
+package webapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+const (
+	// Sends of the official cloud are shared on a dedicated domain, but are
+	// accessed through the usual API.
+	bitwardenSendHost      = "send.bitwarden.com"
+	bitwardenCloudVaultURL = "https://vault.bitwarden.com"
+)
+
+// ParseSendURL extracts the server, the access identifier and the key
+// material from the URL of a Send, e.g.
+// https://vault.example.com/#/send/<access_id>/<key>.
+func ParseSendURL(sendURL string) (serverURL, accessID string, keyMaterial []byte, err error) {
+	u, err := url.Parse(sendURL)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error parsing send URL: %w", err)
+	}
+	if len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return "", "", nil, fmt.Errorf("send URL is not absolute")
+	}
+
+	parts := strings.Split(strings.Trim(u.Fragment, "/"), "/")
+	if len(parts) != 3 || parts[0] != "send" || len(parts[1]) == 0 || len(parts[2]) == 0 {
+		return "", "", nil, fmt.Errorf("send URL doesn't end with '#/send/<access_id>/<key>'")
+	}
+
+	keyMaterial, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error decoding send key: %w", err)
+	}
+
+	if u.Host == bitwardenSendHost {
+		return bitwardenCloudVaultURL, parts[1], keyMaterial, nil
+	}
+	serverURL = strings.TrimSuffix(fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, u.Path), "/")
+	return serverURL, parts[1], keyMaterial, nil
+}
+
+// AccessSend retrieves and decrypts the content of a Send, as an anonymous
+// recipient. The password is only needed for password protected Sends.
+func (c *client) AccessSend(ctx context.Context, accessID string, keyMaterial []byte, password string) (*Send, error) {
+	sendKey, err := keybuilder.BuildSendKey(keyMaterial)
+	if err != nil {
+		return nil, err
+	}
+
+	accessReq := SendAccessRequest{}
+	if len(password) > 0 {
+		accessReq.Password = crypto.HashSendPassword(password, keyMaterial)
+	}
+
+	var accessResp SendAccessResponse
+	err = c.callAnonymousJSON(ctx, "POST", c.sendAccessURL(accessID), accessReq, &accessResp, "send access")
+	if err != nil {
+		return nil, err
+	}
+
+	send := &Send{
+		ID:             accessResp.Id,
+		Type:           accessResp.Type,
+		ExpirationDate: accessResp.ExpirationDate,
+	}
+
+	send.Name, err = decryptSendString(accessResp.Name, *sendKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting send name: %w", err)
+	}
+
+	switch accessResp.Type {
+	case SendTypeText:
+		if accessResp.Text == nil {
+			return nil, fmt.Errorf("text send without text")
+		}
+		send.Hidden = accessResp.Text.Hidden
+		send.Text, err = decryptSendString(accessResp.Text.Text, *sendKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting send text: %w", err)
+		}
+	case SendTypeFile:
+		if accessResp.File == nil {
+			return nil, fmt.Errorf("file send without file")
+		}
+		send.FileName, err = decryptSendString(accessResp.File.FileName, *sendKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting send file name: %w", err)
+		}
+		send.File, err = c.downloadSendFile(ctx, accessResp.Id, accessResp.File.Id, accessReq, *sendKey)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported send type: %d", accessResp.Type)
+	}
+	return send, nil
+}
+
+func (c *client) downloadSendFile(ctx context.Context, sendID, fileID string, accessReq SendAccessRequest, sendKey symmetrickey.Key) ([]byte, error) {
+	var downloadResp SendFileDownloadResponse
+	err := c.callAnonymousJSON(ctx, "POST", c.sendFileAccessURL(sendID, fileID), accessReq, &downloadResp, "send file access")
+	if err != nil {
+		return nil, err
+	}
+
+	downloadURL := downloadResp.Url
+	if strings.HasPrefix(downloadURL, "/") {
+		downloadURL = c.serverURL + downloadURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing send file download request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling send file download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, newAPIError("send file download", resp.StatusCode, body)
+	}
+
+	var content bytes.Buffer
+	err = crypto.DecryptFile(&content, resp.Body, sendKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting send file: %w", err)
+	}
+	return content.Bytes(), nil
+}
+
+func decryptSendString(encryptedStr string, key symmetrickey.Key) (string, error) {
+	if len(encryptedStr) == 0 {
+		return "", nil
+	}
+	decrypted, err := crypto.DecryptString(encryptedStr, key)
+	if err != nil {
+		return "", err
+	}
+	return string(decrypted), nil
+}
@@ -0,0 +1,135 @@
+package webapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSendKeyMaterial = []byte("0123456789abcdef")
+
+func TestParseSendURL(t *testing.T) {
+	serverURL, accessID, keyMaterial, err := ParseSendURL("https://vault.example.com/#/send/access-id/MDEyMzQ1Njc4OWFiY2RlZg")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://vault.example.com", serverURL)
+	assert.Equal(t, "access-id", accessID)
+	assert.Equal(t, testSendKeyMaterial, keyMaterial)
+
+	serverURL, _, _, err = ParseSendURL("https://send.bitwarden.com/#/send/access-id/MDEyMzQ1Njc4OWFiY2RlZg")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://vault.bitwarden.com", serverURL)
+
+	serverURL, _, _, err = ParseSendURL("https://example.com/vault/#/send/access-id/MDEyMzQ1Njc4OWFiY2RlZg/")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/vault", serverURL)
+
+	for _, invalidURL := range []string{
+		"/#/send/access-id/MDEyMzQ1Njc4OWFiY2RlZg",
+		"https://vault.example.com/#/send/access-id",
+		"https://vault.example.com/#/vault/access-id/MDEyMzQ1Njc4OWFiY2RlZg",
+		"https://vault.example.com/#/send/access-id/not*base64",
+	} {
+		_, _, _, err = ParseSendURL(invalidURL)
+		assert.Error(t, err, invalidURL)
+	}
+}
+
+func TestAccessTextSend(t *testing.T) {
+	server := newTestSendServer(t, "")
+	defer server.Close()
+
+	send, err := newTestClient(t, server.URL).AccessSend(context.Background(), "text-access-id", testSendKeyMaterial, "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, SendTypeText, send.Type)
+		assert.Equal(t, "send-name", send.Name)
+		assert.Equal(t, "send-text", send.Text)
+		assert.True(t, send.Hidden)
+	}
+}
+
+func TestAccessFileSendWithPassword(t *testing.T) {
+	server := newTestSendServer(t, "send-password")
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	_, err := c.AccessSend(context.Background(), "file-access-id", testSendKeyMaterial, "")
+	assert.True(t, IsUnauthorized(err))
+
+	send, err := c.AccessSend(context.Background(), "file-access-id", testSendKeyMaterial, "send-password")
+	if assert.NoError(t, err) {
+		assert.Equal(t, SendTypeFile, send.Type)
+		assert.Equal(t, "file.txt", send.FileName)
+		assert.Equal(t, []byte("file-content"), send.File)
+	}
+}
+
+func newTestSendServer(t *testing.T, password string) *httptest.Server {
+	sendKey, err := keybuilder.BuildSendKey(testSendKeyMaterial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypt := func(value string) string {
+		encrypted, err := crypto.Encrypt([]byte(value), *sendKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encrypted
+	}
+
+	checkPassword := func(w http.ResponseWriter, r *http.Request) bool {
+		var accessReq SendAccessRequest
+		json.NewDecoder(r.Body).Decode(&accessReq)
+		if len(password) > 0 && accessReq.Password != crypto.HashSendPassword(password, testSendKeyMaterial) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/sends/access/text-access-id", func(w http.ResponseWriter, r *http.Request) {
+		if !checkPassword(w, r) {
+			return
+		}
+		json.NewEncoder(w).Encode(SendAccessResponse{
+			Id:   "text-send-id",
+			Type: SendTypeText,
+			Name: encrypt("send-name"),
+			Text: &SendText{Text: encrypt("send-text"), Hidden: true},
+		})
+	})
+	mux.HandleFunc("/api/sends/access/file-access-id", func(w http.ResponseWriter, r *http.Request) {
+		if !checkPassword(w, r) {
+			return
+		}
+		json.NewEncoder(w).Encode(SendAccessResponse{
+			Id:   "file-send-id",
+			Type: SendTypeFile,
+			Name: encrypt("send-name"),
+			File: &SendFile{Id: "file-id", FileName: encrypt("file.txt")},
+		})
+	})
+	mux.HandleFunc("/api/sends/file-send-id/access/file/file-id", func(w http.ResponseWriter, r *http.Request) {
+		if !checkPassword(w, r) {
+			return
+		}
+		json.NewEncoder(w).Encode(SendFileDownloadResponse{Id: "file-id", Url: "/sends/file-send-id/file-id?t=token"})
+	})
+	mux.HandleFunc("/sends/file-send-id/file-id", func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("authorization"))
+		err := crypto.EncryptFile(w, func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader([]byte("file-content"))), nil
+		}, *sendKey)
+		assert.NoError(t, err)
+	})
+	return httptest.NewServer(mux)
+}
@@ -0,0 +1,52 @@
+package webapi
+
+import (
+	"context"
+	"time"
+)
+
+// Sync returns the whole content of the Vault, as it is stored on the server:
+// encrypted.
+func (c *client) Sync(ctx context.Context) (*SyncResponse, error) {
+	var syncResp SyncResponse
+	err := c.callJSON(ctx, "GET", c.syncURL(), nil, &syncResp, "sync")
+	if err != nil {
+		return nil, err
+	}
+	return &syncResp, nil
+}
+
+// GetRevisionDate returns the last time anything changed in the Vault of the
+// user, which is a cheap way to find out whether a previous sync is outdated.
+func (c *client) GetRevisionDate(ctx context.Context) (time.Time, error) {
+	var revisionDateMillis int64
+	err := c.callJSON(ctx, "GET", c.revisionDateURL(), nil, &revisionDateMillis, "revision date retrieval")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(revisionDateMillis).UTC(), nil
+}
+
+func (c *client) GetSessionTokens() SessionTokens {
+	c.sessionMu.RLock()
+	defer c.sessionMu.RUnlock()
+	return SessionTokens{
+		AccessToken:  c.session.accessToken,
+		RefreshToken: c.session.refreshToken,
+		ExpiresAt:    c.session.expiresAt,
+	}
+}
+
+// SetSessionTokens resumes a session from previously persisted tokens. Only
+// calls which don't involve decrypting anything are possible in such a
+// session.
+func (c *client) SetSessionTokens(tokens SessionTokens) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+	c.session.accessToken = tokens.AccessToken
+	c.session.refreshToken = tokens.RefreshToken
+	c.session.expiresAt = tokens.ExpiresAt
+
+	// Only password logins come with refresh tokens.
+	c.session.grant = sessionGrant{clientID: webClientID}
+}
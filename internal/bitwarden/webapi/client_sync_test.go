@@ -0,0 +1,35 @@
+package webapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumedSessionGetsRevisionDate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/accounts/revision-date", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer persisted-token", r.Header.Get("authorization"))
+		w.Write([]byte(`1700000000123`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tokens := SessionTokens{
+		AccessToken:  "persisted-token",
+		RefreshToken: "refresh-token",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	c := newTestClient(t, server.URL)
+	c.SetSessionTokens(tokens)
+	assert.Equal(t, tokens, c.GetSessionTokens())
+
+	revisionDate, err := c.GetRevisionDate(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 11, 14, 22, 13, 20, 123000000, time.UTC), revisionDate)
+}
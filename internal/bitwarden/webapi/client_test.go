@@ -0,0 +1,305 @@
+package webapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/cose"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginWithAPIKey(t *testing.T) {
+	preloginKey, err := keybuilder.BuildPreloginKey("test-password", "test@example.com", 1000)
+	assert.NoError(t, err)
+
+	encryptionKey, encryptedEncryptionKey, err := keybuilder.GenerateEncryptionKey(*preloginKey)
+	assert.NoError(t, err)
+
+	_, encryptedPrivateKey, err := keybuilder.GenerateKeyPair(*encryptionKey)
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "api", r.PostForm.Get("scope"))
+		assert.Equal(t, "user.client-id", r.PostForm.Get("client_id"))
+		assert.Equal(t, "client-secret", r.PostForm.Get("client_secret"))
+
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken:   "access-token",
+			ExpireIn:      3600,
+			Key:           encryptedEncryptionKey,
+			PrivateKey:    encryptedPrivateKey,
+			Kdf:           keybuilder.PBKDF2_SHA256,
+			KdfIterations: 1000,
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	err = c.LoginWithAPIKey(context.Background(), "test@example.com", "test-password", "user.client-id", "client-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token", c.session.accessToken)
+	assert.Equal(t, encryptionKey.Key, c.session.encryptionKey.Key)
+	assert.NotNil(t, c.session.privateKey)
+}
+
+func TestLoginWithCoseUserKey(t *testing.T) {
+	preloginKey, err := keybuilder.BuildPreloginKey("test-password", "test@example.com", 1000)
+	assert.NoError(t, err)
+
+	rawUserKey, err := cose.SymmetricKey{KeyID: []byte("0123456789abcdef"), Key: bytes.Repeat([]byte{0x42}, 32)}.Marshal()
+	assert.NoError(t, err)
+	userKey, err := symmetrickey.NewFromRawBytes(rawUserKey)
+	assert.NoError(t, err)
+
+	encryptedUserKey, err := keybuilder.RewrapEncryptionKey(*preloginKey, *userKey)
+	assert.NoError(t, err)
+
+	_, encryptedPrivateKey, err := keybuilder.GenerateKeyPair(*userKey)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encryptedPrivateKey, "7."))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken:   "access-token",
+			ExpireIn:      3600,
+			Key:           encryptedUserKey,
+			PrivateKey:    encryptedPrivateKey,
+			KdfIterations: 1000,
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	err = c.Login(context.Background(), "test@example.com", "test-password", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, symmetrickey.CoseEncrypt0_B64, c.session.encryptionKey.EncryptionType)
+	assert.NotNil(t, c.session.privateKey)
+}
+
+func TestAPIKeySessionRenewedWithClientCredentials(t *testing.T) {
+	preloginKey, err := keybuilder.BuildPreloginKey("test-password", "test@example.com", 1000)
+	assert.NoError(t, err)
+
+	encryptionKey, encryptedEncryptionKey, err := keybuilder.GenerateEncryptionKey(*preloginKey)
+	assert.NoError(t, err)
+
+	_, encryptedPrivateKey, err := keybuilder.GenerateKeyPair(*encryptionKey)
+	assert.NoError(t, err)
+
+	tokenCalls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "user.client-id", r.PostForm.Get("client_id"))
+		assert.Equal(t, "client-secret", r.PostForm.Get("client_secret"))
+		tokenCalls++
+
+		// The first token has already expired.
+		expiresIn := 1
+		if tokenCalls > 1 {
+			expiresIn = 3600
+		}
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken:   fmt.Sprintf("access-token-%d", tokenCalls),
+			ExpireIn:      expiresIn,
+			Key:           encryptedEncryptionKey,
+			PrivateKey:    encryptedPrivateKey,
+			Kdf:           keybuilder.PBKDF2_SHA256,
+			KdfIterations: 1000,
+		})
+	})
+	mux.HandleFunc("/api/organizations/org-id/collections", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access-token-2", r.Header.Get("authorization"))
+		w.Write([]byte(`{"data":[{"id":"collection-id"}],"object":"list"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	err = c.LoginWithAPIKey(context.Background(), "test@example.com", "test-password", "user.client-id", "client-secret")
+	assert.NoError(t, err)
+
+	collectionID, err := c.GetCollections(context.Background(), "org-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "collection-id", collectionID)
+	assert.Equal(t, 2, tokenCalls)
+}
+
+func TestLoginWithTwoFactor(t *testing.T) {
+	preloginKey, err := keybuilder.BuildPreloginKey("test-password", "test@example.com", 1000)
+	assert.NoError(t, err)
+
+	encryptionKey, encryptedEncryptionKey, err := keybuilder.GenerateEncryptionKey(*preloginKey)
+	assert.NoError(t, err)
+
+	_, encryptedPrivateKey, err := keybuilder.GenerateKeyPair(*encryptionKey)
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		if r.PostForm.Get("twoFactorToken") != "123456" || r.PostForm.Get("twoFactorProvider") != "0" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Two factor required.","TwoFactorProviders":[0],"TwoFactorProviders2":{"0":null}}`))
+			return
+		}
+
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken:   "access-token",
+			ExpireIn:      3600,
+			Key:           encryptedEncryptionKey,
+			PrivateKey:    encryptedPrivateKey,
+			KdfIterations: 1000,
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	err = c.Login(context.Background(), "test@example.com", "test-password", 1000)
+	assert.True(t, IsTwoFactorRequired(err))
+
+	err = c.Login(context.Background(), "test@example.com", "test-password", 1000, WithTwoFactor(TwoFactorProviderAuthenticator, "123456"))
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token", c.session.accessToken)
+}
+
+func TestAccessTokenRefreshedWhenExpired(t *testing.T) {
+	server, refreshCalls := newTestTokenServer(t, "valid-token")
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	c.session = session{
+		accessToken:  "expired-token",
+		refreshToken: "refresh-token",
+		grant:        sessionGrant{clientID: webClientID},
+		expiresAt:    time.Now().Add(-time.Minute),
+	}
+
+	collectionID, err := c.GetCollections(context.Background(), "org-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "collection-id", collectionID)
+	assert.Equal(t, 1, refreshCalls())
+	assert.Equal(t, "valid-token", c.session.accessToken)
+	assert.True(t, c.session.expiresAt.After(time.Now()))
+}
+
+func TestAccessTokenRefreshedOnUnauthorized(t *testing.T) {
+	server, refreshCalls := newTestTokenServer(t, "valid-token")
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	c.session = session{
+		accessToken:  "revoked-token",
+		refreshToken: "refresh-token",
+		grant:        sessionGrant{clientID: webClientID},
+		expiresAt:    time.Now().Add(time.Hour),
+	}
+
+	collectionID, err := c.GetCollections(context.Background(), "org-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "collection-id", collectionID)
+	assert.Equal(t, 1, refreshCalls())
+}
+
+func TestAccessTokenWithoutExpiryNotRefreshedUntilUnauthorized(t *testing.T) {
+	server, refreshCalls := newTestTokenServer(t, "valid-token")
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	c.session.grant = sessionGrant{clientID: webClientID}
+	c.setTokens(TokenResponse{AccessToken: "valid-token", RefreshToken: "refresh-token"})
+	assert.True(t, c.session.expiresAt.IsZero())
+
+	_, err := c.GetCollections(context.Background(), "org-id")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, refreshCalls())
+
+	c.setTokens(TokenResponse{AccessToken: "revoked-token"})
+	_, err = c.GetCollections(context.Background(), "org-id")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, refreshCalls())
+}
+
+func TestAccessTokenRefreshedOnceForConcurrentCalls(t *testing.T) {
+	server, refreshCalls := newTestTokenServer(t, "valid-token")
+	defer server.Close()
+
+	c := newTestClient(t, server.URL)
+	c.session = session{
+		accessToken:  "expired-token",
+		refreshToken: "refresh-token",
+		grant:        sessionGrant{clientID: webClientID},
+		expiresAt:    time.Now().Add(-time.Minute),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetCollections(context.Background(), "org-id")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, refreshCalls())
+}
+
+func newTestClient(t *testing.T, serverURL string) *client {
+	c, err := NewClient(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(*client)
+}
+
+func newTestTokenServer(t *testing.T, validToken string) (*httptest.Server, func() int) {
+	var mu sync.Mutex
+	refreshCalls := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "refresh_token", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "refresh-token", r.PostForm.Get("refresh_token"))
+		assert.Equal(t, "web", r.PostForm.Get("client_id"))
+
+		mu.Lock()
+		refreshCalls++
+		mu.Unlock()
+
+		w.Write([]byte(`{"access_token":"` + validToken + `","expires_in":3600,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/api/organizations/org-id/collections", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("authorization") != "Bearer "+validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"collection-id"}],"object":"list"}`))
+	})
+
+	return httptest.NewServer(mux), func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return refreshCalls
+	}
+}
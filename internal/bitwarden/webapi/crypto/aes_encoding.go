@@ -8,12 +8,18 @@ import (
 )
 
 func aes256Decode(cipherText []byte, encKey []byte, iv []byte) ([]byte, error) {
-
 	block, err := aes.NewCipher(encKey)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new cipher block: %w", err)
 	}
 
+	if len(iv) != block.BlockSize() {
+		return nil, fmt.Errorf("bad IV length - expected %d, got: %d", block.BlockSize(), len(iv))
+	}
+	if len(cipherText) == 0 || len(cipherText)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("bad data length - expected a non-zero multiple of %d, got: %d", block.BlockSize(), len(cipherText))
+	}
+
 	plainText := make([]byte, len(cipherText))
 
 	mode := cipher.NewCBCDecrypter(block, iv)
@@ -40,10 +46,23 @@ func aes256Encode(plainText []byte, key []byte, iv []byte, blockSize int) ([]byt
 
 func pkcs5Unpadding(src []byte, blockSize int) ([]byte, error) {
 	srcLen := len(src)
+	if srcLen == 0 || srcLen%blockSize != 0 {
+		return nil, fmt.Errorf("bad padded data length")
+	}
+
 	paddingLen := int(src[srcLen-1])
-	if paddingLen >= srcLen || paddingLen > blockSize {
+	if paddingLen == 0 || paddingLen > blockSize {
 		return nil, fmt.Errorf("bad padding size")
 	}
+
+	// Every padding byte must be equal to the padding length.
+	invalid := byte(0)
+	for _, b := range src[srcLen-paddingLen:] {
+		invalid |= b ^ byte(paddingLen)
+	}
+	if invalid != 0 {
+		return nil, fmt.Errorf("bad padding")
+	}
 	return src[:srcLen-paddingLen], nil
 }
 
@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPkcs5Unpadding(t *testing.T) {
+	testCases := []struct {
+		name          string
+		src           []byte
+		expected      []byte
+		expectedError string
+	}{
+		{
+			name:     "partial block",
+			src:      []byte{'a', 'b', 'c', 'd', 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12},
+			expected: []byte("abcd"),
+		},
+		{
+			name:     "full padding block",
+			src:      []byte{16, 16, 16, 16, 16, 16, 16, 16, 16, 16, 16, 16, 16, 16, 16, 16},
+			expected: []byte{},
+		},
+		{
+			name:          "empty",
+			src:           []byte{},
+			expectedError: "bad padded data length",
+		},
+		{
+			name:          "unaligned",
+			src:           []byte{'a', 1},
+			expectedError: "bad padded data length",
+		},
+		{
+			name:          "zero padding",
+			src:           []byte{'a', 'b', 'c', 'd', 'a', 'b', 'c', 'd', 'a', 'b', 'c', 'd', 'a', 'b', 'c', 0},
+			expectedError: "bad padding size",
+		},
+		{
+			name:          "padding larger than block",
+			src:           []byte{'a', 'b', 'c', 'd', 'a', 'b', 'c', 'd', 'a', 'b', 'c', 'd', 'a', 'b', 'c', 17},
+			expectedError: "bad padding size",
+		},
+		{
+			name:          "inconsistent padding bytes",
+			src:           []byte{'a', 'b', 'c', 'd', 'a', 'b', 'c', 'd', 'a', 'b', 'c', 'd', 'a', 2, 3, 3},
+			expectedError: "bad padding",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			unpadded, err := pkcs5Unpadding(tc.src, 16)
+			if len(tc.expectedError) > 0 {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, unpadded)
+			}
+		})
+	}
+}
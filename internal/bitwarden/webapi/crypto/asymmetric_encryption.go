@@ -0,0 +1,47 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/encryptedstring"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+// EncryptAsymmetric encrypts a value with a public key using RSA-OAEP with
+// SHA-1, which is what the official clients use to share keys.
+func EncryptAsymmetric(plainValue []byte, publicKey *rsa.PublicKey) (string, error) {
+	if publicKey == nil {
+		return "", fmt.Errorf("no public key was provided")
+	}
+
+	data, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, publicKey, plainValue, nil)
+	if err != nil {
+		return "", fmt.Errorf("error rsa encrypting data: %w", err)
+	}
+
+	res := encryptedstring.New(nil, data, nil, symmetrickey.Key{EncryptionType: symmetrickey.Rsa2048_OaepSha1_B64})
+	return res.String(), nil
+}
+
+func decryptAsymmetric(encString *encryptedstring.EncryptedString, privateKey *rsa.PrivateKey) ([]byte, error) {
+	var hashFunc hash.Hash
+	switch encString.Key.EncryptionType {
+	case symmetrickey.Rsa2048_OaepSha1_B64, symmetrickey.Rsa2048_OaepSha1_HmacSha256_B64:
+		hashFunc = sha1.New()
+	case symmetrickey.Rsa2048_OaepSha256_B64, symmetrickey.Rsa2048_OaepSha256_HmacSha256_B64:
+		hashFunc = sha256.New()
+	default:
+		return nil, fmt.Errorf("unsupported asymmetric encryption type: %d", encString.Key.EncryptionType)
+	}
+
+	decData, err := rsa.DecryptOAEP(hashFunc, rand.Reader, privateKey, encString.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error rsa decrypting data: %w", err)
+	}
+	return decData, nil
+}
@@ -0,0 +1,259 @@
+package cose
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+/*
+* This is a minimal CBOR (RFC 8949) implementation, supporting only what is
+* needed to read and write COSE_Encrypt0 messages and COSE symmetric keys:
+* integers, byte and text strings, arrays, maps and tags.
+ */
+
+const (
+	majorUnsigned = 0
+	majorNegative = 1
+	majorBytes    = 2
+	majorText     = 3
+	majorArray    = 4
+	majorMap      = 5
+	majorTag      = 6
+	majorSimple   = 7
+
+	// maxNestingDepth protects against stack exhaustion on malicious input.
+	maxNestingDepth = 16
+)
+
+// Tag is a tagged CBOR value.
+type Tag struct {
+	Number uint64
+	Value  interface{}
+}
+
+// Map is a CBOR map whose keys are either int64 or string.
+type Map map[interface{}]interface{}
+
+func encodeCBOR(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := writeCBOR(&buf, value)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCBOR(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case int:
+		return writeCBOR(buf, int64(v))
+	case int64:
+		if v >= 0 {
+			writeHead(buf, majorUnsigned, uint64(v))
+		} else {
+			writeHead(buf, majorNegative, uint64(-(v + 1)))
+		}
+	case []byte:
+		writeHead(buf, majorBytes, uint64(len(v)))
+		buf.Write(v)
+	case string:
+		writeHead(buf, majorText, uint64(len(v)))
+		buf.WriteString(v)
+	case []interface{}:
+		writeHead(buf, majorArray, uint64(len(v)))
+		for _, item := range v {
+			err := writeCBOR(buf, item)
+			if err != nil {
+				return err
+			}
+		}
+	case Map:
+		// Keys are written in the canonical order, which makes the encoding
+		// deterministic.
+		encodedKeys := make([][]byte, 0, len(v))
+		encodedValues := map[string][]byte{}
+		for key, item := range v {
+			encodedKey, err := encodeCBOR(key)
+			if err != nil {
+				return err
+			}
+			encodedValue, err := encodeCBOR(item)
+			if err != nil {
+				return err
+			}
+			encodedKeys = append(encodedKeys, encodedKey)
+			encodedValues[string(encodedKey)] = encodedValue
+		}
+		sort.Slice(encodedKeys, func(i, j int) bool {
+			if len(encodedKeys[i]) != len(encodedKeys[j]) {
+				return len(encodedKeys[i]) < len(encodedKeys[j])
+			}
+			return bytes.Compare(encodedKeys[i], encodedKeys[j]) < 0
+		})
+
+		writeHead(buf, majorMap, uint64(len(v)))
+		for _, encodedKey := range encodedKeys {
+			buf.Write(encodedKey)
+			buf.Write(encodedValues[string(encodedKey)])
+		}
+	case Tag:
+		writeHead(buf, majorTag, v.Number)
+		return writeCBOR(buf, v.Value)
+	default:
+		return fmt.Errorf("unsupported cbor type: %T", value)
+	}
+	return nil
+}
+
+func writeHead(buf *bytes.Buffer, major byte, arg uint64) {
+	switch {
+	case arg < 24:
+		buf.WriteByte(major<<5 | byte(arg))
+	case arg <= 0xff:
+		buf.WriteByte(major<<5 | 24)
+		buf.WriteByte(byte(arg))
+	case arg <= 0xffff:
+		buf.WriteByte(major<<5 | 25)
+		binary.Write(buf, binary.BigEndian, uint16(arg))
+	case arg <= 0xffffffff:
+		buf.WriteByte(major<<5 | 26)
+		binary.Write(buf, binary.BigEndian, uint32(arg))
+	default:
+		buf.WriteByte(major<<5 | 27)
+		binary.Write(buf, binary.BigEndian, arg)
+	}
+}
+
+func decodeCBOR(data []byte) (interface{}, error) {
+	d := decoder{data: data}
+	value, err := d.read(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("unexpected trailing cbor data")
+	}
+	return value, nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) read(depth int) (interface{}, error) {
+	if depth > maxNestingDepth {
+		return nil, fmt.Errorf("cbor data too deeply nested")
+	}
+
+	major, arg, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case majorUnsigned:
+		if arg > 1<<63-1 {
+			return nil, fmt.Errorf("cbor integer overflow")
+		}
+		return int64(arg), nil
+	case majorNegative:
+		if arg > 1<<63-1 {
+			return nil, fmt.Errorf("cbor integer overflow")
+		}
+		return -int64(arg) - 1, nil
+	case majorBytes, majorText:
+		raw, err := d.readBytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		if major == majorText {
+			return string(raw), nil
+		}
+		return append([]byte{}, raw...), nil
+	case majorArray:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, fmt.Errorf("cbor array longer than data")
+		}
+		array := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			item, err := d.read(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+		return array, nil
+	case majorMap:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, fmt.Errorf("cbor map longer than data")
+		}
+		m := Map{}
+		for i := uint64(0); i < arg; i++ {
+			key, err := d.read(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("unsupported cbor map key type: %T", key)
+			}
+			value, err := d.read(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	case majorTag:
+		value, err := d.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Tag{Number: arg, Value: value}, nil
+	}
+	return nil, fmt.Errorf("unsupported cbor major type: %d", major)
+}
+
+func (d *decoder) readHead() (byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, fmt.Errorf("unexpected end of cbor data")
+	}
+	initial := d.data[d.pos]
+	d.pos++
+
+	major := initial >> 5
+	info := initial & 0x1f
+	if major == majorSimple {
+		return 0, 0, fmt.Errorf("unsupported cbor simple value or float")
+	}
+
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		raw, err := d.readBytes(uint64(size))
+		if err != nil {
+			return 0, 0, err
+		}
+		var arg uint64
+		for _, b := range raw {
+			arg = arg<<8 | uint64(b)
+		}
+		return major, arg, nil
+	}
+	return 0, 0, fmt.Errorf("unsupported cbor indefinite length or reserved value")
+}
+
+func (d *decoder) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, fmt.Errorf("unexpected end of cbor data")
+	}
+	raw := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return raw, nil
+}
@@ -0,0 +1,228 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+/*
+* Newer Bitwarden keys and values are encrypted with XChaCha20-Poly1305 and
+* serialized as COSE (RFC 9052) structures:
+* - keys are COSE_Key maps of the 'Symmetric' key type
+* - values are COSE_Encrypt0 messages, with the nonce in the unprotected
+*   header and the key ID, algorithm and content type in the protected one
+ */
+
+const (
+	// AlgorithmXChaCha20Poly1305 is the private-use algorithm identifier
+	// Bitwarden uses for XChaCha20-Poly1305.
+	AlgorithmXChaCha20Poly1305 = -70000
+
+	// ContentTypeUtf8Padded is used for text values, which are padded to hide
+	// their length.
+	ContentTypeUtf8Padded  = "application/x.bitwarden.utf8-padded"
+	ContentTypeOctetStream = "application/octet-stream"
+
+	headerAlgorithm   = 1
+	headerContentType = 3
+	headerKeyID       = 4
+	headerIV          = 5
+
+	keyLabelType      = 1
+	keyLabelID        = 2
+	keyLabelAlgorithm = 3
+	keyLabelK         = -1
+	keyTypeSymmetric  = 4
+
+	tagEncrypt0 = 16
+
+	// textPadMinLength is the minimum length text values are padded to.
+	textPadMinLength = 32
+)
+
+// SymmetricKey is an XChaCha20-Poly1305 key along with its identifier.
+type SymmetricKey struct {
+	KeyID []byte
+	Key   []byte
+}
+
+// ParseSymmetricKey parses a CBOR-encoded COSE_Key.
+func ParseSymmetricKey(raw []byte) (*SymmetricKey, error) {
+	decoded, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding cose key: %w", err)
+	}
+
+	m, ok := decoded.(Map)
+	if !ok {
+		return nil, fmt.Errorf("cose key is not a map")
+	}
+
+	if kty, _ := m[int64(keyLabelType)].(int64); kty != keyTypeSymmetric {
+		return nil, fmt.Errorf("unsupported cose key type: %v", m[int64(keyLabelType)])
+	}
+	if alg, _ := m[int64(keyLabelAlgorithm)].(int64); alg != AlgorithmXChaCha20Poly1305 {
+		return nil, fmt.Errorf("unsupported cose key algorithm: %v", m[int64(keyLabelAlgorithm)])
+	}
+
+	k, _ := m[int64(keyLabelK)].([]byte)
+	if len(k) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("bad cose key length (expected: %d, got: %d)", chacha20poly1305.KeySize, len(k))
+	}
+
+	keyID, _ := m[int64(keyLabelID)].([]byte)
+	return &SymmetricKey{KeyID: keyID, Key: k}, nil
+}
+
+// Marshal returns the CBOR-encoded COSE_Key.
+func (k SymmetricKey) Marshal() ([]byte, error) {
+	m := Map{
+		int64(keyLabelType):      int64(keyTypeSymmetric),
+		int64(keyLabelAlgorithm): int64(AlgorithmXChaCha20Poly1305),
+		int64(keyLabelK):         k.Key,
+	}
+	if len(k.KeyID) > 0 {
+		m[int64(keyLabelID)] = k.KeyID
+	}
+	return encodeCBOR(m)
+}
+
+// Encrypt encrypts a value into a CBOR-encoded COSE_Encrypt0 message.
+func Encrypt(plaintext []byte, key SymmetricKey, contentType string) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key.Key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating xchacha20-poly1305 cipher: %w", err)
+	}
+
+	if contentType == ContentTypeUtf8Padded {
+		plaintext = padText(plaintext)
+	}
+
+	protectedHeader := Map{
+		int64(headerAlgorithm):   int64(AlgorithmXChaCha20Poly1305),
+		int64(headerContentType): contentType,
+	}
+	if len(key.KeyID) > 0 {
+		protectedHeader[int64(headerKeyID)] = key.KeyID
+	}
+	protected, err := encodeCBOR(protectedHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	aad, err := encStructure(protected)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	ciphertext := aead.Seal(nil, nonce, plaintext, aad)
+	return encodeCBOR([]interface{}{protected, Map{int64(headerIV): nonce}, ciphertext})
+}
+
+// Decrypt decrypts a CBOR-encoded COSE_Encrypt0 message, tagged or not.
+func Decrypt(message []byte, key SymmetricKey) ([]byte, error) {
+	decoded, err := decodeCBOR(message)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding cose message: %w", err)
+	}
+
+	if tag, ok := decoded.(Tag); ok {
+		if tag.Number != tagEncrypt0 {
+			return nil, fmt.Errorf("unexpected cose message tag: %d", tag.Number)
+		}
+		decoded = tag.Value
+	}
+
+	parts, ok := decoded.([]interface{})
+	if !ok || len(parts) != 3 {
+		return nil, fmt.Errorf("cose message is not a 3-element array")
+	}
+	protected, ok1 := parts[0].([]byte)
+	unprotectedHeader, ok2 := parts[1].(Map)
+	ciphertext, ok3 := parts[2].([]byte)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("cose message has unexpected element types")
+	}
+
+	protectedHeader := Map{}
+	if len(protected) > 0 {
+		decodedHeader, err := decodeCBOR(protected)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding cose protected header: %w", err)
+		}
+		protectedHeader, ok = decodedHeader.(Map)
+		if !ok {
+			return nil, fmt.Errorf("cose protected header is not a map")
+		}
+	}
+
+	if alg, _ := protectedHeader[int64(headerAlgorithm)].(int64); alg != AlgorithmXChaCha20Poly1305 {
+		return nil, fmt.Errorf("unsupported cose algorithm: %v", protectedHeader[int64(headerAlgorithm)])
+	}
+
+	if keyID, ok := protectedHeader[int64(headerKeyID)].([]byte); ok && len(key.KeyID) > 0 && !bytes.Equal(keyID, key.KeyID) {
+		return nil, fmt.Errorf("cose message was encrypted with a different key")
+	}
+
+	nonce, _ := unprotectedHeader[int64(headerIV)].([]byte)
+	if len(nonce) != chacha20poly1305.NonceSizeX {
+		return nil, fmt.Errorf("bad cose nonce length (expected: %d, got: %d)", chacha20poly1305.NonceSizeX, len(nonce))
+	}
+
+	aead, err := chacha20poly1305.NewX(key.Key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating xchacha20-poly1305 cipher: %w", err)
+	}
+
+	aad, err := encStructure(protected)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("cose message authentication failed")
+	}
+
+	if contentType, _ := protectedHeader[int64(headerContentType)].(string); contentType == ContentTypeUtf8Padded {
+		return unpadText(plaintext)
+	}
+	return plaintext, nil
+}
+
+// encStructure builds the additional authenticated data of a COSE_Encrypt0
+// message, as defined in RFC 9052 section 5.3.
+func encStructure(protected []byte) ([]byte, error) {
+	return encodeCBOR([]interface{}{"Encrypt0", protected, []byte{}})
+}
+
+// padText pads a value to textPadMinLength, with at least one byte of padding
+// whose value is the padding length.
+func padText(plaintext []byte) []byte {
+	padLen := textPadMinLength - len(plaintext)
+	if padLen < 1 {
+		padLen = 1
+	}
+	padded := append([]byte{}, plaintext...)
+	return append(padded, bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+}
+
+func unpadText(padded []byte) ([]byte, error) {
+	if len(padded) == 0 {
+		return nil, fmt.Errorf("bad text padding")
+	}
+	padLen := int(padded[len(padded)-1])
+	if padLen == 0 || padLen > len(padded) {
+		return nil, fmt.Errorf("bad text padding")
+	}
+	return padded[:len(padded)-padLen], nil
+}
@@ -0,0 +1,150 @@
+package cose
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey() SymmetricKey {
+	return SymmetricKey{
+		KeyID: []byte("0123456789abcdef"),
+		Key:   bytes.Repeat([]byte{0x42}, 32),
+	}
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	value := []interface{}{
+		int64(0), int64(23), int64(24), int64(-1), int64(-70000), int64(1 << 40),
+		[]byte{1, 2, 3}, "text",
+		Map{int64(1): "a", int64(-1): []byte{}, "key": []interface{}{}},
+		Tag{Number: 16, Value: int64(1)},
+	}
+
+	encoded, err := encodeCBOR(value)
+	assert.NoError(t, err)
+
+	decoded, err := decodeCBOR(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, value, decoded)
+}
+
+func TestCBORMalformed(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":          {},
+		"truncated":      {0x43, 0x01},
+		"trailing data":  {0x01, 0x01},
+		"float":          {0xf9, 0x00, 0x00},
+		"indefinite":     {0x5f, 0xff},
+		"bad map key":    {0xa1, 0x41, 0x00, 0x01},
+		"long array":     {0x9a, 0xff, 0xff, 0xff, 0xff},
+		"deeply nested":  bytes.Repeat([]byte{0x81}, maxNestingDepth+2),
+		"integer overfl": {0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeCBOR(data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSymmetricKeyRoundTrip(t *testing.T) {
+	raw, err := testKey().Marshal()
+	assert.NoError(t, err)
+
+	key, err := ParseSymmetricKey(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, testKey(), *key)
+
+	_, err = ParseSymmetricKey(bytes.Repeat([]byte{0x42}, 64))
+	assert.Error(t, err)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, contentType := range []string{ContentTypeOctetStream, ContentTypeUtf8Padded} {
+		for _, plaintext := range []string{"", "short", string(bytes.Repeat([]byte("x"), 100))} {
+			message, err := Encrypt([]byte(plaintext), testKey(), contentType)
+			assert.NoError(t, err)
+
+			decrypted, err := Decrypt(message, testKey())
+			assert.NoError(t, err)
+			assert.Equal(t, plaintext, string(decrypted))
+		}
+	}
+}
+
+// testIndependentKey and testIndependentMessage were encoded and encrypted by
+// a separate Python implementation of CBOR and XChaCha20-Poly1305, checked
+// against the HChaCha20 vector of draft-irtf-cfrg-xchacha and against
+// OpenSSL's ChaCha20 and Poly1305, so that decryption doesn't only rely on
+// messages produced by this package.
+const (
+	testIndependentKey     = "a4010402503f2a9c1b7e6d4f508a1b2c3d4e5f6071033a0001116f205820a3c1f0b85e4d2c7691e0f4a8b6d3c25e7f1a9b0c8d2e3f405162738495a6b7c8"
+	testIndependentMessage = "83583fa3013a0001116f0378236170706c69636174696f6e2f782e62697477617264656e2e757466382d70616464656404503f2a9c1b7e6d4f508a1b2c3d4e5f6071a10558180f1e2d3c4b5a69788796a5b4c3d2e1f0011223344556677858303ce3712a11afc101bace7bca952f914b3f33dfc13469ffb1d59772ffaede96a66c891a2136b0163575b91e9d75270019"
+)
+
+func TestDecryptIndependentMessage(t *testing.T) {
+	rawKey, err := hex.DecodeString(testIndependentKey)
+	assert.NoError(t, err)
+	message, err := hex.DecodeString(testIndependentMessage)
+	assert.NoError(t, err)
+
+	key, err := ParseSymmetricKey(rawKey)
+	assert.NoError(t, err)
+
+	decrypted, err := Decrypt(message, *key)
+	assert.NoError(t, err)
+	assert.Equal(t, "independent vector", string(decrypted))
+
+	// The key is encoded the same way.
+	encodedKey, err := key.Marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, rawKey, encodedKey)
+}
+
+func TestDecryptTaggedMessage(t *testing.T) {
+	message, err := Encrypt([]byte("value"), testKey(), ContentTypeOctetStream)
+	assert.NoError(t, err)
+
+	untagged, err := decodeCBOR(message)
+	assert.NoError(t, err)
+	tagged, err := encodeCBOR(Tag{Number: tagEncrypt0, Value: untagged})
+	assert.NoError(t, err)
+
+	decrypted, err := Decrypt(tagged, testKey())
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(decrypted))
+}
+
+func TestDecryptRejectsTamperedMessages(t *testing.T) {
+	message, err := Encrypt([]byte("value"), testKey(), ContentTypeOctetStream)
+	assert.NoError(t, err)
+
+	tampered := append([]byte{}, message...)
+	tampered[len(tampered)-1] ^= 0x01
+	_, err = Decrypt(tampered, testKey())
+	assert.ErrorContains(t, err, "authentication failed")
+
+	otherKey := testKey()
+	otherKey.Key = bytes.Repeat([]byte{0x43}, 32)
+	_, err = Decrypt(message, otherKey)
+	assert.ErrorContains(t, err, "authentication failed")
+
+	otherKey = testKey()
+	otherKey.KeyID = []byte("another key id")
+	_, err = Decrypt(message, otherKey)
+	assert.ErrorContains(t, err, "different key")
+}
+
+func FuzzDecrypt(f *testing.F) {
+	message, err := Encrypt([]byte("value"), testKey(), ContentTypeUtf8Padded)
+	assert.NoError(f, err)
+	f.Add(message)
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Decrypt(data, testKey())
+	})
+}
@@ -9,6 +9,12 @@ import (
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
 )
 
+const (
+	aesBlockSize = 16
+	ivSize       = 16
+	macSize      = 32
+)
+
 type EncryptedString struct {
 	IV   []byte
 	Data []byte
@@ -30,7 +36,9 @@ func NewFromEncryptedValue(encryptedValue string) (*EncryptedString, error) {
 	encString := EncryptedString{}
 
 	headerPieces := strings.Split(encryptedValue, ".")
-	if len(headerPieces) == 2 {
+	if len(headerPieces) > 2 {
+		return nil, fmt.Errorf("bad format (too many '.')")
+	} else if len(headerPieces) == 2 {
 		s, err := strconv.ParseInt(headerPieces[0], 10, 8)
 		if err != nil {
 			return nil, fmt.Errorf("unable to parse encryption type: %w", err)
@@ -62,14 +70,21 @@ func NewFromEncryptedValue(encryptedValue string) (*EncryptedString, error) {
 
 		encString.IV = []byte(encPieces[0])
 		encString.Data = []byte(encPieces[1])
-	case symmetrickey.Rsa2048_OaepSha256_B64, symmetrickey.Rsa2048_OaepSha1_B64:
+	case symmetrickey.Rsa2048_OaepSha256_B64, symmetrickey.Rsa2048_OaepSha1_B64, symmetrickey.CoseEncrypt0_B64:
 		if len(encPieces) != 1 {
 			return nil, fmt.Errorf("bad length (expected: 1)")
 		}
 
 		encString.Data = []byte(encPieces[0])
+	case symmetrickey.Rsa2048_OaepSha256_HmacSha256_B64, symmetrickey.Rsa2048_OaepSha1_HmacSha256_B64:
+		if len(encPieces) != 2 {
+			return nil, fmt.Errorf("bad length (expected: 2)")
+		}
+
+		encString.Data = []byte(encPieces[0])
+		encString.Hmac = []byte(encPieces[1])
 	default:
-		return nil, fmt.Errorf("unsupported encryption type)")
+		return nil, fmt.Errorf("unsupported encryption type: %d", encString.Key.EncryptionType)
 	}
 
 	base64DecodedIV, err := base64.StdEncoding.DecodeString(string(encString.IV))
@@ -90,9 +105,40 @@ func NewFromEncryptedValue(encryptedValue string) (*EncryptedString, error) {
 	encString.IV = base64DecodedIV
 	encString.Data = base64DecodedData
 	encString.Hmac = base64DecodedMac
+
+	err = encString.validateLengths()
+	if err != nil {
+		return nil, err
+	}
 	return &encString, nil
 }
 
+// validateLengths ensures the decoded pieces have the sizes expected for the
+// encryption type, so that decryption never operates on malformed data.
+func (encString *EncryptedString) validateLengths() error {
+	switch encString.Key.EncryptionType {
+	case symmetrickey.AesCbc256_B64, symmetrickey.AesCbc128_HmacSha256_B64, symmetrickey.AesCbc256_HmacSha256_B64:
+		if len(encString.IV) != ivSize {
+			return fmt.Errorf("bad IV length (expected: %d, got: %d)", ivSize, len(encString.IV))
+		}
+		if len(encString.Data) == 0 || len(encString.Data)%aesBlockSize != 0 {
+			return fmt.Errorf("bad data length (expected a non-zero multiple of %d, got: %d)", aesBlockSize, len(encString.Data))
+		}
+	case symmetrickey.Rsa2048_OaepSha256_B64, symmetrickey.Rsa2048_OaepSha1_B64, symmetrickey.Rsa2048_OaepSha256_HmacSha256_B64, symmetrickey.Rsa2048_OaepSha1_HmacSha256_B64, symmetrickey.CoseEncrypt0_B64:
+		if len(encString.Data) == 0 {
+			return fmt.Errorf("bad data length (expected: >0)")
+		}
+	}
+
+	switch encString.Key.EncryptionType {
+	case symmetrickey.AesCbc128_HmacSha256_B64, symmetrickey.AesCbc256_HmacSha256_B64, symmetrickey.Rsa2048_OaepSha256_HmacSha256_B64, symmetrickey.Rsa2048_OaepSha1_HmacSha256_B64:
+		if len(encString.Hmac) != macSize {
+			return fmt.Errorf("bad mac length (expected: %d, got: %d)", macSize, len(encString.Hmac))
+		}
+	}
+	return nil
+}
+
 func (encString *EncryptedString) String() string {
 	base64EncodedIV := base64.StdEncoding.EncodeToString(encString.IV)
 	base64EncodedData := base64.StdEncoding.EncodeToString(encString.Data)
@@ -0,0 +1,58 @@
+package encryptedstring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFromEncryptedValueRejectsMalformedValues(t *testing.T) {
+	testCases := map[string]string{
+		"too many dots":     "2.a.b",
+		"bad type":          "x.AAAAAAAAAAAAAAAAAAAAAA==|AAAAAAAAAAAAAAAAAAAAAA==|AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		"unsupported type":  "42.AAAAAAAAAAAAAAAAAAAAAA==",
+		"missing pieces":    "2.AAAAAAAAAAAAAAAAAAAAAA==|AAAAAAAAAAAAAAAAAAAAAA==",
+		"short IV":          "2.AAAA|AAAAAAAAAAAAAAAAAAAAAA==|AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		"empty data":        "2.AAAAAAAAAAAAAAAAAAAAAA==||AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		"unaligned data":    "2.AAAAAAAAAAAAAAAAAAAAAA==|AAAA|AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		"short mac":         "2.AAAAAAAAAAAAAAAAAAAAAA==|AAAAAAAAAAAAAAAAAAAAAA==|AAAA",
+		"invalid base64":    "2.!!!!|AAAAAAAAAAAAAAAAAAAAAA==|AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		"empty rsa payload": "4.",
+		"missing rsa mac":   "6.AAAAAAAAAAAAAAAAAAAAAA==",
+		"empty cose":        "7.",
+	}
+
+	for name, value := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewFromEncryptedValue(value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func FuzzNewFromEncryptedValue(f *testing.F) {
+	f.Add("2.A/89QpHf5lcmfJhoEHKbLw==|gUk+VUVzeaArKHGTz4O8ds0EoIiugPnTiZ59li6uy/k=|CvPdfaaIbbsBr3Tnius/n69Rg60v/AoBQTfecWgFsv4=")
+	f.Add("0.A/89QpHf5lcmfJhoEHKbLw==|gUk+VUVzeaArKHGTz4O8dg==")
+	f.Add("A/89QpHf5lcmfJhoEHKbLw==|gUk+VUVzeaArKHGTz4O8dg==|CvPdfaaIbbsBr3Tnius/n69Rg60v/AoBQTfecWgFsv4=")
+	f.Add("4.jT09e5U9IcghbEX0a5Qul/xVfInhDobOVYb9KlvHRVKWR25bDBB2bPccNRiAgUd90GUhFuVrfqqO")
+	f.Add("6.AAAAAAAAAAAAAAAAAAAAAA==|AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	f.Add("7.g1gYowE6AAERbwN4JGFwcGxpY2F0aW9uL3guYml0d2FyZGVuLnV0ZjgtcGFkZGVk")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		encString, err := NewFromEncryptedValue(value)
+		if err != nil {
+			return
+		}
+
+		// Whatever is accepted must survive a serialization round-trip.
+		reparsed, err := NewFromEncryptedValue(encString.String())
+		if err != nil {
+			t.Fatalf("unable to parse serialized value '%s': %v", encString.String(), err)
+		}
+		assert.Equal(t, encString.Key.EncryptionType, reparsed.Key.EncryptionType)
+		assert.Equal(t, encString.IV, reparsed.IV)
+		assert.Equal(t, encString.Data, reparsed.Data)
+		assert.Equal(t, encString.Hmac, reparsed.Hmac)
+	})
+}
@@ -1,7 +1,6 @@
 package crypto
 
 import (
-	"bytes"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
@@ -10,16 +9,21 @@ import (
 	"fmt"
 	"hash"
 
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/cose"
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/encryptedstring"
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
 )
 
 func Encrypt(plainValue []byte, key symmetrickey.Key) (string, error) {
+	if key.EncryptionType == symmetrickey.CoseEncrypt0_B64 {
+		return encryptCose(plainValue, key)
+	}
+
 	randomIV := make([]byte, 16)
 	rand.Read(randomIV)
 
 	if len(key.EncryptionKey) == 0 {
-		return "", fmt.Errorf("no encryption key was provided: %v", key.EncryptionKey)
+		return "", fmt.Errorf("no encryption key was provided")
 	}
 	if len(randomIV) != 16 {
 		return "", fmt.Errorf("bad IV length - expected 16, got: %d", len(randomIV))
@@ -42,7 +46,7 @@ func DecryptPrivateKey(encryptedPrivateKeyStr string, encryptionKey symmetrickey
 		return nil, fmt.Errorf("error decrypting private key: %w", err)
 	}
 
-	decryptedPrivateKey, err := decrypt(encString, &encryptionKey)
+	decryptedPrivateKey, err := Decrypt(encString, encryptionKey)
 	if err != nil {
 		return nil, fmt.Errorf("error decrypting private key: %w", err)
 	}
@@ -51,7 +55,12 @@ func DecryptPrivateKey(encryptedPrivateKeyStr string, encryptionKey symmetrickey
 	if err != nil {
 		return nil, fmt.Errorf("error parse private key: %w", err)
 	}
-	return p.(*rsa.PrivateKey), nil
+
+	rsaPrivateKey, ok := p.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type: %T", p)
+	}
+	return rsaPrivateKey, nil
 }
 
 func DecryptEncryptionKey(encryptedKeyStr string, key symmetrickey.Key) (*symmetrickey.Key, error) {
@@ -86,6 +95,99 @@ func DecryptEncryptionKey(encryptedKeyStr string, key symmetrickey.Key) (*symmet
 	return encryptionKey, nil
 }
 
+// Decrypt decrypts an encrypted string with either a symmetric key
+// (symmetrickey.Key or *symmetrickey.Key) or a private key (*rsa.PrivateKey),
+// depending on the encryption type of the string.
+func Decrypt(encString *encryptedstring.EncryptedString, keyMaterial interface{}) ([]byte, error) {
+	switch encString.Key.EncryptionType {
+	case symmetrickey.AesCbc256_B64, symmetrickey.AesCbc128_HmacSha256_B64, symmetrickey.AesCbc256_HmacSha256_B64:
+		switch key := keyMaterial.(type) {
+		case symmetrickey.Key:
+			return decrypt(encString, &key)
+		case *symmetrickey.Key:
+			if key == nil {
+				return nil, fmt.Errorf("no symmetric key was provided")
+			}
+			return decrypt(encString, key)
+		}
+		return nil, fmt.Errorf("encryption type %d requires a symmetric key, got %T", encString.Key.EncryptionType, keyMaterial)
+
+	case symmetrickey.CoseEncrypt0_B64:
+		switch key := keyMaterial.(type) {
+		case symmetrickey.Key:
+			return decryptCose(encString, &key)
+		case *symmetrickey.Key:
+			if key == nil {
+				return nil, fmt.Errorf("no symmetric key was provided")
+			}
+			return decryptCose(encString, key)
+		}
+		return nil, fmt.Errorf("encryption type %d requires a symmetric key, got %T", encString.Key.EncryptionType, keyMaterial)
+
+	case symmetrickey.Rsa2048_OaepSha256_B64, symmetrickey.Rsa2048_OaepSha1_B64, symmetrickey.Rsa2048_OaepSha256_HmacSha256_B64, symmetrickey.Rsa2048_OaepSha1_HmacSha256_B64:
+		privateKey, ok := keyMaterial.(*rsa.PrivateKey)
+		if !ok || privateKey == nil {
+			return nil, fmt.Errorf("encryption type %d requires a private key, got %T", encString.Key.EncryptionType, keyMaterial)
+		}
+		return decryptAsymmetric(encString, privateKey)
+	}
+	return nil, fmt.Errorf("unsupported encryption type: %d", encString.Key.EncryptionType)
+}
+
+// DecryptString parses and decrypts an encrypted string, see Decrypt().
+func DecryptString(encryptedStr string, keyMaterial interface{}) ([]byte, error) {
+	encString, err := encryptedstring.NewFromEncryptedValue(encryptedStr)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing encrypted string: %w", err)
+	}
+
+	return Decrypt(encString, keyMaterial)
+}
+
+// DecryptSymmetricKey decrypts a symmetric key wrapped with another symmetric
+// key, like cipher keys or attachment keys.
+func DecryptSymmetricKey(encryptedKeyStr string, key symmetrickey.Key) (*symmetrickey.Key, error) {
+	rawKey, err := DecryptString(encryptedKeyStr, key)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting symmetric key: %w", err)
+	}
+
+	return symmetrickey.NewFromRawBytes(rawKey)
+}
+
+// DecryptOrganizationKey decrypts an organization's symmetric key, which is
+// wrapped with the user's public key.
+func DecryptOrganizationKey(encryptedKeyStr string, privateKey *rsa.PrivateKey) (*symmetrickey.Key, error) {
+	rawKey, err := DecryptString(encryptedKeyStr, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting organization key: %w", err)
+	}
+
+	return symmetrickey.NewFromRawBytes(rawKey)
+}
+
+func encryptCose(plainValue []byte, key symmetrickey.Key) (string, error) {
+	data, err := cose.Encrypt(plainValue, key.CoseKey(), cose.ContentTypeOctetStream)
+	if err != nil {
+		return "", fmt.Errorf("error cose encrypting data: %w", err)
+	}
+
+	res := encryptedstring.New(nil, data, nil, key)
+	return res.String(), nil
+}
+
+func decryptCose(encString *encryptedstring.EncryptedString, key *symmetrickey.Key) ([]byte, error) {
+	if key.EncryptionType != symmetrickey.CoseEncrypt0_B64 {
+		return nil, fmt.Errorf("bad encryption type: %d!=%d", encString.Key.EncryptionType, key.EncryptionType)
+	}
+
+	decData, err := cose.Decrypt(encString.Data, key.CoseKey())
+	if err != nil {
+		return nil, fmt.Errorf("error cose decrypting data: %w", err)
+	}
+	return decData, nil
+}
+
 func decrypt(encString *encryptedstring.EncryptedString, key *symmetrickey.Key) ([]byte, error) {
 	if encString.Key.EncryptionType == symmetrickey.AesCbc128_HmacSha256_B64 && key.EncryptionType == symmetrickey.AesCbc256_B64 {
 		return nil, fmt.Errorf("unsupported old scheme")
@@ -95,13 +197,17 @@ func decrypt(encString *encryptedstring.EncryptedString, key *symmetrickey.Key)
 		return nil, fmt.Errorf("bad encryption type: %d!=%d", encString.Key.EncryptionType, key.EncryptionType)
 	}
 
-	if len(encString.Hmac) == 0 && len(key.MacKey) > 0 {
-		return nil, fmt.Errorf("hmac value is missing")
-	}
+	if len(key.MacKey) > 0 {
+		if len(encString.Hmac) == 0 {
+			return nil, fmt.Errorf("hmac value is missing")
+		}
 
-	hmac := hmacSum(append(encString.IV, encString.Data...), key.MacKey, sha256.New)
-	if !bytes.Equal(hmac, encString.Hmac) {
-		return nil, fmt.Errorf("hmac comparison failed: %s!=%s", encString.Hmac, hmac)
+		// The IV is copied to avoid append() writing into the array backing
+		// encString.IV.
+		macData := append(append([]byte{}, encString.IV...), encString.Data...)
+		if !hmac.Equal(hmacSum(macData, key.MacKey, sha256.New), encString.Hmac) {
+			return nil, fmt.Errorf("hmac comparison failed")
+		}
 	}
 	decData, err := aes256Decode(encString.Data, key.EncryptionKey, encString.IV)
 	if err != nil {
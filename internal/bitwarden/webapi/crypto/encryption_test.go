@@ -1,10 +1,12 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/x509"
 	"encoding/base64"
 	"testing"
 
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/cose"
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
 	"github.com/stretchr/testify/assert"
 )
@@ -41,3 +43,36 @@ func TestDecryptPrivateKey(t *testing.T) {
 	base64PublicKey := base64.StdEncoding.EncodeToString(publicKeyBytes)
 	assert.Equal(t, "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEAzfZx4rRpKBVnhiqZe5IH5mRvHjY1iTrZOpooma8PtOIoIdtSRY5YdeX4Hben09C8jZODgyPtxVbWZv/YBS9okE6gPsqugDMQ5M+t7hp3ye9art7CkfvIDjGHZMrANQCYB/tPWkda7jaaAIBkCIPM4+vZ7afBN3Mq/BX7hotSaGlPPP7DCkzbKK/f5U/F/dA8UTZFXtST9ivRWWI8bHdjNwe6Zm2wGUT29zcDmkFq5FqvtY5AuQ6yhuOjXwS1vLP1ckXSJePz0TJNDITW5UmSRI/tesjvnbsq+D/NcerrOvuF0xzKkXlm/lMYq2n3EgQ7neWCCQCrKiQcY9BdhsFEqwIDAQAB", base64PublicKey)
 }
+
+func TestEncryptDecryptCose(t *testing.T) {
+	rawCoseKey, err := cose.SymmetricKey{
+		KeyID: []byte("0123456789abcdef"),
+		Key:   bytes.Repeat([]byte{0x42}, 32),
+	}.Marshal()
+	assert.NoError(t, err)
+
+	// Newer user keys are COSE keys wrapped by the AES-CBC stretched master
+	// key.
+	preloginKey, err := symmetrickey.NewFromRawBytes(testPreloginKey)
+	assert.NoError(t, err)
+	stretchedKey, err := preloginKey.StretchKey()
+	assert.NoError(t, err)
+	wrappedKey, err := Encrypt(rawCoseKey, *stretchedKey)
+	assert.NoError(t, err)
+
+	coseKey, err := DecryptSymmetricKey(wrappedKey, *stretchedKey)
+	assert.NoError(t, err)
+	assert.Equal(t, symmetrickey.CoseEncrypt0_B64, coseKey.EncryptionType)
+	assert.Equal(t, []byte("0123456789abcdef"), coseKey.KeyID)
+
+	encryptedValue, err := Encrypt([]byte("some value"), *coseKey)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^7\.[A-Za-z0-9+/=]+$`, encryptedValue)
+
+	decrypted, err := DecryptString(encryptedValue, coseKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "some value", string(decrypted))
+
+	_, err = DecryptString(encryptedValue, stretchedKey)
+	assert.ErrorContains(t, err, "bad encryption type")
+}
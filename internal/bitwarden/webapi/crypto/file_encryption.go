@@ -0,0 +1,186 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+/*
+* Files (like attachments) are encrypted in a binary format:
+*   [encryption type (1 byte)][IV (16 bytes)][MAC (32 bytes)][data]
+* where the MAC is computed over the IV and the encrypted data.
+ */
+
+const (
+	fileIVSize     = 16
+	fileMACSize    = 32
+	fileHeaderSize = 1 + fileIVSize + fileMACSize
+
+	// fileChunkSize is the amount of data processed at once when streaming.
+	// It must be a multiple of aes.BlockSize.
+	fileChunkSize = 64 * 1024
+)
+
+// EncryptedFileSize returns the size of a file of plainSize bytes once
+// encrypted.
+func EncryptedFileSize(plainSize int64) int64 {
+	return fileHeaderSize + (plainSize/aes.BlockSize+1)*aes.BlockSize
+}
+
+// EncryptFile encrypts the content returned by openFile into dst, without
+// holding the whole content in memory. As the MAC precedes the data, the
+// content is read twice: once to compute the MAC, and once to write the data.
+func EncryptFile(dst io.Writer, openFile func() (io.ReadCloser, error), key symmetrickey.Key) error {
+	if key.EncryptionType != symmetrickey.AesCbc256_HmacSha256_B64 {
+		return fmt.Errorf("unsupported file encryption type: %d", key.EncryptionType)
+	}
+
+	iv := make([]byte, fileIVSize)
+	_, err := rand.Read(iv)
+	if err != nil {
+		return fmt.Errorf("error generating IV: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key.MacKey)
+	mac.Write(iv)
+	err = encryptFileContent(io.Discard, mac, openFile, key, iv)
+	if err != nil {
+		return err
+	}
+
+	header := append([]byte{byte(key.EncryptionType)}, iv...)
+	header = append(header, mac.Sum(nil)...)
+	_, err = dst.Write(header)
+	if err != nil {
+		return fmt.Errorf("error writing encrypted file header: %w", err)
+	}
+
+	return encryptFileContent(dst, nil, openFile, key, iv)
+}
+
+// DecryptFile decrypts an encrypted file from src into dst, without holding
+// the whole content in memory.
+//
+// The MAC can only be verified once everything has been read: if an error is
+// returned, whatever was written to dst must be discarded.
+func DecryptFile(dst io.Writer, src io.Reader, key symmetrickey.Key) error {
+	header := make([]byte, fileHeaderSize)
+	_, err := io.ReadFull(src, header)
+	if err != nil {
+		return fmt.Errorf("error reading encrypted file header: %w", err)
+	}
+
+	encType := symmetrickey.EncryptionType(header[0])
+	if encType != key.EncryptionType || encType != symmetrickey.AesCbc256_HmacSha256_B64 {
+		return fmt.Errorf("unsupported file encryption type: %d", encType)
+	}
+	iv := header[1 : 1+fileIVSize]
+	expectedMAC := header[1+fileIVSize:]
+
+	block, err := aes.NewCipher(key.EncryptionKey)
+	if err != nil {
+		return fmt.Errorf("error creating new cipher block: %w", err)
+	}
+	mode := cipher.NewCBCDecrypter(block, iv)
+
+	mac := hmac.New(sha256.New, key.MacKey)
+	mac.Write(iv)
+
+	// The last encrypted block is held back until the end of the stream, as
+	// it's the one containing the padding.
+	var lastBlock []byte
+	buf := make([]byte, fileChunkSize)
+	for {
+		n, err := io.ReadFull(src, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return fmt.Errorf("error reading encrypted file: %w", err)
+		}
+		if n%aes.BlockSize != 0 {
+			return fmt.Errorf("encrypted file is not a multiple of the block size")
+		}
+
+		if n > 0 {
+			mac.Write(buf[:n])
+			chunk := append(lastBlock, buf[:n]...)
+			lastBlock = append([]byte{}, chunk[len(chunk)-aes.BlockSize:]...)
+
+			chunk = chunk[:len(chunk)-aes.BlockSize]
+			mode.CryptBlocks(chunk, chunk)
+			_, writeErr := dst.Write(chunk)
+			if writeErr != nil {
+				return fmt.Errorf("error writing decrypted file: %w", writeErr)
+			}
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if !hmac.Equal(mac.Sum(nil), expectedMAC) {
+		return fmt.Errorf("mac verification of encrypted file failed")
+	}
+
+	if len(lastBlock) == 0 {
+		return fmt.Errorf("encrypted file has no data")
+	}
+	mode.CryptBlocks(lastBlock, lastBlock)
+	unpadded, err := pkcs5Unpadding(lastBlock, aes.BlockSize)
+	if err != nil {
+		return err
+	}
+	_, err = dst.Write(unpadded)
+	if err != nil {
+		return fmt.Errorf("error writing decrypted file: %w", err)
+	}
+	return nil
+}
+
+func encryptFileContent(dst io.Writer, mac hash.Hash, openFile func() (io.ReadCloser, error), key symmetrickey.Key, iv []byte) error {
+	src, err := openFile()
+	if err != nil {
+		return fmt.Errorf("error opening file to encrypt: %w", err)
+	}
+	defer src.Close()
+
+	block, err := aes.NewCipher(key.EncryptionKey)
+	if err != nil {
+		return fmt.Errorf("error creating new cipher block: %w", err)
+	}
+	mode := cipher.NewCBCEncrypter(block, iv)
+
+	buf := make([]byte, fileChunkSize)
+	for {
+		n, err := io.ReadFull(src, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return fmt.Errorf("error reading file to encrypt: %w", err)
+		}
+
+		chunk := buf[:n]
+		lastChunk := err != nil
+		if lastChunk {
+			chunk = pkcs5Padding(chunk, aes.BlockSize, n)
+		}
+
+		mode.CryptBlocks(chunk, chunk)
+		if mac != nil {
+			mac.Write(chunk)
+		}
+		_, writeErr := dst.Write(chunk)
+		if writeErr != nil {
+			return fmt.Errorf("error writing encrypted file: %w", writeErr)
+		}
+
+		if lastChunk {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecryptFile(t *testing.T) {
+	key, err := symmetrickey.NewFromRawBytes(testEncryptionKey)
+	assert.NoError(t, err)
+
+	for _, size := range []int{0, 1, 15, 16, 17, fileChunkSize - 1, fileChunkSize, fileChunkSize + 1, 3*fileChunkSize + 7} {
+		content := make([]byte, size)
+		rand.Read(content)
+
+		var encrypted bytes.Buffer
+		err := EncryptFile(&encrypted, openBytes(content), *key)
+		assert.NoError(t, err)
+		assert.Equal(t, EncryptedFileSize(int64(size)), int64(encrypted.Len()), "size %d", size)
+
+		var decrypted bytes.Buffer
+		err = DecryptFile(&decrypted, &encrypted, *key)
+		assert.NoError(t, err, "size %d", size)
+		assert.Equal(t, string(content), decrypted.String(), "size %d", size)
+	}
+}
+
+func TestDecryptFileDetectsTampering(t *testing.T) {
+	key, err := symmetrickey.NewFromRawBytes(testEncryptionKey)
+	assert.NoError(t, err)
+
+	var encrypted bytes.Buffer
+	err = EncryptFile(&encrypted, openBytes([]byte("some secret content")), *key)
+	assert.NoError(t, err)
+
+	tampered := encrypted.Bytes()
+	tampered[len(tampered)-1] ^= 0x01
+
+	err = DecryptFile(io.Discard, bytes.NewReader(tampered), *key)
+	assert.EqualError(t, err, "mac verification of encrypted file failed")
+}
+
+func openBytes(content []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+}
@@ -0,0 +1,52 @@
+package crypto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+func FuzzDecryptString(f *testing.F) {
+	encryptionKey, err := symmetrickey.NewFromRawBytes(testEncryptionKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+	privateKey, err := DecryptPrivateKey(testEncryptedPrivateKey, *encryptionKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(testEncryptedEncryptionKey)
+	f.Add(testEncryptedPrivateKey)
+	f.Add(testRsaOaepSha1EncryptedValue)
+	f.Add("0.A/89QpHf5lcmfJhoEHKbLw==|gUk+VUVzeaArKHGTz4O8dg==")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		// Only the absence of panic matters here.
+		DecryptString(value, encryptionKey)
+		DecryptString(value, *encryptionKey)
+		DecryptString(value, privateKey)
+	})
+}
+
+func FuzzDecryptFile(f *testing.F) {
+	key, err := symmetrickey.NewFromRawBytes(testEncryptionKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	var encrypted bytes.Buffer
+	err = EncryptFile(&encrypted, openBytes([]byte("some content")), *key)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(encrypted.Bytes())
+	f.Add([]byte{2})
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		DecryptFile(io.Discard, bytes.NewReader(content), *key)
+	})
+}
@@ -8,6 +8,10 @@ import (
 	"golang.org/x/crypto/pbkdf2"
 )
 
+const (
+	sendPasswordIterations = 100000
+)
+
 func HashPassword(password string, key symmetrickey.Key, localAuthorization bool) string {
 	iterations := 1
 	if localAuthorization {
@@ -16,3 +20,10 @@ func HashPassword(password string, key symmetrickey.Key, localAuthorization bool
 	derivedKey := pbkdf2.Key(key.Key, []byte(password), iterations, 32, sha256.New)
 	return base64.StdEncoding.EncodeToString(derivedKey)
 }
+
+// HashSendPassword hashes the password of a Send, as expected by the Send
+// access endpoints.
+func HashSendPassword(password string, keyMaterial []byte) string {
+	derivedKey := pbkdf2.Key([]byte(password), keyMaterial, sendPasswordIterations, 32, sha256.New)
+	return base64.StdEncoding.EncodeToString(derivedKey)
+}
@@ -14,6 +14,13 @@ func GenerateEncryptionKey(key symmetrickey.Key) (*symmetrickey.Key, string, err
 	return buildEncryptionKey(key, encryptionKey)
 }
 
+// RewrapEncryptionKey encrypts an existing encryption key with another key,
+// which is needed when the master password or the KDF settings change.
+func RewrapEncryptionKey(key symmetrickey.Key, encryptionKey symmetrickey.Key) (string, error) {
+	_, encryptedEncryptionKey, err := buildEncryptionKey(key, encryptionKey.Key)
+	return encryptedEncryptionKey, err
+}
+
 func buildEncryptionKey(key symmetrickey.Key, encryptionKey []byte) (newEncryptionKey *symmetrickey.Key, encryptedEncryptionKey string, err error) {
 	if len(key.Key) == 32 {
 		stretchedKey, err := key.StretchKey()
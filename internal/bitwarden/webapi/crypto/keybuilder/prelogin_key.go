@@ -2,19 +2,46 @@ package keybuilder
 
 import (
 	"crypto/sha256"
+	"fmt"
 
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
 )
 
 const (
 	PBKDF2_SHA256 = 0
+	ARGON2ID      = 1
 )
 
+// KdfConfig describes how keys are derived from passwords. Memory (in MiB)
+// and Parallelism only apply to Argon2id.
+type KdfConfig struct {
+	Type        int
+	Iterations  int
+	Memory      int
+	Parallelism int
+}
+
 func BuildPreloginKey(masterPassword, email string, kdfIteration int) (*symmetrickey.Key, error) {
-	return buildKey(masterPassword, email, PBKDF2_SHA256, kdfIteration)
+	return BuildKey(masterPassword, email, KdfConfig{Type: PBKDF2_SHA256, Iterations: kdfIteration})
 }
 
-func buildKey(masterPassword, salt string, kdf, iterations int) (*symmetrickey.Key, error) {
-	return symmetrickey.NewFromRawBytes(pbkdf2.Key([]byte(masterPassword), []byte(salt), iterations, 32, sha256.New))
+// BuildKey derives a key from a password and a salt.
+func BuildKey(password, salt string, kdf KdfConfig) (*symmetrickey.Key, error) {
+	switch kdf.Type {
+	case PBKDF2_SHA256:
+		if kdf.Iterations < 1 {
+			return nil, fmt.Errorf("invalid PBKDF2 iterations: %d", kdf.Iterations)
+		}
+		return symmetrickey.NewFromRawBytes(pbkdf2.Key([]byte(password), []byte(salt), kdf.Iterations, 32, sha256.New))
+	case ARGON2ID:
+		if kdf.Iterations < 1 || kdf.Memory < 1 || kdf.Parallelism < 1 || kdf.Parallelism > 255 {
+			return nil, fmt.Errorf("invalid Argon2id parameters: iterations=%d, memory=%d, parallelism=%d", kdf.Iterations, kdf.Memory, kdf.Parallelism)
+		}
+		// Bitwarden hashes the salt first, as Argon2 expects a fixed size one.
+		saltHash := sha256.Sum256([]byte(salt))
+		return symmetrickey.NewFromRawBytes(argon2.IDKey([]byte(password), saltHash[:], uint32(kdf.Iterations), uint32(kdf.Memory*1024), uint8(kdf.Parallelism), 32))
+	}
+	return nil, fmt.Errorf("unsupported KDF type: %d", kdf.Type)
 }
@@ -0,0 +1,21 @@
+package keybuilder
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+	"golang.org/x/crypto/hkdf"
+)
+
+// BuildSendKey derives the key of a Send from the key material found in its
+// URL.
+func BuildSendKey(keyMaterial []byte) (*symmetrickey.Key, error) {
+	sendKey := make([]byte, 64)
+	_, err := io.ReadFull(hkdf.New(sha256.New, keyMaterial, []byte("bitwarden-send"), []byte("send")), sendKey)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving send key: %w", err)
+	}
+	return symmetrickey.NewFromRawBytes(sendKey)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/cose"
 	"golang.org/x/crypto/hkdf"
 )
 
@@ -12,6 +13,9 @@ type Key struct {
 	EncryptionKey  []byte
 	EncryptionType EncryptionType
 	MacKey         []byte
+
+	// KeyID is only set for CoseEncrypt0_B64 keys.
+	KeyID []byte
 }
 
 type EncryptionType int
@@ -22,6 +26,15 @@ const (
 	AesCbc256_HmacSha256_B64 EncryptionType = 2
 	Rsa2048_OaepSha256_B64   EncryptionType = 3
 	Rsa2048_OaepSha1_B64     EncryptionType = 4
+
+	// Deprecated types, the HMAC they carry is ignored by the official
+	// clients.
+	Rsa2048_OaepSha256_HmacSha256_B64 EncryptionType = 5
+	Rsa2048_OaepSha1_HmacSha256_B64   EncryptionType = 6
+
+	// CoseEncrypt0_B64 values are COSE_Encrypt0 messages, encrypted with
+	// XChaCha20-Poly1305 using COSE keys.
+	CoseEncrypt0_B64 EncryptionType = 7
 )
 
 func NewFromRawBytesWithEncryptionType(rawKey []byte, encType EncryptionType) (*Key, error) {
@@ -37,6 +50,13 @@ func NewFromRawBytesWithEncryptionType(rawKey []byte, encType EncryptionType) (*
 	} else if encType == AesCbc256_HmacSha256_B64 && len(rawKey) == 64 {
 		key.EncryptionKey = rawKey[0:32]
 		key.MacKey = rawKey[32:]
+	} else if encType == CoseEncrypt0_B64 {
+		coseKey, err := cose.ParseSymmetricKey(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported cose key: %w", err)
+		}
+		key.EncryptionKey = coseKey.Key
+		key.KeyID = coseKey.KeyID
 	} else {
 		return nil, fmt.Errorf("unsupported encryption type and len: %d/%d", encType, len(rawKey))
 	}
@@ -50,9 +70,19 @@ func NewFromRawBytes(rawKey []byte) (*Key, error) {
 	} else if len(rawKey) == 64 {
 		return NewFromRawBytesWithEncryptionType(rawKey, AesCbc256_HmacSha256_B64)
 	}
+
+	// Newer keys are CBOR-encoded COSE keys, and don't have a fixed length.
+	if key, err := NewFromRawBytesWithEncryptionType(rawKey, CoseEncrypt0_B64); err == nil {
+		return key, nil
+	}
 	return nil, fmt.Errorf("unsupported raw key len: %d", len(rawKey))
 }
 
+// CoseKey returns the COSE representation of a CoseEncrypt0_B64 key.
+func (key *Key) CoseKey() cose.SymmetricKey {
+	return cose.SymmetricKey{KeyID: key.KeyID, Key: key.EncryptionKey}
+}
+
 func (key *Key) StretchKey() (*Key, error) {
 	encKey := hkdf.Expand(sha256.New, key.Key, []byte("enc"))
 	macKey := hkdf.Expand(sha256.New, key.Key, []byte("mac"))
@@ -0,0 +1,129 @@
+package webapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// APIError is returned when the server answers with an unexpected status
+// code. It carries what could be parsed from the server's error model, but
+// never the raw response body as it may contain secrets.
+type APIError struct {
+	StatusCode       int
+	Message          string
+	ValidationErrors map[string][]string
+
+	// TwoFactorProviders lists the two-step login methods the user can
+	// choose from, when the login request requires a second factor.
+	TwoFactorProviders []TwoFactorProvider
+
+	description string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("bad status code for %s call: %d", e.description, e.StatusCode)
+	if len(e.Message) > 0 {
+		msg = fmt.Sprintf("%s, message: %s", msg, e.Message)
+	}
+
+	fields := make([]string, 0, len(e.ValidationErrors))
+	for field := range e.ValidationErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		msg = fmt.Sprintf("%s, %s: %s", msg, field, strings.Join(e.ValidationErrors[field], " "))
+	}
+	return msg
+}
+
+// errorResponse is the union of the error models of the API and Identity
+// endpoints, of both Bitwarden and Vaultwarden.
+type errorResponse struct {
+	Message             string                 `json:"message"`
+	ValidationErrors    map[string][]string    `json:"validationErrors"`
+	Error               string                 `json:"error"`
+	ErrorDescription    string                 `json:"error_description"`
+	ErrorModel          *errorResponse         `json:"errorModel"`
+	TwoFactorProviders2 map[string]interface{} `json:"twoFactorProviders2"`
+}
+
+func newAPIError(description string, statusCode int, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode:  statusCode,
+		description: description,
+	}
+
+	var errResp errorResponse
+	if json.Unmarshal(body, &errResp) != nil {
+		return apiErr
+	}
+
+	if errResp.ErrorModel != nil && len(errResp.ErrorModel.Message) > 0 {
+		errResp.Message = errResp.ErrorModel.Message
+		if len(errResp.ValidationErrors) == 0 {
+			errResp.ValidationErrors = errResp.ErrorModel.ValidationErrors
+		}
+	}
+
+	switch {
+	case len(errResp.Message) > 0:
+		apiErr.Message = errResp.Message
+	case len(errResp.ErrorDescription) > 0:
+		apiErr.Message = errResp.ErrorDescription
+	default:
+		apiErr.Message = errResp.Error
+	}
+
+	// Vaultwarden repeats the message as a validation error with an empty
+	// field name.
+	for field, msgs := range errResp.ValidationErrors {
+		if len(field) == 0 && len(msgs) == 1 && msgs[0] == apiErr.Message {
+			continue
+		}
+		if apiErr.ValidationErrors == nil {
+			apiErr.ValidationErrors = map[string][]string{}
+		}
+		apiErr.ValidationErrors[field] = msgs
+	}
+
+	for provider := range errResp.TwoFactorProviders2 {
+		providerType, err := strconv.Atoi(provider)
+		if err != nil {
+			continue
+		}
+		apiErr.TwoFactorProviders = append(apiErr.TwoFactorProviders, TwoFactorProvider(providerType))
+	}
+	sort.Slice(apiErr.TwoFactorProviders, func(i, j int) bool {
+		return apiErr.TwoFactorProviders[i] < apiErr.TwoFactorProviders[j]
+	})
+
+	return apiErr
+}
+
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+func IsRateLimited(err error) bool {
+	return hasStatusCode(err, http.StatusTooManyRequests)
+}
+
+func IsTwoFactorRequired(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && len(apiErr.TwoFactorProviders) > 0
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
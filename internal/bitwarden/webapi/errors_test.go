@@ -0,0 +1,71 @@
+package webapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAPIError(t *testing.T) {
+	testCases := []struct {
+		name                       string
+		statusCode                 int
+		body                       string
+		expectedError              string
+		expectedTwoFactorProviders []TwoFactorProvider
+	}{
+		{
+			name:          "bitwarden validation error",
+			statusCode:    400,
+			body:          `{"message":"The model state is invalid.","validationErrors":{"MasterPasswordHash":["Invalid password."]},"exceptionMessage":null,"object":"error"}`,
+			expectedError: "bad status code for test call: 400, message: The model state is invalid., MasterPasswordHash: Invalid password.",
+		},
+		{
+			name:          "vaultwarden error",
+			statusCode:    400,
+			body:          `{"message":"User already exists","validationErrors":{"":["User already exists"]},"error":"","error_description":"","ErrorModel":{"Message":"User already exists","Object":"error"},"Object":"error"}`,
+			expectedError: "bad status code for test call: 400, message: User already exists",
+		},
+		{
+			name:          "identity error",
+			statusCode:    400,
+			body:          `{"error":"invalid_grant","error_description":"invalid_username_or_password"}`,
+			expectedError: "bad status code for test call: 400, message: invalid_username_or_password",
+		},
+		{
+			name:                       "two factor required",
+			statusCode:                 400,
+			body:                       `{"error":"invalid_grant","error_description":"Two factor required.","TwoFactorProviders":[0,1],"TwoFactorProviders2":{"1":{"Email":"t***@example.com"},"0":null}}`,
+			expectedError:              "bad status code for test call: 400, message: Two factor required.",
+			expectedTwoFactorProviders: []TwoFactorProvider{TwoFactorProviderAuthenticator, TwoFactorProviderEmail},
+		},
+		{
+			name:          "unparsable body",
+			statusCode:    502,
+			body:          `<html>Bad Gateway</html>`,
+			expectedError: "bad status code for test call: 502",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := newAPIError("test", tc.statusCode, []byte(tc.body))
+			assert.EqualError(t, apiErr, tc.expectedError)
+			assert.Equal(t, tc.statusCode, apiErr.StatusCode)
+			assert.Equal(t, tc.expectedTwoFactorProviders, apiErr.TwoFactorProviders)
+		})
+	}
+}
+
+func TestAPIErrorChecks(t *testing.T) {
+	notFound := fmt.Errorf("error calling test: %w", newAPIError("test", 404, nil))
+	assert.True(t, IsNotFound(notFound))
+	assert.False(t, IsUnauthorized(notFound))
+
+	assert.True(t, IsUnauthorized(newAPIError("test", 401, nil)))
+	assert.True(t, IsRateLimited(newAPIError("test", 429, nil)))
+	assert.True(t, IsTwoFactorRequired(newAPIError("test", 400, []byte(`{"TwoFactorProviders2":{"0":null}}`))))
+	assert.False(t, IsTwoFactorRequired(newAPIError("test", 400, nil)))
+	assert.False(t, IsNotFound(fmt.Errorf("some other error")))
+}
@@ -1,5 +1,10 @@
 package webapi
 
+import (
+	"fmt"
+	"time"
+)
+
 type SignupRequest struct {
 	Email              string  `json:"email"`
 	Name               string  `json:"name"`
@@ -28,6 +33,29 @@ type CreateOrganizationResponse struct {
 	Id string `json:"id"`
 }
 
+type PreloginRequest struct {
+	Email string `json:"email"`
+}
+
+type PreloginResponse struct {
+	Kdf           int `json:"kdf"`
+	KdfIterations int `json:"kdfIterations"`
+}
+
+type PasswordRequest struct {
+	MasterPasswordHash    string `json:"masterPasswordHash"`
+	NewMasterPasswordHash string `json:"newMasterPasswordHash"`
+	Key                   string `json:"key"`
+}
+
+type KdfRequest struct {
+	Kdf                   int    `json:"kdf"`
+	KdfIterations         int    `json:"kdfIterations"`
+	MasterPasswordHash    string `json:"masterPasswordHash"`
+	NewMasterPasswordHash string `json:"newMasterPasswordHash"`
+	Key                   string `json:"key"`
+}
+
 type TokenResponse struct {
 	Kdf                 int    `json:"Kdf"`
 	KdfIterations       int    `json:"KdfIterations"`
@@ -54,3 +82,259 @@ type Collection struct {
 	Object         string `json:"object"`
 	ExternalId     string `json:"external_id"`
 }
+
+type Cipher struct {
+	Id             string               `json:"id"`
+	OrganizationId string               `json:"organizationId"`
+	FolderId       string               `json:"folderId"`
+	Type           int                  `json:"type"`
+	Name           string               `json:"name"`
+	Notes          string               `json:"notes"`
+	Login          *CipherLogin         `json:"login"`
+	SecureNote     *CipherSecureNote    `json:"secureNote"`
+	Fields         []CipherField        `json:"fields"`
+	Favorite       bool                 `json:"favorite"`
+	Reprompt       int                  `json:"reprompt"`
+	CollectionIds  []string             `json:"collectionIds"`
+	CreationDate   *time.Time           `json:"creationDate"`
+	RevisionDate   *time.Time           `json:"revisionDate"`
+	DeletedDate    *time.Time           `json:"deletedDate"`
+	Key            string               `json:"key"`
+	Attachments    []AttachmentResponse `json:"attachments"`
+}
+
+type CipherLogin struct {
+	Username string           `json:"username"`
+	Password string           `json:"password"`
+	Totp     string           `json:"totp"`
+	Uris     []CipherLoginUri `json:"uris"`
+}
+
+type CipherLoginUri struct {
+	Uri   string `json:"uri"`
+	Match *int   `json:"match"`
+}
+
+type CipherSecureNote struct {
+	Type int `json:"type"`
+}
+
+type CipherField struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Type     int    `json:"type"`
+	LinkedId *int   `json:"linkedId"`
+}
+
+type Folder struct {
+	Id           string     `json:"id"`
+	Name         string     `json:"name"`
+	RevisionDate *time.Time `json:"revisionDate"`
+}
+
+type CollectionDetails struct {
+	Id             string `json:"id"`
+	OrganizationId string `json:"organizationId"`
+	Name           string `json:"name"`
+	ExternalId     string `json:"externalId"`
+}
+
+type SyncResponse struct {
+	Profile     ProfileResponse     `json:"profile"`
+	Folders     []Folder            `json:"folders"`
+	Collections []CollectionDetails `json:"collections"`
+	Ciphers     []Cipher            `json:"ciphers"`
+}
+
+// SessionTokens are the tokens of a logged in session, which can be persisted
+// to resume the session later on.
+type SessionTokens struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+type AttachmentResponse struct {
+	Id       string `json:"id"`
+	FileName string `json:"fileName"`
+	Key      string `json:"key"`
+	Size     string `json:"size"`
+	SizeName string `json:"sizeName"`
+	Url      string `json:"url"`
+}
+
+type AttachmentUploadRequest struct {
+	Key          string `json:"key"`
+	FileName     string `json:"fileName"`
+	FileSize     int64  `json:"fileSize"`
+	AdminRequest bool   `json:"adminRequest"`
+}
+
+type FileUploadType int
+
+const (
+	FileUploadTypeDirect FileUploadType = 0
+	FileUploadTypeAzure  FileUploadType = 1
+)
+
+type AttachmentUploadResponse struct {
+	AttachmentId   string         `json:"attachmentId"`
+	Url            string         `json:"url"`
+	FileUploadType FileUploadType `json:"fileUploadType"`
+	CipherResponse Cipher         `json:"cipherResponse"`
+}
+
+// Attachment is a decrypted attachment's metadata.
+type Attachment struct {
+	ID       string
+	FileName string
+	Size     string
+	SizeName string
+	Url      string
+}
+
+type ProfileResponse struct {
+	Id            string                `json:"id"`
+	Email         string                `json:"email"`
+	Key           string                `json:"key"`
+	PrivateKey    string                `json:"privateKey"`
+	Organizations []ProfileOrganization `json:"organizations"`
+}
+
+type ProfileOrganization struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+type TwoFactorProvider int
+
+const (
+	TwoFactorProviderAuthenticator   TwoFactorProvider = 0
+	TwoFactorProviderEmail           TwoFactorProvider = 1
+	TwoFactorProviderDuo             TwoFactorProvider = 2
+	TwoFactorProviderYubiKey         TwoFactorProvider = 3
+	TwoFactorProviderU2f             TwoFactorProvider = 4
+	TwoFactorProviderRemember        TwoFactorProvider = 5
+	TwoFactorProviderOrganizationDuo TwoFactorProvider = 6
+	TwoFactorProviderWebAuthn        TwoFactorProvider = 7
+)
+
+var twoFactorProviderNames = map[TwoFactorProvider]string{
+	TwoFactorProviderAuthenticator:   "authenticator",
+	TwoFactorProviderEmail:           "email",
+	TwoFactorProviderDuo:             "duo",
+	TwoFactorProviderYubiKey:         "yubikey",
+	TwoFactorProviderU2f:             "u2f",
+	TwoFactorProviderRemember:        "remember",
+	TwoFactorProviderOrganizationDuo: "organization_duo",
+	TwoFactorProviderWebAuthn:        "webauthn",
+}
+
+func (p TwoFactorProvider) String() string {
+	if name, ok := twoFactorProviderNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(p))
+}
+
+type SendType int
+
+const (
+	SendTypeText SendType = 0
+	SendTypeFile SendType = 1
+)
+
+type SendAccessRequest struct {
+	Password string `json:"password,omitempty"`
+}
+
+type SendAccessResponse struct {
+	Id             string     `json:"id"`
+	Type           SendType   `json:"type"`
+	Name           string     `json:"name"`
+	File           *SendFile  `json:"file"`
+	Text           *SendText  `json:"text"`
+	ExpirationDate *time.Time `json:"expirationDate"`
+}
+
+type SendFile struct {
+	Id       string `json:"id"`
+	FileName string `json:"fileName"`
+	Size     string `json:"size"`
+	SizeName string `json:"sizeName"`
+}
+
+type SendText struct {
+	Text   string `json:"text"`
+	Hidden bool   `json:"hidden"`
+}
+
+type SendFileDownloadResponse struct {
+	Id  string `json:"id"`
+	Url string `json:"url"`
+}
+
+// Send is the decrypted content of a Send, as seen by its recipients.
+type Send struct {
+	ID             string
+	Type           SendType
+	Name           string
+	Text           string
+	Hidden         bool
+	FileName       string
+	File           []byte
+	ExpirationDate *time.Time
+}
+
+type EmergencyAccessType int
+
+const (
+	EmergencyAccessTypeView     EmergencyAccessType = 0
+	EmergencyAccessTypeTakeover EmergencyAccessType = 1
+)
+
+type EmergencyAccessStatus int
+
+const (
+	EmergencyAccessStatusInvited           EmergencyAccessStatus = 0
+	EmergencyAccessStatusAccepted          EmergencyAccessStatus = 1
+	EmergencyAccessStatusConfirmed         EmergencyAccessStatus = 2
+	EmergencyAccessStatusRecoveryInitiated EmergencyAccessStatus = 3
+	EmergencyAccessStatusRecoveryApproved  EmergencyAccessStatus = 4
+)
+
+// EmergencyAccess is an emergency access granted by the logged in user.
+type EmergencyAccess struct {
+	Id           string                `json:"id"`
+	Status       EmergencyAccessStatus `json:"status"`
+	Type         EmergencyAccessType   `json:"type"`
+	WaitTimeDays int                   `json:"waitTimeDays"`
+	GranteeId    string                `json:"granteeId"`
+	Email        string                `json:"email"`
+	Name         string                `json:"name"`
+}
+
+type EmergencyAccessListResponse struct {
+	Data []EmergencyAccess `json:"data"`
+}
+
+type EmergencyAccessInviteRequest struct {
+	Email        string              `json:"email"`
+	Type         EmergencyAccessType `json:"type"`
+	WaitTimeDays int                 `json:"waitTimeDays"`
+}
+
+type EmergencyAccessUpdateRequest struct {
+	Type         EmergencyAccessType `json:"type"`
+	WaitTimeDays int                 `json:"waitTimeDays"`
+}
+
+type EmergencyAccessConfirmRequest struct {
+	Key string `json:"key"`
+}
+
+type UserPublicKeyResponse struct {
+	UserId    string `json:"userId"`
+	PublicKey string `json:"publicKey"`
+}
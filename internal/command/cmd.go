@@ -3,12 +3,19 @@ package command
 import (
 	"bytes"
 	"context"
-	"io"
+	"errors"
 	"os/exec"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
+const (
+	// processWaitDelay is how long to wait for the outputs to be closed, once
+	// a command exited or was killed.
+	processWaitDelay = 5 * time.Second
+)
+
 type NewFn func(binary string, args ...string) Command
 
 // New is only meant to be changed during tests.
@@ -20,15 +27,19 @@ var New = func(binary string, args ...string) Command {
 }
 
 type command struct {
-	binary string
-	args   []string
-	env    []string
-	stdin  io.Reader
+	binary       string
+	args         []string
+	env          []string
+	secretOutput bool
+	stdin        []byte
+	timeout      time.Duration
 }
 
 type Command interface {
 	AppendEnv(envs []string) Command
+	WithSecretOutput() Command
 	WithStdin(string) Command
+	WithTimeout(time.Duration) Command
 	Run(ctx context.Context) ([]byte, error)
 }
 
@@ -37,32 +48,61 @@ func (c *command) AppendEnv(envs []string) Command {
 	return c
 }
 func (c *command) WithStdin(dir string) Command {
-	var stdinBuf bytes.Buffer
-	stdinBuf.Write([]byte(dir))
+	c.stdin = []byte(dir)
+	return c
+}
+
+// WithSecretOutput registers the output of the command as a secret, before
+// it's logged.
+func (c *command) WithSecretOutput() Command {
+	c.secretOutput = true
+	return c
+}
 
-	c.stdin = &stdinBuf
+// WithTimeout kills the command if it didn't finish in time. Retries are
+// given the same timeout again.
+func (c *command) WithTimeout(timeout time.Duration) Command {
+	c.timeout = timeout
 	return c
 }
 
 func (c *command) Run(ctx context.Context) ([]byte, error) {
-	ctx = tflog.SetField(ctx, "command", c.args)
+	ctx = tflog.SetField(ctx, "command", redactAll(c.args))
 	tflog.Debug(ctx, "Running command")
 
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
 	var stdOut, stdErr bytes.Buffer
 	cmd := exec.CommandContext(ctx, c.binary, c.args...)
+	killProcessGroupOnCancel(cmd)
+	cmd.WaitDelay = processWaitDelay
 	cmd.Env = c.env
-	cmd.Stdin = c.stdin
+	if c.stdin != nil {
+		// A new reader is needed for every run, as commands can be retried.
+		cmd.Stdin = bytes.NewReader(c.stdin)
+	}
 	cmd.Stdout = &stdOut
 	cmd.Stderr = &stdErr
 
 	err := cmd.Run()
-	if err != nil {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		tflog.Error(ctx, "Command timed out", map[string]interface{}{"error": err})
+		return nil, NewTimeoutError(c.args)
+	} else if err != nil {
 		tflog.Error(ctx, "Command finished with error", map[string]interface{}{"error": err})
-		tflog.Trace(ctx, "Command outputs", map[string]interface{}{"stdout": stdOut.String(), "stderr": stdErr.String()})
+		tflog.Trace(ctx, "Command outputs", map[string]interface{}{"stdout": Redact(stdOut.String()), "stderr": Redact(stdErr.String())})
 		return nil, NewError(err, c.args, stdOut.String(), stdErr.String())
 	}
+
+	if c.secretOutput {
+		RegisterSecret(stdOut.String())
+	}
 	tflog.Debug(ctx, "Command finished with success")
-	tflog.Trace(ctx, "Command outputs", map[string]interface{}{"stdout": stdOut.String(), "stderr": stdErr.String()})
+	tflog.Trace(ctx, "Command outputs", map[string]interface{}{"stdout": Redact(stdOut.String()), "stderr": Redact(stdErr.String())})
 
 	return stdOut.Bytes(), nil
 }
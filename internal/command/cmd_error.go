@@ -1,6 +1,8 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -12,12 +14,13 @@ type CommandError struct {
 	stderr string
 }
 
+// NewError redacts the secrets of the command's arguments and outputs.
 func NewError(err error, args []string, stdout, stderr string) *CommandError {
 	return &CommandError{
 		err:    err,
-		args:   args,
-		stdout: stdout,
-		stderr: stderr,
+		args:   redactAll(args),
+		stdout: Redact(stdout),
+		stderr: Redact(stderr),
 	}
 }
 
@@ -28,3 +31,40 @@ func (c CommandError) Error() string {
 func (c CommandError) Stderr() string {
 	return c.stderr
 }
+
+func (c CommandError) Stdout() string {
+	return c.stdout
+}
+
+// ExitCode returns the exit code of the command, or -1 if it didn't exit.
+func (c CommandError) ExitCode() int {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(c.err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// TimeoutError is returned when a command was killed because it didn't
+// finish in time, or before the deadline of its context.
+type TimeoutError struct {
+	args []string
+}
+
+// NewTimeoutError redacts the secrets of the command's arguments.
+func NewTimeoutError(args []string) *TimeoutError {
+	return &TimeoutError{args: redactAll(args)}
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timeout while running '%s'", strings.Join(e.args, " "))
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr)
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NewWithLimiter returns commands which wait for the limiter before running.
+// Commands for which isExclusive returns true run alone.
+func NewWithLimiter(newFn NewFn, limiter *Limiter, isExclusive func(args []string) bool) NewFn {
+	return func(binary string, args ...string) Command {
+		return &limitedCommand{
+			cmd:       newFn(binary, args...),
+			exclusive: isExclusive(args),
+			limiter:   limiter,
+		}
+	}
+}
+
+type limitedCommand struct {
+	cmd       Command
+	exclusive bool
+	limiter   *Limiter
+}
+
+func (c *limitedCommand) AppendEnv(envs []string) Command {
+	c.cmd.AppendEnv(envs)
+	return c
+}
+
+func (c *limitedCommand) WithStdin(dir string) Command {
+	c.cmd.WithStdin(dir)
+	return c
+}
+
+func (c *limitedCommand) WithSecretOutput() Command {
+	c.cmd.WithSecretOutput()
+	return c
+}
+
+func (c *limitedCommand) WithTimeout(timeout time.Duration) Command {
+	c.cmd.WithTimeout(timeout)
+	return c
+}
+
+func (c *limitedCommand) Run(ctx context.Context) ([]byte, error) {
+	release, err := c.limiter.Acquire(ctx, c.exclusive)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting to run command: %w", err)
+	}
+	defer release()
+
+	return c.cmd.Run(ctx)
+}
@@ -7,14 +7,17 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
+// RetryHandler decides which failed commands are run again, and how long to
+// wait before doing so.
 type RetryHandler interface {
-	IsRetryable(err error, attempts int) bool
+	IsRetryable(args []string, err error, attempt int) bool
 	Backoff(attempt int) time.Duration
 }
 
 func NewWithRetries(retryHandler RetryHandler) NewFn {
 	return func(binary string, args ...string) Command {
 		return &retryableCommand{
+			args:         args,
 			cmd:          New(binary, args...),
 			retryHandler: retryHandler,
 		}
@@ -22,6 +25,7 @@ func NewWithRetries(retryHandler RetryHandler) NewFn {
 }
 
 type retryableCommand struct {
+	args         []string
 	cmd          Command
 	retryHandler RetryHandler
 }
@@ -35,15 +39,31 @@ func (c *retryableCommand) WithStdin(dir string) Command {
 	return c
 }
 
+func (c *retryableCommand) WithSecretOutput() Command {
+	c.cmd.WithSecretOutput()
+	return c
+}
+
+func (c *retryableCommand) WithTimeout(timeout time.Duration) Command {
+	c.cmd.WithTimeout(timeout)
+	return c
+}
+
 func (c *retryableCommand) Run(ctx context.Context) ([]byte, error) {
 	attempts := 0
 	for {
 		attempts = attempts + 1
 		out, err := c.cmd.Run(ctx)
-		if err == nil || !c.retryHandler.IsRetryable(err, attempts) {
+		if err == nil || ctx.Err() != nil || !c.retryHandler.IsRetryable(c.args, err, attempts) {
 			return out, err
 		}
-		c.retryHandler.Backoff(attempts)
-		tflog.Error(ctx, "Retrying command after error", map[string]interface{}{"error": err})
+
+		delay := c.retryHandler.Backoff(attempts)
+		tflog.Error(ctx, "Retrying command after error", map[string]interface{}{"error": err, "attempt": attempts, "delay": delay.String()})
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
 	}
 }
@@ -3,6 +3,7 @@ package command
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -47,15 +48,57 @@ func TestCommandFailsOnUnmatchedError(t *testing.T) {
 	assert.Equal(t, retryHandler.called, 1)
 }
 
+func TestCommandStdinOnRetries(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") == "1" {
+		stdin, _ := io.ReadAll(os.Stdin)
+		fmt.Printf("test: failing on purpose with stdin '%s'", stdin)
+		os.Exit(1)
+		return
+	}
+
+	retryHandler := &testRetryHandler{}
+	cmd := NewWithRetries(retryHandler)(os.Args[0], "-test.run=TestCommandStdinOnRetries")
+	cmd.AppendEnv([]string{"GO_WANT_HELPER_PROCESS=1"})
+	cmd.WithStdin("payload")
+
+	_, err := cmd.Run(context.Background())
+
+	assert.Equal(t, retryHandler.called, 3)
+	assert.ErrorContains(t, err, "with stdin 'payload'")
+}
+
+func TestCommandBackoffInterruptedByContext(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") == "1" {
+		fmt.Println("test: failing on purpose")
+		os.Exit(1)
+		return
+	}
+
+	retryHandler := &testRetryHandler{delay: time.Minute}
+	cmd := NewWithRetries(retryHandler)(os.Args[0], "-test.run=TestCommandBackoffInterruptedByContext")
+	cmd.AppendEnv([]string{"GO_WANT_HELPER_PROCESS=1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := cmd.Run(ctx)
+
+	assert.ErrorContains(t, err, "failing on purpose")
+	assert.Equal(t, retryHandler.called, 1)
+	assert.Less(t, time.Since(start), time.Minute)
+}
+
 type testRetryHandler struct {
 	called int
+	delay  time.Duration
 }
 
-func (r *testRetryHandler) IsRetryable(err error, attempt int) bool {
+func (r *testRetryHandler) IsRetryable(_ []string, err error, attempt int) bool {
 	r.called = r.called + 1
 	return strings.Contains(err.Error(), "failing on purpose") && attempt < 3
 }
 
 func (r *testRetryHandler) Backoff(attempt int) time.Duration {
-	return 0
+	return r.delay
 }
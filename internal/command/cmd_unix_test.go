@@ -0,0 +1,44 @@
+//go:build unix
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandTimeout(t *testing.T) {
+	cmd := New("/bin/sh", "-c", "sleep 30").AppendEnv([]string{fmt.Sprintf("PATH=%s", os.Getenv("PATH"))})
+
+	_, err := cmd.WithTimeout(200 * time.Millisecond).Run(context.Background())
+
+	assert.True(t, IsTimeout(err))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCommandTimeoutKillsProcessGroup(t *testing.T) {
+	// The forked 'sleep' keeps the output open, and would delay the command's
+	// return if it was left running.
+	cmd := New("/bin/sh", "-c", "sleep 30; true").AppendEnv([]string{fmt.Sprintf("PATH=%s", os.Getenv("PATH"))})
+
+	start := time.Now()
+	_, err := cmd.WithTimeout(200 * time.Millisecond).Run(context.Background())
+
+	assert.True(t, IsTimeout(err))
+	assert.Less(t, time.Since(start), processWaitDelay)
+}
+
+func TestCommandCanceledIsNotTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	_, err := New("/bin/sh", "-c", "sleep 30").Run(ctx)
+
+	assert.Error(t, err)
+	assert.False(t, IsTimeout(err))
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package command
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// killProcessGroupOnCancel starts the command in its own process group, and
+// kills its whole process tree, as Windows doesn't kill children along with
+// their parent.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+	cmd.Cancel = func() error {
+		err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+		if err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+}
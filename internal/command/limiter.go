@@ -0,0 +1,100 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+const (
+	lockPollInterval = 50 * time.Millisecond
+)
+
+// Limiter restricts which commands can run at the same time. Within the
+// process, at most maxParallel commands run concurrently. Across processes,
+// an advisory lock on a file lets commands either share access or get it
+// exclusively.
+type Limiter struct {
+	lockPath string
+	slots    chan struct{}
+}
+
+// NewLimiter returns a Limiter locking the given file, if any. A maxParallel
+// of 0 doesn't restrict how many commands share access.
+func NewLimiter(lockPath string, maxParallel int) *Limiter {
+	l := &Limiter{lockPath: lockPath}
+	if maxParallel > 0 {
+		l.slots = make(chan struct{}, maxParallel)
+	}
+	return l
+}
+
+// Acquire blocks until a command can run, and returns the function to call
+// once it's finished.
+func (l *Limiter) Acquire(ctx context.Context, exclusive bool) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	releaseSlot := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if len(l.lockPath) == 0 {
+		return releaseSlot, nil
+	}
+
+	unlock, err := lockFile(ctx, l.lockPath, exclusive)
+	if err != nil {
+		releaseSlot()
+		return nil, err
+	}
+
+	return func() {
+		unlock()
+		releaseSlot()
+	}, nil
+}
+
+// lockFile polls for the lock, as blocking system calls can't be
+// interrupted when the context is cancelled. The lock's directory isn't
+// created: until the CLI creates its data directory, there is no data file
+// to protect and commands run without the lock.
+func lockFile(ctx context.Context, path string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if errors.Is(err, fs.ErrNotExist) {
+		return func() {}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error opening lock file: %w", err)
+	}
+
+	for {
+		locked, err := tryLockFile(f, exclusive)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error locking '%s': %w", path, err)
+		}
+		if locked {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
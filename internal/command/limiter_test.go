@@ -0,0 +1,86 @@
+package command
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterSharedLocks(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "bw.lock")
+	l := NewLimiter(lockPath, 0)
+
+	release1, err := l.Acquire(context.Background(), false)
+	assert.NoError(t, err)
+	release2, err := l.Acquire(context.Background(), false)
+	assert.NoError(t, err)
+
+	// Another process, or client, can't get an exclusive access meanwhile.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = NewLimiter(lockPath, 0).Acquire(ctx, true)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release1()
+	release2()
+
+	release3, err := NewLimiter(lockPath, 0).Acquire(context.Background(), true)
+	assert.NoError(t, err)
+	release3()
+}
+
+func TestLimiterExclusiveLock(t *testing.T) {
+	l := NewLimiter(filepath.Join(t.TempDir(), "bw.lock"), 0)
+
+	release, err := l.Acquire(context.Background(), true)
+	assert.NoError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		release, err := l.Acquire(context.Background(), false)
+		assert.NoError(t, err)
+		release()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("shared lock acquired while exclusively locked")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	release()
+	<-acquired
+}
+
+func TestLimiterMaxParallel(t *testing.T) {
+	l := NewLimiter("", 2)
+
+	release1, err := l.Acquire(context.Background(), false)
+	assert.NoError(t, err)
+	_, err = l.Acquire(context.Background(), false)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(ctx, false)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release1()
+	_, err = l.Acquire(context.Background(), false)
+	assert.NoError(t, err)
+}
+
+func TestLimiterDoesntCreateLockDirectory(t *testing.T) {
+	lockDir := filepath.Join(t.TempDir(), "missing")
+	l := NewLimiter(filepath.Join(lockDir, "bw.lock"), 0)
+
+	release, err := l.Acquire(context.Background(), true)
+	if assert.NoError(t, err) {
+		release()
+	}
+	assert.NoDirExists(t, lockDir)
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	RedactedValue = "<redacted>"
+
+	// minSecretLength prevents short values from masking large parts of the
+	// outputs they appear in.
+	minSecretLength = 4
+)
+
+var (
+	// sensitiveJSONFieldsRegexp matches string fields of the CLI's JSON
+	// outputs, which hold decrypted secrets or keys.
+	sensitiveJSONFieldsRegexp = regexp.MustCompile(`("(?:password|totp|value|notes|privateKey|key|number|code|ssn)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+	secrets         = map[string]struct{}{}
+	secretsReplacer *strings.Replacer
+	secretsMu       sync.RWMutex
+)
+
+// RegisterSecret masks the secret in everything commands log or wrap into
+// errors from now on.
+func RegisterSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	if _, exists := secrets[secret]; exists {
+		return
+	}
+	secrets[secret] = struct{}{}
+	secretsReplacer = nil
+}
+
+// Redact masks registered secrets, and values of sensitive JSON fields.
+func Redact(s string) string {
+	s = sensitiveJSONFieldsRegexp.ReplaceAllString(s, `$1"`+RedactedValue+`"`)
+	return replacer().Replace(s)
+}
+
+func redactAll(values []string) []string {
+	redacted := make([]string, 0, len(values))
+	for _, v := range values {
+		redacted = append(redacted, Redact(v))
+	}
+	return redacted
+}
+
+func replacer() *strings.Replacer {
+	secretsMu.RLock()
+	r := secretsReplacer
+	secretsMu.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	// Longer secrets go first, in case they contain shorter ones.
+	sorted := make([]string, 0, len(secrets))
+	for secret := range secrets {
+		sorted = append(sorted, secret)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	oldNew := make([]string, 0, 2*len(sorted))
+	for _, secret := range sorted {
+		oldNew = append(oldNew, secret, RedactedValue)
+	}
+	secretsReplacer = strings.NewReplacer(oldNew...)
+	return secretsReplacer
+}
@@ -0,0 +1,308 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+)
+
+const (
+	// RecordCassettesEnv makes UseCassette record interactions with the real
+	// CLI, instead of replaying them.
+	RecordCassettesEnv = "BW_RECORD_CASSETTES"
+)
+
+// errorKindTimeout is recorded for commands which timed out, so that they
+// time out again when replayed.
+const errorKindTimeout = "timeout"
+
+// unredactedEnvs are environment variables whose values are recorded.
+var unredactedEnvs = []string{"BW_NOINTERACTION"}
+
+// Cassette is a list of interactions with the CLI, in the order they
+// happened.
+type Cassette struct {
+	// Comment describes where the interactions come from, like for cassettes
+	// written by hand. It's left empty when recording.
+	Comment      string        `json:"comment,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Args     []string `json:"args"`
+	Env      []string `json:"env,omitempty"`
+	Stdin    *string  `json:"stdin,omitempty"`
+	Stdout   string   `json:"stdout"`
+	Stderr   string   `json:"stderr,omitempty"`
+	ExitCode int      `json:"exit_code"`
+
+	// Error is set when the command couldn't run or didn't exit, like on
+	// timeouts.
+	Error     string `json:"error,omitempty"`
+	ErrorKind string `json:"error_kind,omitempty"`
+}
+
+// UseCassette replays the interactions of a cassette, or records them with
+// the real CLI if RecordCassettesEnv is set.
+func UseCassette(t *testing.T, cassettePath string) func(t *testing.T) {
+	if len(os.Getenv(RecordCassettesEnv)) > 0 {
+		return RecordCommands(t, cassettePath)
+	}
+	return ReplayCommands(t, cassettePath)
+}
+
+// RecordCommands runs commands for real, and writes their interactions to
+// the cassette once the returned function is called. Secrets are redacted
+// from the recorded inputs and outputs.
+func RecordCommands(t *testing.T, cassettePath string) func(t *testing.T) {
+	r := &recorder{newCommand: command.New}
+	command.New = func(binary string, args ...string) command.Command {
+		return &recordingCommand{cmd: r.newCommand(binary, args...), args: args, recorder: r}
+	}
+
+	return func(t *testing.T) {
+		command.New = r.newCommand
+
+		err := r.cassette.save(cassettePath)
+		if err != nil {
+			t.Fatalf("unable to write cassette: %v", err)
+		}
+	}
+}
+
+// ReplayCommands serves the interactions of the cassette instead of running
+// commands. Every command gets the first interaction not served yet, with
+// the same arguments and stdin. The returned function fails the test if
+// interactions weren't all served.
+func ReplayCommands(t *testing.T, cassettePath string) func(t *testing.T) {
+	cassette, err := loadCassette(cassettePath)
+	if err != nil {
+		t.Fatalf("unable to read cassette: %v", err)
+	}
+
+	r := &replayer{cassette: cassette, served: make([]bool, len(cassette.Interactions))}
+	newCommandToRestore := command.New
+	command.New = func(_ string, args ...string) command.Command {
+		return &replayingCommand{args: args, replayer: r}
+	}
+
+	return func(t *testing.T) {
+		command.New = newCommandToRestore
+
+		for i, served := range r.served {
+			if !served {
+				t.Errorf("interaction %d of cassette '%s' wasn't replayed: '%s'", i, cassettePath, strings.Join(r.cassette.Interactions[i].Args, " "))
+			}
+		}
+	}
+}
+
+type recorder struct {
+	cassette   Cassette
+	mu         sync.Mutex
+	newCommand command.NewFn
+}
+
+type recordingCommand struct {
+	cmd      command.Command
+	args     []string
+	env      []string
+	stdin    *string
+	recorder *recorder
+}
+
+func (c *recordingCommand) AppendEnv(envs []string) command.Command {
+	c.cmd.AppendEnv(envs)
+	c.env = append(c.env, envs...)
+	return c
+}
+
+func (c *recordingCommand) WithStdin(data string) command.Command {
+	c.cmd.WithStdin(data)
+	stdin := redactStdin(data)
+	c.stdin = &stdin
+	return c
+}
+
+func (c *recordingCommand) WithSecretOutput() command.Command {
+	c.cmd.WithSecretOutput()
+	return c
+}
+
+func (c *recordingCommand) WithTimeout(timeout time.Duration) command.Command {
+	c.cmd.WithTimeout(timeout)
+	return c
+}
+
+func (c *recordingCommand) Run(ctx context.Context) ([]byte, error) {
+	out, err := c.cmd.Run(ctx)
+
+	interaction := Interaction{
+		Args:   c.args,
+		Env:    redactEnv(c.env),
+		Stdin:  c.stdin,
+		Stdout: command.Redact(string(out)),
+	}
+
+	// Outputs of command errors are already redacted.
+	var cmdErr *command.CommandError
+	if errors.As(err, &cmdErr) {
+		interaction.Stdout = cmdErr.Stdout()
+		interaction.Stderr = cmdErr.Stderr()
+		interaction.ExitCode = cmdErr.ExitCode()
+	} else if err != nil {
+		interaction.ExitCode = -1
+		interaction.Error = command.Redact(err.Error())
+		if command.IsTimeout(err) {
+			interaction.ErrorKind = errorKindTimeout
+		}
+	}
+
+	c.recorder.mu.Lock()
+	c.recorder.cassette.Interactions = append(c.recorder.cassette.Interactions, interaction)
+	c.recorder.mu.Unlock()
+	return out, err
+}
+
+type replayer struct {
+	cassette Cassette
+	mu       sync.Mutex
+	served   []bool
+}
+
+func (r *replayer) next(args []string, stdin *string) (*Interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.served[i] || !slices.Equal(interaction.Args, args) || !equalStdin(interaction.Stdin, stdin) {
+			continue
+		}
+		r.served[i] = true
+		return &r.cassette.Interactions[i], nil
+	}
+	return nil, fmt.Errorf("no interaction left in cassette for command: '%s'", strings.Join(args, " "))
+}
+
+type replayingCommand struct {
+	args     []string
+	stdin    *string
+	replayer *replayer
+}
+
+func (c *replayingCommand) AppendEnv(_ []string) command.Command {
+	return c
+}
+
+func (c *replayingCommand) WithStdin(data string) command.Command {
+	stdin := redactStdin(data)
+	c.stdin = &stdin
+	return c
+}
+
+func (c *replayingCommand) WithSecretOutput() command.Command {
+	return c
+}
+
+func (c *replayingCommand) WithTimeout(_ time.Duration) command.Command {
+	return c
+}
+
+func (c *replayingCommand) Run(_ context.Context) ([]byte, error) {
+	interaction, err := c.replayer.next(c.args, c.stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case interaction.ErrorKind == errorKindTimeout:
+		return nil, command.NewTimeoutError(c.args)
+	case len(interaction.Error) > 0:
+		return nil, errors.New(interaction.Error)
+	case interaction.ExitCode != 0:
+		return nil, command.NewError(exitError(interaction.ExitCode), c.args, interaction.Stdout, interaction.Stderr)
+	}
+	return []byte(interaction.Stdout), nil
+}
+
+// exitError stands in for the *exec.ExitError of recorded commands.
+type exitError int
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func (e exitError) ExitCode() int {
+	return int(e)
+}
+
+func loadCassette(cassettePath string) (Cassette, error) {
+	var cassette Cassette
+	data, err := os.ReadFile(cassettePath)
+	if err != nil {
+		return cassette, err
+	}
+
+	err = json.Unmarshal(data, &cassette)
+	if err != nil {
+		return cassette, fmt.Errorf("error parsing '%s': %w", cassettePath, err)
+	}
+	return cassette, nil
+}
+
+func (c Cassette) save(cassettePath string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(cassettePath), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cassettePath, append(data, '\n'), 0644)
+}
+
+// redactEnv keeps the names of the environment variables, but not their
+// values which can be secrets or depend on the machine.
+func redactEnv(envs []string) []string {
+	redacted := make([]string, 0, len(envs))
+	for _, env := range envs {
+		name, _, _ := strings.Cut(env, "=")
+		if slices.Contains(unredactedEnvs, name) {
+			redacted = append(redacted, env)
+		} else {
+			redacted = append(redacted, fmt.Sprintf("%s=%s", name, command.RedactedValue))
+		}
+	}
+	return redacted
+}
+
+// redactStdin redacts secrets from stdin, including from the base64 encoded
+// JSON objects passed to the CLI. Stdins of replayed commands are redacted
+// the same way before being compared to recorded ones.
+func redactStdin(stdin string) string {
+	decoded, err := base64.RawStdEncoding.DecodeString(stdin)
+	if err == nil && json.Valid(decoded) {
+		return base64.RawStdEncoding.EncodeToString([]byte(command.Redact(string(decoded))))
+	}
+	return command.Redact(stdin)
+}
+
+func equalStdin(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	cassettePath := filepath.Join(t.TempDir(), "cassettes", "test.json")
+
+	removeRecorder := RecordCommands(t, cassettePath)
+	recorded := runHelperCommands()
+	removeRecorder(t)
+
+	cassette, err := loadCassette(cassettePath)
+	if assert.NoError(t, err) && assert.Len(t, cassette.Interactions, 2) {
+		assert.Equal(t, []string{"GO_WANT_HELPER_PROCESS=<redacted>", "BW_SESSION=<redacted>"}, cassette.Interactions[0].Env)
+		assert.Equal(t, "payload", *cassette.Interactions[0].Stdin)
+		assert.Equal(t, "out: payload", cassette.Interactions[0].Stdout)
+		assert.Equal(t, 0, cassette.Interactions[0].ExitCode)
+		assert.Equal(t, "err: failing", cassette.Interactions[1].Stderr)
+		assert.Equal(t, 3, cassette.Interactions[1].ExitCode)
+	}
+
+	removeReplayer := ReplayCommands(t, cassettePath)
+	replayed := runHelperCommands()
+	removeReplayer(t)
+
+	assert.Equal(t, recorded, replayed)
+}
+
+func TestCassetteRedactsSecrets(t *testing.T) {
+	cassettePath := filepath.Join(t.TempDir(), "test.json")
+	encodedObject := base64.RawStdEncoding.EncodeToString([]byte(`{"name":"item","login":{"password":"secret-2"}}`))
+
+	removeRecorder := RecordCommands(t, cassettePath)
+	out, err := helperCommand("echo").WithStdin(`{"password":"secret-1"}`).Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, `out: {"password":"secret-1"}`, string(out))
+	_, err = helperCommand("echo").WithStdin(encodedObject).Run(context.Background())
+	assert.NoError(t, err)
+	removeRecorder(t)
+
+	raw, err := os.ReadFile(cassettePath)
+	if assert.NoError(t, err) {
+		assert.NotContains(t, string(raw), "secret-1")
+	}
+
+	cassette, err := loadCassette(cassettePath)
+	if assert.NoError(t, err) && assert.Len(t, cassette.Interactions, 2) {
+		assert.Equal(t, `{"password":"<redacted>"}`, *cassette.Interactions[0].Stdin)
+		assert.Equal(t, `out: {"password":"<redacted>"}`, cassette.Interactions[0].Stdout)
+
+		decoded, err := base64.RawStdEncoding.DecodeString(*cassette.Interactions[1].Stdin)
+		if assert.NoError(t, err) {
+			assert.Equal(t, `{"name":"item","login":{"password":"<redacted>"}}`, string(decoded))
+		}
+	}
+
+	removeReplayer := ReplayCommands(t, cassettePath)
+	defer removeReplayer(t)
+
+	out, err = command.New("bw", helperArgs("echo")...).WithStdin(`{"password":"secret-1"}`).Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, `out: {"password":"<redacted>"}`, string(out))
+	_, err = command.New("bw", helperArgs("echo")...).WithStdin(encodedObject).Run(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestCassetteReplaysTimeouts(t *testing.T) {
+	cassettePath := filepath.Join(t.TempDir(), "test.json")
+
+	removeRecorder := RecordCommands(t, cassettePath)
+	_, err := helperCommand("sleep").WithTimeout(100 * time.Millisecond).Run(context.Background())
+	assert.True(t, command.IsTimeout(err))
+	removeRecorder(t)
+
+	removeReplayer := ReplayCommands(t, cassettePath)
+	defer removeReplayer(t)
+
+	_, replayedErr := command.New("bw", helperArgs("sleep")...).Run(context.Background())
+	assert.True(t, command.IsTimeout(replayedErr))
+	assert.ErrorIs(t, replayedErr, context.DeadlineExceeded)
+	assert.Equal(t, err.Error(), replayedErr.Error())
+}
+
+func TestCassetteReplayUnknownCommand(t *testing.T) {
+	cassettePath := filepath.Join(t.TempDir(), "test.json")
+	assert.NoError(t, Cassette{Interactions: []Interaction{{Args: []string{"status"}, Stdout: "{}"}}}.save(cassettePath))
+
+	removeReplayer := ReplayCommands(t, cassettePath)
+	defer removeReplayer(t)
+
+	out, err := command.New("bw", "status").Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(out))
+
+	_, err = command.New("bw", "status").Run(context.Background())
+	assert.ErrorContains(t, err, "no interaction left in cassette for command: 'status'")
+}
+
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	switch os.Args[len(os.Args)-1] {
+	case "echo":
+		stdin, _ := io.ReadAll(os.Stdin)
+		fmt.Printf("out: %s", stdin)
+		os.Exit(0)
+	case "sleep":
+		time.Sleep(time.Minute)
+	case "fail":
+		fmt.Fprint(os.Stderr, "err: failing")
+		os.Exit(3)
+	}
+}
+
+func helperArgs(command string) []string {
+	return []string{"-test.run=TestHelperProcess", "--", command}
+}
+
+func helperCommand(name string) command.Command {
+	return command.New(os.Args[0], helperArgs(name)...).AppendEnv([]string{"GO_WANT_HELPER_PROCESS=1"})
+}
+
+// runHelperCommands returns the outputs and errors of commands run through
+// command.New.
+func runHelperCommands() []string {
+	results := []string{}
+
+	out, err := command.New(os.Args[0], "-test.run=TestHelperProcess", "--", "echo").
+		AppendEnv([]string{"GO_WANT_HELPER_PROCESS=1", "BW_SESSION=secret"}).
+		WithStdin("payload").
+		Run(context.Background())
+	results = append(results, string(out), fmt.Sprint(err))
+
+	_, err = command.New(os.Args[0], "-test.run=TestHelperProcess", "--", "fail").
+		AppendEnv([]string{"GO_WANT_HELPER_PROCESS=1"}).
+		Run(context.Background())
+
+	var cmdErr *command.CommandError
+	if errors.As(err, &cmdErr) {
+		results = append(results, cmdErr.Error(), cmdErr.Stderr(), fmt.Sprint(cmdErr.ExitCode()))
+	} else {
+		results = append(results, fmt.Sprintf("unexpected error: %v", err))
+	}
+	return results
+}
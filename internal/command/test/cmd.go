@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
 )
@@ -37,6 +38,14 @@ func (c *testCommand) WithStdin(data string) command.Command {
 	return c
 }
 
+func (c *testCommand) WithSecretOutput() command.Command {
+	return c
+}
+
+func (c *testCommand) WithTimeout(_ time.Duration) command.Command {
+	return c
+}
+
 func (c *testCommand) Run(_ context.Context) ([]byte, error) {
 	argsStr := strings.Join(c.args, " ")
 	c.callback(argsStr, c.stdin)
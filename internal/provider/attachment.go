@@ -11,17 +11,41 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
 )
 
 func attachmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	itemId := d.Get(attributeAttachmentItemID).(string)
+	filePath := d.Get(attributeAttachmentFile).(string)
+
+	apiClient, err := attachmentAPIClient(ctx, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if apiClient != nil {
+		attachment, err := apiClient.CreateAttachment(ctx, itemId, filePath)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		err = meta.(bw.Client).Sync(ctx)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		return diag.FromErr(attachmentDataFromStruct(d, bw.Attachment{
+			ID:       attachment.ID,
+			FileName: attachment.FileName,
+			Size:     attachment.Size,
+			SizeName: attachment.SizeName,
+			Url:      attachment.Url,
+		}))
+	}
 
 	existingAttachments, err := listExistingAttachments(ctx, meta.(bw.Client), itemId)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	filePath := d.Get(attributeAttachmentFile).(string)
 	obj, err := meta.(bw.Client).CreateAttachment(ctx, itemId, filePath)
 	if err != nil {
 		return diag.FromErr(err)
@@ -66,9 +90,33 @@ func attachmentRead(ctx context.Context, d *schema.ResourceData, meta interface{
 func attachmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	itemId := d.Get(attributeAttachmentItemID).(string)
 
+	apiClient, err := attachmentAPIClient(ctx, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if apiClient != nil {
+		err = apiClient.DeleteAttachment(ctx, itemId, d.Id())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		return diag.FromErr(meta.(bw.Client).Sync(ctx))
+	}
+
 	return diag.FromErr(meta.(bw.Client).DeleteAttachment(ctx, itemId, d.Id()))
 }
 
+// attachmentAPIClient returns the API client attachments are transferred
+// with, which streams them instead of going through temporary files. It's nil
+// when the provider isn't configured with a master password, in which case
+// the CLI is used.
+func attachmentAPIClient(ctx context.Context, meta interface{}) (webapi.Client, error) {
+	m, ok := meta.(*providerMeta)
+	if !ok || len(m.email) == 0 || len(m.getMasterPassword()) == 0 {
+		return nil, nil
+	}
+	return m.sharedWebAPIClient(ctx)
+}
+
 func attachmentDataFromStruct(d *schema.ResourceData, attachment bw.Attachment) error {
 	d.SetId(attachment.ID)
 
@@ -100,7 +148,17 @@ func readDataSourceAttachment() schema.ReadContextFunc {
 
 		attachmentId := d.Get(attributeID).(string)
 
-		content, err := meta.(bw.Client).GetAttachment(ctx, itemId, attachmentId)
+		apiClient, err := attachmentAPIClient(ctx, meta)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		var content []byte
+		if apiClient != nil {
+			content, err = apiClient.GetAttachment(ctx, itemId, attachmentId)
+		} else {
+			content, err = meta.(bw.Client).GetAttachment(ctx, itemId, attachmentId)
+		}
 		if err != nil {
 			return diag.FromErr(err)
 		}
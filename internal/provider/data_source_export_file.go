@@ -0,0 +1,281 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/exportfile"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/symmetrickey"
+)
+
+func dataSourceExportFile() *schema.Resource {
+	return &schema.Resource{
+		Description: "Use this data source to read the content of a Bitwarden JSON export. " +
+			"Unencrypted and password-protected exports are read without contacting the server. " +
+			"Account-encrypted exports require the provider's `master_password`. " +
+			"Cards, identities and SSH keys aren't supported, and are reported in a warning.",
+		ReadContext: readDataSourceExportFile,
+		Schema: map[string]*schema.Schema{
+			attributeID: {
+				Description: descriptionIdentifier,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			attributeExportFilePath: {
+				Description: descriptionExportFilePath,
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			attributeExportPassword: {
+				Description: descriptionExportFilePassword,
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+			},
+			attributeExportFolders: {
+				Description: descriptionExportFolders,
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						attributeID:   computedString(descriptionIdentifier, false),
+						attributeName: computedString(descriptionName, false),
+					},
+				},
+			},
+			attributeExportCollections: {
+				Description: descriptionExportCollections,
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						attributeID:             computedString(descriptionIdentifier, false),
+						attributeName:           computedString(descriptionName, false),
+						attributeOrganizationID: computedString(descriptionOrganizationID, false),
+					},
+				},
+			},
+			attributeExportLogins: {
+				Description: descriptionExportLogins,
+				Type:        schema.TypeList,
+				Computed:    true,
+				Sensitive:   true,
+				Elem: &schema.Resource{
+					Schema: exportItemSchema(map[string]*schema.Schema{
+						attributeLoginUsername: computedString(descriptionLoginUsername, true),
+						attributeLoginPassword: computedString(descriptionLoginPassword, true),
+						attributeLoginTotp:     computedString(descriptionLoginTotp, true),
+						attributeLoginURIs: {
+							Description: descriptionLoginUri,
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									attributeLoginURIsMatch: computedString(descriptionLoginUriMatch, false),
+									attributeLoginURIsValue: computedString(descriptionLoginUriValue, false),
+								},
+							},
+						},
+					}),
+				},
+			},
+			attributeExportSecureNotes: {
+				Description: descriptionExportSecureNotes,
+				Type:        schema.TypeList,
+				Computed:    true,
+				Sensitive:   true,
+				Elem: &schema.Resource{
+					Schema: exportItemSchema(map[string]*schema.Schema{}),
+				},
+			},
+		},
+	}
+}
+
+func exportItemSchema(base map[string]*schema.Schema) map[string]*schema.Schema {
+	base[attributeID] = computedString(descriptionIdentifier, false)
+	base[attributeName] = computedString(descriptionName, false)
+	base[attributeNotes] = computedString(descriptionNotes, true)
+	base[attributeFolderID] = computedString(descriptionFolderID, false)
+	base[attributeOrganizationID] = computedString(descriptionOrganizationID, false)
+	base[attributeCollectionIDs] = &schema.Schema{
+		Description: descriptionCollectionIDs,
+		Type:        schema.TypeList,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Computed:    true,
+	}
+	return base
+}
+
+func computedString(description string, sensitive bool) *schema.Schema {
+	return &schema.Schema{
+		Description: description,
+		Type:        schema.TypeString,
+		Computed:    true,
+		Sensitive:   sensitive,
+	}
+}
+
+func readDataSourceExportFile(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	raw, err := os.ReadFile(d.Get(attributeExportFilePath).(string))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error reading export file: %w", err))
+	}
+
+	f, err := exportfile.Read(raw, d.Get(attributeExportPassword).(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if f.Encrypted {
+		err = decryptAccountExport(ctx, f, meta.(*providerMeta))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	folders := []interface{}{}
+	collections := []interface{}{}
+	logins := []interface{}{}
+	secureNotes := []interface{}{}
+	unsupportedItems := []string{}
+	for _, obj := range f.Objects() {
+		switch obj.Object {
+		case bw.ObjectTypeFolder:
+			folders = append(folders, map[string]interface{}{
+				attributeID:   obj.ID,
+				attributeName: obj.Name,
+			})
+		case bw.ObjectTypeOrgCollection:
+			collections = append(collections, map[string]interface{}{
+				attributeID:             obj.ID,
+				attributeName:           obj.Name,
+				attributeOrganizationID: obj.OrganizationID,
+			})
+		case bw.ObjectTypeItem:
+			item := map[string]interface{}{
+				attributeID:             obj.ID,
+				attributeName:           obj.Name,
+				attributeNotes:          obj.Notes,
+				attributeFolderID:       obj.FolderID,
+				attributeOrganizationID: obj.OrganizationID,
+				attributeCollectionIDs:  obj.CollectionIds,
+			}
+
+			switch obj.Type {
+			case bw.ItemTypeLogin:
+				item[attributeLoginUsername] = obj.Login.Username
+				item[attributeLoginPassword] = obj.Login.Password
+				item[attributeLoginTotp] = obj.Login.Totp
+				item[attributeLoginURIs] = objectLoginURIsFromStruct(ctx, obj.Login.URIs)
+				logins = append(logins, item)
+			case bw.ItemTypeSecureNote:
+				secureNotes = append(secureNotes, item)
+			default:
+				unsupportedItems = append(unsupportedItems, fmt.Sprintf("'%s' (type %d)", obj.Name, obj.Type))
+			}
+		}
+	}
+
+	hash := sha256.Sum256(raw)
+	d.SetId(hex.EncodeToString(hash[:]))
+
+	err = d.Set(attributeExportFolders, folders)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set(attributeExportCollections, collections)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set(attributeExportLogins, logins)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set(attributeExportSecureNotes, secureNotes)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Cards, identities and SSH keys can't be represented by the provider,
+	// which is reported instead of silently leaving them out.
+	if len(unsupportedItems) > 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Export file contains unsupported items",
+			Detail:   fmt.Sprintf("Only logins and secure notes are read from export files, the following items were skipped: %s.", strings.Join(unsupportedItems, ", ")),
+		}}
+	}
+	return nil
+}
+
+// decryptAccountExport decrypts an export with the key of the account, or of
+// the organization, it was made from. Those are retrieved from the server.
+func decryptAccountExport(ctx context.Context, f *exportfile.File, m *providerMeta) error {
+	masterPassword := m.getMasterPassword()
+	if len(masterPassword) == 0 {
+		return fmt.Errorf("the provider's '%s' is required to read account-encrypted exports", attributeMasterPassword)
+	}
+
+	client, err := m.newLoggedInWebAPIClient(ctx, masterPassword)
+	if err != nil {
+		return err
+	}
+
+	prelogin, err := client.PreLogin(ctx, m.email)
+	if err != nil {
+		return err
+	}
+
+	syncResp, err := client.Sync(ctx)
+	if err != nil {
+		return err
+	}
+
+	preloginKey, err := keybuilder.BuildPreloginKey(masterPassword, m.email, prelogin.KdfIterations)
+	if err != nil {
+		return fmt.Errorf("error building prelogin key: %w", err)
+	}
+
+	userKey, err := crypto.DecryptEncryptionKey(syncResp.Profile.Key, *preloginKey)
+	if err != nil {
+		return fmt.Errorf("error decrypting user key: %w", err)
+	}
+
+	keys := []symmetrickey.Key{*userKey}
+	if len(syncResp.Profile.PrivateKey) > 0 {
+		privateKey, err := crypto.DecryptPrivateKey(syncResp.Profile.PrivateKey, *userKey)
+		if err != nil {
+			return fmt.Errorf("error decrypting private key: %w", err)
+		}
+
+		for _, org := range syncResp.Profile.Organizations {
+			orgKey, err := crypto.DecryptOrganizationKey(org.Key, privateKey)
+			if err != nil {
+				return fmt.Errorf("error decrypting key of organization '%s': %w", org.Id, err)
+			}
+			keys = append(keys, *orgKey)
+		}
+	}
+
+	for _, key := range keys {
+		err = f.Decrypt(key)
+		if !errors.Is(err, exportfile.ErrWrongKey) {
+			return err
+		}
+	}
+	return fmt.Errorf("export wasn't made from this account or one of its organizations")
+}
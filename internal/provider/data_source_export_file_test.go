@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccDataSourceExportFile(t *testing.T) {
+	ensureVaultwardenConfigured(t)
+
+	resourceName := "data.bitwarden_export_file.foo"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: tfConfigProvider() + tfConfigDataExportFile(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "folders.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "folders.0.name", "imported-folder"),
+					resource.TestCheckResourceAttr(resourceName, "collections.#", "0"),
+					resource.TestCheckResourceAttr(resourceName, "logins.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "logins.0.name", "imported-login"),
+					resource.TestCheckResourceAttr(resourceName, "logins.0.username", "imported-username"),
+					resource.TestCheckResourceAttr(resourceName, "logins.0.password", "imported-password"),
+					resource.TestCheckResourceAttr(resourceName, "secure_notes.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "secure_notes.0.notes", "imported-notes"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSourceExportFileWarnsAboutUnsupportedItems(t *testing.T) {
+	d := dataSourceExportFile().TestResourceData()
+	assert.NoError(t, d.Set(attributeExportFilePath, "../bitwarden/exportfile/fixtures/export-card-identity.json"))
+
+	diags := readDataSourceExportFile(context.Background(), d, &providerMeta{})
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, diag.Warning, diags[0].Severity)
+		assert.Contains(t, diags[0].Detail, "'card-1' (type 3)")
+		assert.Contains(t, diags[0].Detail, "'identity-1' (type 4)")
+		assert.Contains(t, diags[0].Detail, "'ssh-key-1' (type 5)")
+	}
+}
+
+func tfConfigDataExportFile() string {
+	return `
+data "bitwarden_export_file" "foo" {
+	provider = bitwarden
+
+	path = "fixtures/import.json"
+}
+`
+}
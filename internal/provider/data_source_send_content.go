@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+)
+
+func dataSourceSendContent() *schema.Resource {
+	return &schema.Resource{
+		Description: "Use this data source to receive the content of a Send from its URL. " +
+			"Sends are accessed anonymously, on the server the URL points to. " +
+			"Each read counts as an access of the Send.",
+		ReadContext: readDataSourceSendContent,
+		Schema: map[string]*schema.Schema{
+			attributeID: {
+				Description: descriptionIdentifier,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			attributeSendURL: {
+				Description: descriptionSendURL,
+				Type:        schema.TypeString,
+				Required:    true,
+				Sensitive:   true,
+			},
+			attributeSendPassword: {
+				Description: descriptionSendPassword,
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+			},
+			attributeName: {
+				Description: descriptionName,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			attributeType: {
+				Description: descriptionSendType,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			attributeSendText: {
+				Description: descriptionSendText,
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			attributeSendFileName: {
+				Description: descriptionSendFileName,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			attributeSendFileContent: {
+				Description: descriptionSendFileContent,
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			attributeSendExpirationDate: {
+				Description: descriptionSendExpirationDate,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func readDataSourceSendContent(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	serverURL, accessID, keyMaterial, err := webapi.ParseSendURL(d.Get(attributeSendURL).(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	client, err := meta.(*providerMeta).newWebAPIClientForServer(serverURL)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	send, err := client.AccessSend(ctx, accessID, keyMaterial, d.Get(attributeSendPassword).(string))
+	if webapi.IsUnauthorized(err) {
+		return diag.Errorf("send is password protected, '%s' is required", attributeSendPassword)
+	} else if webapi.IsNotFound(err) {
+		return diag.FromErr(fmt.Errorf("send not found, it may have expired or been deleted: %w", err))
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(send.ID)
+
+	err = d.Set(attributeName, send.Name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	sendType := "text"
+	if send.Type == webapi.SendTypeFile {
+		sendType = "file"
+	}
+	err = d.Set(attributeType, sendType)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set(attributeSendText, send.Text)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set(attributeSendFileName, send.FileName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set(attributeSendFileContent, string(send.File))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if send.ExpirationDate != nil {
+		return diag.FromErr(d.Set(attributeSendExpirationDate, send.ExpirationDate.Format(bw.DateLayout)))
+	}
+	return diag.FromErr(d.Set(attributeSendExpirationDate, ""))
+}
@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceSendContentFailsOnInvalidSend(t *testing.T) {
+	ensureVaultwardenConfigured(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      tfConfigProvider() + tfConfigDataSendContent("https://vault.example.com/#/vault/"),
+				ExpectError: regexp.MustCompile("send URL doesn't end with"),
+			},
+			{
+				Config:      tfConfigProvider() + tfConfigDataSendContent(fmt.Sprintf("%s/#/send/inexistent-send/MDEyMzQ1Njc4OWFiY2RlZg", testServerURL)),
+				ExpectError: regexp.MustCompile("send not found"),
+			},
+		},
+	})
+}
+
+func tfConfigDataSendContent(url string) string {
+	return fmt.Sprintf(`
+data "bitwarden_send_content" "foo" {
+	provider = bitwarden
+
+	url = "%s"
+}
+`, url)
+}
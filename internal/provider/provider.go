@@ -5,11 +5,20 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bwserve"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/readcache"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/vaultcache"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
 )
 
 type LoginMethod int
@@ -77,6 +86,17 @@ func New(version string) func() *schema.Provider {
 					Required:    true,
 					DefaultFunc: schema.EnvDefaultFunc("BW_EMAIL", nil),
 				},
+				attributeCLIPath: {
+					Type:        schema.TypeString,
+					Description: descriptionCLIPath,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("BW_CLI_PATH", "bw"),
+				},
+				attributeUseCLIServe: {
+					Type:        schema.TypeBool,
+					Description: descriptionUseCLIServe,
+					Optional:    true,
+				},
 				attributeVaultPath: {
 					Type:        schema.TypeString,
 					Description: descriptionVaultPath,
@@ -89,33 +109,102 @@ func New(version string) func() *schema.Provider {
 					Optional:    true,
 					DefaultFunc: schema.EnvDefaultFunc("NODE_EXTRA_CA_CERTS", nil),
 				},
+				attributeMaxParallel: {
+					Type:             schema.TypeInt,
+					Description:      descriptionMaxParallel,
+					Optional:         true,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+				},
+				attributeCommandTimeout: {
+					Type:             schema.TypeString,
+					Description:      descriptionCommandTimeout,
+					Optional:         true,
+					ValidateDiagFunc: validateDuration,
+				},
+				attributeRetryMaxAttempts: {
+					Type:             schema.TypeInt,
+					Description:      descriptionRetryMaxAttempts,
+					Optional:         true,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+				},
+				attributeRetryMaxDelay: {
+					Type:             schema.TypeString,
+					Description:      descriptionRetryMaxDelay,
+					Optional:         true,
+					ValidateDiagFunc: validateDuration,
+				},
+				attributeOfflineVaultCache: {
+					Type:         schema.TypeBool,
+					Description:  descriptionOfflineVaultCache,
+					Optional:     true,
+					RequiredWith: []string{attributeMasterPassword},
+				},
+				attributeTwoFactorMethod: {
+					Type:             schema.TypeString,
+					Description:      descriptionTwoFactorMethod,
+					Optional:         true,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(twoFactorMethods, false)),
+				},
+				attributeTwoFactorCode: {
+					Type:          schema.TypeString,
+					Description:   descriptionTwoFactorCode,
+					Optional:      true,
+					Sensitive:     true,
+					ConflictsWith: []string{attributeTwoFactorTotpSeed},
+				},
+				attributeTwoFactorTotpSeed: {
+					Type:          schema.TypeString,
+					Description:   descriptionTwoFactorTotpSeed,
+					Optional:      true,
+					Sensitive:     true,
+					ConflictsWith: []string{attributeTwoFactorCode},
+				},
 			},
 			DataSourcesMap: map[string]*schema.Resource{
 				"bitwarden_attachment":       dataSourceAttachment(),
+				"bitwarden_export_file":      dataSourceExportFile(),
 				"bitwarden_folder":           dataSourceFolder(),
 				"bitwarden_item_login":       dataSourceItemLogin(),
 				"bitwarden_item_secure_note": dataSourceItemSecureNote(),
 				"bitwarden_org_collection":   dataSourceOrgCollection(),
 				"bitwarden_organization":     dataSourceOrganization(),
+				"bitwarden_send_content":     dataSourceSendContent(),
 			},
 			ResourcesMap: map[string]*schema.Resource{
-				"bitwarden_attachment":       resourceAttachment(),
-				"bitwarden_folder":           resourceFolder(),
-				"bitwarden_item_login":       resourceItemLogin(),
-				"bitwarden_item_secure_note": resourceItemSecureNote(),
-				"bitwarden_org_collection":   resourceOrgCollection(),
+				"bitwarden_account_master_password": resourceAccountMasterPassword(),
+				"bitwarden_attachment":              resourceAttachment(),
+				"bitwarden_emergency_access":        resourceEmergencyAccess(),
+				"bitwarden_folder":                  resourceFolder(),
+				"bitwarden_item_login":              resourceItemLogin(),
+				"bitwarden_item_secure_note":        resourceItemSecureNote(),
+				"bitwarden_org_collection":          resourceOrgCollection(),
+				"bitwarden_vault_export":            resourceVaultExport(),
+				"bitwarden_vault_import":            resourceVaultImport(),
 			},
 		}
 
+		for _, r := range p.ResourcesMap {
+			withSensitiveValuesRedacted(r)
+		}
+		for _, r := range p.DataSourcesMap {
+			withSensitiveValuesRedacted(r)
+		}
+
 		p.ConfigureContextFunc = providerConfigure(version, p)
 		return p
 	}
 }
 
-func providerConfigure(version string, _ *schema.Provider) func(context.Context, *schema.ResourceData) (interface{}, diag.Diagnostics) {
+func providerConfigure(version string, p *schema.Provider) func(context.Context, *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	return func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+		registerSensitiveValues(d, p.Schema)
 
-		bwClient, err := newBitwardenClient(d, version)
+		bwClient, err := newBitwardenClient(ctx, d, version)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+
+		twoFactor, err := newTwoFactor(d)
 		if err != nil {
 			return nil, diag.FromErr(err)
 		}
@@ -125,16 +214,176 @@ func providerConfigure(version string, _ *schema.Provider) func(context.Context,
 			bwClient.SetSessionKey(sessionKey.(string))
 		}
 
-		err = ensureLoggedIn(ctx, d, bwClient)
+		if d.Get(attributeOfflineVaultCache).(bool) {
+			cachedClient, err := newOfflineVaultCacheClient(ctx, d, bwClient, twoFactor)
+			if err == nil {
+				return newProviderMeta(d, readcache.NewClient(cachedClient), twoFactor), nil
+			}
+			tflog.Warn(ctx, "Unable to use the offline Vault cache, falling back to the CLI", map[string]interface{}{"error": err})
+		}
+
+		err = ensureLoggedIn(ctx, d, bwClient, twoFactor)
 		if err != nil {
 			return nil, diag.FromErr(err)
 		}
 
-		return bwClient, nil
+		return newProviderMeta(d, readcache.NewClient(bwClient), twoFactor), nil
+	}
+}
+
+// providerMeta is handed over to resources and data sources. It embeds the
+// bw.Client most of them rely on, and keeps the provider's credentials for
+// the few which talk to the Bitwarden API directly.
+type providerMeta struct {
+	bw.Client
+
+	apiClient         webapi.Client
+	apiClientPassword string
+	apiClientMu       sync.Mutex
+	clientID          string
+	clientSecret      string
+	email             string
+	extraCACertsPath  string
+	masterPassword    string
+	masterPasswordMu  sync.RWMutex
+	serverURL         string
+	twoFactor         *twoFactor
+}
+
+func newProviderMeta(d *schema.ResourceData, bwClient bw.Client, twoFactor *twoFactor) *providerMeta {
+	return &providerMeta{
+		Client:           bwClient,
+		clientID:         d.Get(attributeClientID).(string),
+		clientSecret:     d.Get(attributeClientSecret).(string),
+		email:            d.Get(attributeEmail).(string),
+		extraCACertsPath: d.Get(attributeExtraCACertsPath).(string),
+		masterPassword:   d.Get(attributeMasterPassword).(string),
+		serverURL:        d.Get(attributeServer).(string),
+		twoFactor:        twoFactor,
+	}
+}
+
+// getMasterPassword returns the master password the provider logs in with,
+// which changes when bitwarden_account_master_password rotates it.
+func (m *providerMeta) getMasterPassword() string {
+	m.masterPasswordMu.RLock()
+	defer m.masterPasswordMu.RUnlock()
+	return m.masterPassword
+}
+
+func (m *providerMeta) setMasterPassword(masterPassword string) {
+	m.masterPasswordMu.Lock()
+	defer m.masterPasswordMu.Unlock()
+	m.masterPassword = masterPassword
+}
+
+// newWebAPIClient returns a client for the Bitwarden API, which is not logged
+// in yet.
+func (m *providerMeta) newWebAPIClient() (webapi.Client, error) {
+	return m.newWebAPIClientForServer(m.serverURL)
+}
+
+// newWebAPIClientForServer returns a client for the API of another server
+// than the provider's, with the same connection settings.
+func (m *providerMeta) newWebAPIClientForServer(serverURL string) (webapi.Client, error) {
+	opts := []webapi.Options{}
+	if len(m.extraCACertsPath) > 0 {
+		opts = append(opts, webapi.WithExtraCACertsPath(m.extraCACertsPath))
+	}
+	return webapi.NewClient(strings.TrimSuffix(serverURL, "/"), opts...)
+}
+
+// newLoggedInWebAPIClient returns a client for the Bitwarden API, logged in as
+// the provider's user with the given master password.
+func (m *providerMeta) newLoggedInWebAPIClient(ctx context.Context, masterPassword string) (webapi.Client, error) {
+	client, err := m.newWebAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.clientID) > 0 && len(m.clientSecret) > 0 {
+		return client, client.LoginWithAPIKey(ctx, m.email, masterPassword, m.clientID, m.clientSecret)
+	}
+
+	prelogin, err := client.PreLogin(ctx, m.email)
+	if err != nil {
+		return nil, err
+	}
+
+	loginOptions, err := m.twoFactor.apiLoginOptions(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return client, m.twoFactor.apiLoginError(client.Login(ctx, m.email, masterPassword, prelogin.KdfIterations, loginOptions...))
 }
 
-func ensureLoggedIn(ctx context.Context, d *schema.ResourceData, bwClient bw.Client) error {
+// sharedWebAPIClient returns a client for the Bitwarden API logged in as the
+// provider's user, which is shared by the resources of a run. It's logged in
+// on first use, and again once the master password changed, as every login
+// can require a new two-step login code.
+func (m *providerMeta) sharedWebAPIClient(ctx context.Context) (webapi.Client, error) {
+	masterPassword := m.getMasterPassword()
+
+	m.apiClientMu.Lock()
+	defer m.apiClientMu.Unlock()
+	if m.apiClient != nil && m.apiClientPassword == masterPassword {
+		return m.apiClient, nil
+	}
+
+	client, err := m.newLoggedInWebAPIClient(ctx, masterPassword)
+	if err != nil {
+		return nil, err
+	}
+	m.apiClient = client
+	m.apiClientPassword = masterPassword
+	return client, nil
+}
+
+// relogin logs the CLI in again, which is needed once the server has
+// invalidated the existing sessions.
+func (m *providerMeta) relogin(ctx context.Context, masterPassword string) error {
+	err := m.Logout(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(m.clientID) > 0 && len(m.clientSecret) > 0 {
+		return m.LoginWithAPIKey(ctx, masterPassword, m.clientID, m.clientSecret)
+	}
+	return loginWithPassword(ctx, m.Client, m.email, masterPassword, m.twoFactor)
+}
+
+// newOfflineVaultCacheClient returns a client serving reads from an encrypted
+// copy of the Vault kept in the Vault's directory. The CLI is only logged in
+// once something can't be served from the cache.
+func newOfflineVaultCacheClient(ctx context.Context, d *schema.ResourceData, bwClient bw.Client, twoFactor *twoFactor) (bw.Client, error) {
+	meta := newProviderMeta(d, bwClient, twoFactor)
+	apiClient, err := meta.newWebAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheDir, err := filepath.Abs(d.Get(attributeVaultPath).(string))
+	if err != nil {
+		return nil, err
+	}
+
+	return vaultcache.NewClient(ctx, bwClient, apiClient, vaultcache.Config{
+		CacheDir:       cacheDir,
+		ServerURL:      strings.TrimSuffix(meta.serverURL, "/"),
+		Email:          meta.email,
+		MasterPassword: meta.getMasterPassword(),
+		ClientID:       meta.clientID,
+		ClientSecret:   meta.clientSecret,
+		LoginOptions:   twoFactor.apiLoginOptions,
+		LoginError:     twoFactor.apiLoginError,
+		EnsureLoggedIn: func(ctx context.Context) error {
+			return ensureLoggedIn(ctx, d, bwClient, twoFactor)
+		},
+	})
+}
+
+func ensureLoggedIn(ctx context.Context, d *schema.ResourceData, bwClient bw.Client, twoFactor *twoFactor) error {
 	status, err := bwClient.Status(ctx)
 	if err != nil {
 		return err
@@ -179,7 +428,7 @@ func ensureLoggedIn(ctx context.Context, d *schema.ResourceData, bwClient bw.Cli
 		return bwClient.LoginWithAPIKey(ctx, masterPassword.(string), clientID.(string), clientSecret.(string))
 	case LoginMethodPassword:
 		email := d.Get(attributeEmail)
-		return bwClient.LoginWithPassword(ctx, email.(string), masterPassword.(string))
+		return loginWithPassword(ctx, bwClient, email.(string), masterPassword.(string), twoFactor)
 	}
 
 	// Scenario 4: We need to login but don't have the information to do so.
@@ -194,6 +443,14 @@ func ensureLoggedIn(ctx context.Context, d *schema.ResourceData, bwClient bw.Cli
 	return fmt.Errorf("INTERNAL BUG: not enough parameters provided to login (status: '%s')", status.Status)
 }
 
+func loginWithPassword(ctx context.Context, bwClient bw.Client, email, masterPassword string, twoFactor *twoFactor) error {
+	loginOptions, err := twoFactor.cliLoginOptions(ctx)
+	if err != nil {
+		return err
+	}
+	return twoFactor.cliLoginError(bwClient.LoginWithPassword(ctx, email, masterPassword, loginOptions...))
+}
+
 func loginMethod(d *schema.ResourceData) LoginMethod {
 	_, hasClientID := d.GetOk(attributeClientID)
 	_, hasClientSecret := d.GetOk(attributeClientSecret)
@@ -231,30 +488,98 @@ func logoutIfIdentityChanged(ctx context.Context, d *schema.ResourceData, bwClie
 	return nil
 }
 
-func newBitwardenClient(d *schema.ResourceData, version string) (bw.Client, error) {
+func newBitwardenClient(ctx context.Context, d *schema.ResourceData, version string) (bw.Client, error) {
 	opts := []bw.Options{}
+	serveCfg := bwserve.Config{}
 	if vaultPath, exists := d.GetOk(attributeVaultPath); exists {
 		abs, err := filepath.Abs(vaultPath.(string))
 		if err != nil {
 			return nil, err
 		}
 		opts = append(opts, bw.WithAppDataDir(abs))
+		serveCfg.AppDataDir = abs
 	}
 
 	if extraCACertsPath, exists := d.GetOk(attributeExtraCACertsPath); exists {
 		opts = append(opts, bw.WithExtraCACertsPath(extraCACertsPath.(string)))
+		serveCfg.ExtraCACertsPath = extraCACertsPath.(string)
+	}
+
+	if maxParallelCommands, exists := d.GetOk(attributeMaxParallel); exists {
+		opts = append(opts, bw.WithMaxParallelCommands(maxParallelCommands.(int)))
+	}
+
+	var commandTimeout time.Duration
+	if v, exists := d.GetOk(attributeCommandTimeout); exists {
+		timeout, err := time.ParseDuration(v.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s': %w", attributeCommandTimeout, err)
+		}
+		commandTimeout = timeout
+		opts = append(opts, bw.WithCommandTimeout(timeout))
+		serveCfg.CommandTimeout = timeout
 	}
 
+	retryMaxAttempts := 0
+	if v, exists := d.GetOk(attributeRetryMaxAttempts); exists {
+		retryMaxAttempts = v.(int)
+		opts = append(opts, bw.WithRetryMaxAttempts(retryMaxAttempts))
+	}
+
+	var retryMaxDelay time.Duration
+	if v, exists := d.GetOk(attributeRetryMaxDelay); exists {
+		maxDelay, err := time.ParseDuration(v.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s': %w", attributeRetryMaxDelay, err)
+		}
+		retryMaxDelay = maxDelay
+		opts = append(opts, bw.WithRetryMaxDelay(maxDelay))
+	}
+
+	disableRetryBackoff := false
 	if version == versionDev {
 		// During development, we disable Vault synchronization and retry backoffs to make some
 		// operations faster.
 		opts = append(opts, bw.DisableSync())
 		opts = append(opts, bw.DisableRetryBackoff())
+		disableRetryBackoff = true
 	}
-	bwExecutable, err := exec.LookPath("bw")
+	serveCfg.RetryHandler = bw.NewRetryHandler(retryMaxAttempts, retryMaxDelay, disableRetryBackoff)
+	bwExecutable, err := exec.LookPath(d.Get(attributeCLIPath).(string))
 	if err != nil {
 		return nil, err
 	}
 
-	return bw.NewClient(bwExecutable, opts...), nil
+	cliVersion, err := bw.DetectVersion(ctx, bwExecutable, commandTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error detecting the version of the Bitwarden CLI at '%s': %w", bwExecutable, err)
+	}
+	err = bw.CheckVersion(cliVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%w, please upgrade '%s'", err, bwExecutable)
+	}
+	tflog.Info(ctx, "Using the Bitwarden CLI", map[string]interface{}{"path": bwExecutable, "version": cliVersion.String()})
+	opts = append(opts, bw.WithVersion(cliVersion))
+
+	bwClient := bw.NewClient(bwExecutable, opts...)
+	if d.Get(attributeUseCLIServe).(bool) {
+		err := bw.CheckCapability(bwClient.GetVersion(), bw.CapabilityServe)
+		if err != nil {
+			return nil, fmt.Errorf("%w, please upgrade '%s' or disable '%s'", err, bwExecutable, attributeUseCLIServe)
+		}
+		serveCfg.ExecPath = bwExecutable
+		return bwserve.NewClient(bwClient, serveCfg), nil
+	}
+	return bwClient, nil
+}
+
+func validateDuration(v interface{}, path cty.Path) diag.Diagnostics {
+	timeout, err := time.ParseDuration(v.(string))
+	if err != nil {
+		return diag.Diagnostics{{Severity: diag.Error, Summary: fmt.Sprintf("invalid duration: %s", err), AttributePath: path}}
+	}
+	if timeout <= 0 {
+		return diag.Diagnostics{{Severity: diag.Error, Summary: "duration must be positive", AttributePath: path}}
+	}
+	return nil
 }
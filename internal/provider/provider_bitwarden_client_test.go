@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
@@ -11,6 +12,7 @@ import (
 
 func TestProviderReauthenticateWithPasswordIfAuthenticatedOnDifferentServer(t *testing.T) {
 	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"--version":                       "2024.2.0",
 		"status":                          `{"serverURL": "http://127.0.0.99/", "userEmail": "test@laverse.net", "status": "unlocked"}`,
 		"logout":                          ``,
 		"config server http://127.0.0.1/": ``,
@@ -19,6 +21,7 @@ func TestProviderReauthenticateWithPasswordIfAuthenticatedOnDifferentServer(t *t
 	defer removeMocks(t)
 
 	providerConfiguration := map[string]interface{}{
+		"vault_path":      t.TempDir(),
 		"server":          "http://127.0.0.1/",
 		"email":           "test@laverse.net",
 		"master_password": "master-password-9",
@@ -31,6 +34,7 @@ func TestProviderReauthenticateWithPasswordIfAuthenticatedOnDifferentServer(t *t
 	}
 
 	assert.Equal(t, []string{
+		"--version",
 		"status",
 		"logout",
 		"config server http://127.0.0.1/",
@@ -38,15 +42,37 @@ func TestProviderReauthenticateWithPasswordIfAuthenticatedOnDifferentServer(t *t
 	}, commandsExecuted())
 }
 
+// The cassette is synthetic, as its comment says, until it's recorded with a
+// real CLI.
+func TestProviderReauthenticateOnDifferentServerFromCassette(t *testing.T) {
+	removeCassette := test_command.UseCassette(t, "fixtures/cassettes/login_on_different_server.json")
+	defer removeCassette(t)
+
+	providerConfiguration := map[string]interface{}{
+		"vault_path":      t.TempDir(),
+		"server":          "http://127.0.0.1/",
+		"email":           "test@laverse.net",
+		"master_password": "master-password-9",
+	}
+
+	diag := New(versionDev)().Configure(context.Background(), terraform.NewResourceConfigRaw(providerConfiguration))
+
+	if !assert.False(t, diag.HasError()) {
+		t.Fatalf("unexpected error: %v", diag[0])
+	}
+}
+
 func TestProviderReauthenticateWithPasswordIfAuthenticatedWithDifferentUser(t *testing.T) {
 	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
-		"status": `{"serverURL": "http://127.0.0.1/", "userEmail": "as-an-other-user@laverse.net", "status": "unlocked"}`,
-		"logout": ``,
+		"--version": "2024.2.0",
+		"status":    `{"serverURL": "http://127.0.0.1/", "userEmail": "as-an-other-user@laverse.net", "status": "unlocked"}`,
+		"logout":    ``,
 		"login test@laverse.net --raw --passwordenv BW_PASSWORD": `session-key1234`,
 	})
 	defer removeMocks(t)
 
 	providerConfiguration := map[string]interface{}{
+		"vault_path":      t.TempDir(),
 		"server":          "http://127.0.0.1/",
 		"email":           "test@laverse.net",
 		"master_password": "master-password-9",
@@ -59,6 +85,7 @@ func TestProviderReauthenticateWithPasswordIfAuthenticatedWithDifferentUser(t *t
 	}
 
 	assert.Equal(t, []string{
+		"--version",
 		"status",
 		"logout",
 		"login test@laverse.net --raw --passwordenv BW_PASSWORD",
@@ -67,12 +94,14 @@ func TestProviderReauthenticateWithPasswordIfAuthenticatedWithDifferentUser(t *t
 
 func TestProviderDoesntLogoutFirstIfUnauthenticated(t *testing.T) {
 	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
-		"status": `{"serverURL": "http://127.0.0.1/", "userEmail": "as-an-other-user@laverse.net", "status": "unauthenticated"}`,
+		"--version": "2024.2.0",
+		"status":    `{"serverURL": "http://127.0.0.1/", "userEmail": "as-an-other-user@laverse.net", "status": "unauthenticated"}`,
 		"login test@laverse.net --raw --passwordenv BW_PASSWORD": `session-key1234`,
 	})
 	defer removeMocks(t)
 
 	providerConfiguration := map[string]interface{}{
+		"vault_path":      t.TempDir(),
 		"server":          "http://127.0.0.1/",
 		"email":           "test@laverse.net",
 		"master_password": "master-password-9",
@@ -85,6 +114,7 @@ func TestProviderDoesntLogoutFirstIfUnauthenticated(t *testing.T) {
 	}
 
 	assert.Equal(t, []string{
+		"--version",
 		"status",
 		"login test@laverse.net --raw --passwordenv BW_PASSWORD",
 	}, commandsExecuted())
@@ -92,6 +122,7 @@ func TestProviderDoesntLogoutFirstIfUnauthenticated(t *testing.T) {
 
 func TestProviderReauthenticateWithAPIIfAuthenticatedWithDifferentUser(t *testing.T) {
 	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"--version":                              "2024.2.0",
 		"status":                                 `{"serverURL": "http://127.0.0.1/", "userEmail": "as-an-other-user@laverse.net", "status": "unlocked"}`,
 		"logout":                                 ``,
 		"login --apikey":                         ``,
@@ -101,6 +132,7 @@ func TestProviderReauthenticateWithAPIIfAuthenticatedWithDifferentUser(t *testin
 	defer removeMocks(t)
 
 	providerConfiguration := map[string]interface{}{
+		"vault_path":      t.TempDir(),
 		"server":          "http://127.0.0.1/",
 		"email":           "test@laverse.net",
 		"client_id":       "client-id-1234",
@@ -115,6 +147,7 @@ func TestProviderReauthenticateWithAPIIfAuthenticatedWithDifferentUser(t *testin
 	}
 
 	assert.Equal(t, []string{
+		"--version",
 		"status",
 		"logout",
 		"login --apikey",
@@ -124,12 +157,14 @@ func TestProviderReauthenticateWithAPIIfAuthenticatedWithDifferentUser(t *testin
 
 func TestProviderWithSessionKeySync(t *testing.T) {
 	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
-		"status": `{"serverURL": "http://127.0.0.1/", "userEmail": "test@laverse.net", "status": "unlocked"}`,
-		"sync":   ``,
+		"--version": "2024.2.0",
+		"status":    `{"serverURL": "http://127.0.0.1/", "userEmail": "test@laverse.net", "status": "unlocked"}`,
+		"sync":      ``,
 	})
 	defer removeMocks(t)
 
 	raw := map[string]interface{}{
+		"vault_path":  t.TempDir(),
 		"server":      "http://127.0.0.1/",
 		"email":       "test@laverse.net",
 		"session_key": "abcd1234",
@@ -143,6 +178,7 @@ func TestProviderWithSessionKeySync(t *testing.T) {
 	}
 
 	assert.Equal(t, []string{
+		"--version",
 		"status",
 		"sync",
 	}, commandsExecuted())
@@ -150,11 +186,13 @@ func TestProviderWithSessionKeySync(t *testing.T) {
 
 func TestProviderRetryOnRateLimitExceeded(t *testing.T) {
 	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"--version":     "2024.2.0",
 		"status @error": `Rate limit exceeded. Try again later.`,
 	})
 	defer removeMocks(t)
 
 	raw := map[string]interface{}{
+		"vault_path":  t.TempDir(),
 		"server":      "http://127.0.0.1/",
 		"email":       "test@laverse.net",
 		"session_key": "abcd1234",
@@ -165,6 +203,7 @@ func TestProviderRetryOnRateLimitExceeded(t *testing.T) {
 	if assert.True(t, diag.HasError()) {
 		assert.Equal(t, diag[0].Summary, "failing command 'status' for test purposes: Rate limit exceeded. Try again later.")
 		assert.Equal(t, []string{
+			"--version",
 			"status",
 			"status",
 			"status",
@@ -172,13 +211,40 @@ func TestProviderRetryOnRateLimitExceeded(t *testing.T) {
 	}
 }
 
+func TestProviderRetryMaxAttempts(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"--version":     "2024.2.0",
+		"status @error": `Rate limit exceeded. Try again later.`,
+	})
+	defer removeMocks(t)
+
+	raw := map[string]interface{}{
+		"vault_path":         t.TempDir(),
+		"server":             "http://127.0.0.1/",
+		"email":              "test@laverse.net",
+		"session_key":        "abcd1234",
+		"retry_max_attempts": 1,
+	}
+
+	diag := New(versionDev)().Configure(context.Background(), terraform.NewResourceConfigRaw(raw))
+
+	if assert.True(t, diag.HasError()) {
+		assert.Equal(t, []string{
+			"--version",
+			"status",
+		}, commandsExecuted())
+	}
+}
+
 func TestProviderReturnUnhandledError(t *testing.T) {
 	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"--version":     "2024.2.0",
 		"status @error": `Something unknown and bad happened.`,
 	})
 	defer removeMocks(t)
 
 	raw := map[string]interface{}{
+		"vault_path":  t.TempDir(),
 		"server":      "http://127.0.0.1/",
 		"email":       "test@laverse.net",
 		"session_key": "abcd1234",
@@ -189,7 +255,102 @@ func TestProviderReturnUnhandledError(t *testing.T) {
 	if assert.True(t, diag.HasError()) {
 		assert.Equal(t, diag[0].Summary, "failing command 'status' for test purposes: Something unknown and bad happened.")
 		assert.Equal(t, []string{
+			"--version",
 			"status",
 		}, commandsExecuted())
 	}
 }
+
+func TestProviderLoginWithTwoFactorCode(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"--version": "2024.2.0",
+		"status":    `{"serverURL": "http://127.0.0.1/", "userEmail": "test@laverse.net", "status": "unauthenticated"}`,
+		"login test@laverse.net --raw --passwordenv BW_PASSWORD --method 1 --code 123456": `session-key1234`,
+	})
+	defer removeMocks(t)
+
+	providerConfiguration := map[string]interface{}{
+		"vault_path":        t.TempDir(),
+		"server":            "http://127.0.0.1/",
+		"email":             "test@laverse.net",
+		"master_password":   "master-password-9",
+		"two_factor_method": "email",
+		"two_factor_code":   "123456",
+	}
+
+	diag := New(versionDev)().Configure(context.Background(), terraform.NewResourceConfigRaw(providerConfiguration))
+
+	if !assert.False(t, diag.HasError()) {
+		t.Fatalf("unexpected error: %v", diag[0])
+	}
+
+	assert.Equal(t, []string{
+		"--version",
+		"status",
+		"login test@laverse.net --raw --passwordenv BW_PASSWORD --method 1 --code 123456",
+	}, commandsExecuted())
+}
+
+func TestProviderUnsupportedCLIVersion(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"--version": "1.11.1",
+	})
+	defer removeMocks(t)
+
+	raw := map[string]interface{}{
+		"vault_path":  t.TempDir(),
+		"server":      "http://127.0.0.1/",
+		"email":       "test@laverse.net",
+		"session_key": "abcd1234",
+	}
+
+	diag := New(versionDev)().Configure(context.Background(), terraform.NewResourceConfigRaw(raw))
+
+	if assert.True(t, diag.HasError()) {
+		assert.Contains(t, diag[0].Summary, "Bitwarden CLI 1.11.1 isn't supported, the minimum version is 1.12.0")
+		assert.Equal(t, []string{
+			"--version",
+		}, commandsExecuted())
+	}
+}
+
+func TestProviderCLIServeUnsupportedByCLIVersion(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"--version": "1.21.0",
+	})
+	defer removeMocks(t)
+
+	raw := map[string]interface{}{
+		"vault_path":    t.TempDir(),
+		"server":        "http://127.0.0.1/",
+		"email":         "test@laverse.net",
+		"session_key":   "abcd1234",
+		"use_cli_serve": true,
+	}
+
+	diag := New(versionDev)().Configure(context.Background(), terraform.NewResourceConfigRaw(raw))
+
+	if assert.True(t, diag.HasError()) {
+		assert.Contains(t, diag[0].Summary, "Bitwarden CLI 1.21.0 doesn't support 'bw serve', which requires version 1.22.0 or later")
+		assert.Contains(t, diag[0].Summary, "or disable 'use_cli_serve'")
+		assert.Equal(t, []string{
+			"--version",
+		}, commandsExecuted())
+	}
+}
+
+func TestProviderCLIPath(t *testing.T) {
+	raw := map[string]interface{}{
+		"vault_path":  t.TempDir(),
+		"server":      "http://127.0.0.1/",
+		"email":       "test@laverse.net",
+		"session_key": "abcd1234",
+		"cli_path":    filepath.Join(t.TempDir(), "missing-bw"),
+	}
+
+	diag := New(versionDev)().Configure(context.Background(), terraform.NewResourceConfigRaw(raw))
+
+	if assert.True(t, diag.HasError()) {
+		assert.Contains(t, diag[0].Summary, "missing-bw")
+	}
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+)
+
+type operationFunc interface {
+	~func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+}
+
+// withSensitiveValuesRedacted keeps the values of the resource's sensitive
+// attributes out of the logs and errors of commands. They're registered
+// before every operation, and after it for values read from the Vault.
+func withSensitiveValuesRedacted(r *schema.Resource) *schema.Resource {
+	r.CreateContext = registerAroundOperation(r.CreateContext, r.Schema)
+	r.ReadContext = registerAroundOperation(r.ReadContext, r.Schema)
+	r.UpdateContext = registerAroundOperation(r.UpdateContext, r.Schema)
+	r.DeleteContext = registerAroundOperation(r.DeleteContext, r.Schema)
+	return r
+}
+
+func registerAroundOperation[F operationFunc](operation F, schemaMap map[string]*schema.Schema) F {
+	if operation == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		registerSensitiveValues(d, schemaMap)
+		defer registerSensitiveValues(d, schemaMap)
+		return operation(ctx, d, meta)
+	}
+}
+
+func registerSensitiveValues(d *schema.ResourceData, schemaMap map[string]*schema.Schema) {
+	for key, s := range schemaMap {
+		registerSensitiveValue(s, d.Get(key), false)
+	}
+}
+
+// registerSensitiveValue registers the strings of sensitive attributes, and
+// of the blocks they're nested in.
+func registerSensitiveValue(s *schema.Schema, v interface{}, sensitive bool) {
+	sensitive = sensitive || s.Sensitive
+
+	switch v := v.(type) {
+	case string:
+		if sensitive {
+			command.RegisterSecret(v)
+		}
+	case *schema.Set:
+		registerSensitiveElems(s, v.List(), sensitive)
+	case []interface{}:
+		registerSensitiveElems(s, v, sensitive)
+	case map[string]interface{}:
+		for _, elem := range v {
+			if str, ok := elem.(string); ok && sensitive {
+				command.RegisterSecret(str)
+			}
+		}
+	}
+}
+
+func registerSensitiveElems(s *schema.Schema, elems []interface{}, sensitive bool) {
+	for _, elem := range elems {
+		switch elemSchema := s.Elem.(type) {
+		case *schema.Schema:
+			registerSensitiveValue(elemSchema, elem, sensitive)
+		case *schema.Resource:
+			block, ok := elem.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for key, nested := range elemSchema.Schema {
+				registerSensitiveValue(nested, block[key], sensitive)
+			}
+		}
+	}
+}
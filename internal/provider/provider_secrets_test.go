@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/command"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterSensitiveValuesOfItemLogin(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceItemLogin().Schema, map[string]interface{}{
+		attributeName:          "registered-name",
+		attributeLoginPassword: "registered-password",
+	})
+
+	registerSensitiveValues(d, resourceItemLogin().Schema)
+
+	assert.Equal(t, "<redacted> registered-name", command.Redact("registered-password registered-name"))
+}
+
+func TestRegisterSensitiveValuesNested(t *testing.T) {
+	schemaMap := map[string]*schema.Schema{
+		"block": {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"public": {Type: schema.TypeString, Optional: true},
+					"secret": {Type: schema.TypeString, Optional: true, Sensitive: true},
+				},
+			},
+		},
+		"secrets": {
+			Type:      schema.TypeSet,
+			Optional:  true,
+			Sensitive: true,
+			Elem:      &schema.Schema{Type: schema.TypeString},
+		},
+	}
+
+	d := schema.TestResourceDataRaw(t, schemaMap, map[string]interface{}{
+		"block":   []interface{}{map[string]interface{}{"public": "nested-public", "secret": "nested-secret"}},
+		"secrets": []interface{}{"set-secret"},
+	})
+
+	registerSensitiveValues(d, schemaMap)
+
+	assert.Equal(t, "nested-public <redacted> <redacted>", command.Redact("nested-public nested-secret set-secret"))
+}
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/totp"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+)
+
+const (
+	twoFactorMethodAuthenticator = "authenticator"
+	twoFactorMethodEmail         = "email"
+	twoFactorMethodYubiKey       = "yubikey"
+)
+
+var (
+	twoFactorMethods = []string{twoFactorMethodAuthenticator, twoFactorMethodEmail, twoFactorMethodYubiKey}
+
+	twoFactorCLIMethods = map[string]bw.TwoFactorMethod{
+		twoFactorMethodAuthenticator: bw.TwoFactorMethodAuthenticator,
+		twoFactorMethodEmail:         bw.TwoFactorMethodEmail,
+		twoFactorMethodYubiKey:       bw.TwoFactorMethodYubiKey,
+	}
+
+	twoFactorAPIProviders = map[string]webapi.TwoFactorProvider{
+		twoFactorMethodAuthenticator: webapi.TwoFactorProviderAuthenticator,
+		twoFactorMethodEmail:         webapi.TwoFactorProviderEmail,
+		twoFactorMethodYubiKey:       webapi.TwoFactorProviderYubiKey,
+	}
+)
+
+// twoFactor provides the second factor of accounts with two-step login. A
+// single instance is shared by all the logins of a run, as servers refuse
+// TOTP codes which were already used.
+type twoFactor struct {
+	method    string
+	code      string
+	generator *totp.Generator
+
+	lastStep int64
+	mu       sync.Mutex
+}
+
+// newTwoFactor returns nil if two-step login isn't configured.
+func newTwoFactor(d *schema.ResourceData) (*twoFactor, error) {
+	method := d.Get(attributeTwoFactorMethod).(string)
+	code := d.Get(attributeTwoFactorCode).(string)
+	seed := d.Get(attributeTwoFactorTotpSeed).(string)
+
+	if len(seed) > 0 && len(method) == 0 {
+		method = twoFactorMethodAuthenticator
+	}
+	if len(method) == 0 {
+		return nil, nil
+	}
+
+	tf := &twoFactor{method: method, code: code}
+	if len(seed) > 0 {
+		if method != twoFactorMethodAuthenticator {
+			return nil, fmt.Errorf("'%s' can only be used with the '%s' two-step login method", attributeTwoFactorTotpSeed, twoFactorMethodAuthenticator)
+		}
+
+		generator, err := totp.NewGenerator(seed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s': %w", attributeTwoFactorTotpSeed, err)
+		}
+		tf.generator = generator
+	} else if len(code) == 0 {
+		return nil, fmt.Errorf("'%s' or '%s' is required with '%s'", attributeTwoFactorCode, attributeTwoFactorTotpSeed, attributeTwoFactorMethod)
+	}
+	return tf, nil
+}
+
+func (tf *twoFactor) cliLoginOptions(ctx context.Context) ([]bw.LoginOption, error) {
+	if tf == nil {
+		return nil, nil
+	}
+
+	code, err := tf.nextCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []bw.LoginOption{bw.WithTwoFactor(twoFactorCLIMethods[tf.method], code)}, nil
+}
+
+func (tf *twoFactor) apiLoginOptions(ctx context.Context) ([]webapi.LoginOption, error) {
+	if tf == nil {
+		return nil, nil
+	}
+
+	code, err := tf.nextCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []webapi.LoginOption{webapi.WithTwoFactor(twoFactorAPIProviders[tf.method], code)}, nil
+}
+
+// nextCode returns the configured code, or computes one from the TOTP seed.
+// In the latter case, it waits for the next time step if the current code
+// was already used.
+func (tf *twoFactor) nextCode(ctx context.Context) (string, error) {
+	if tf.generator == nil {
+		return tf.code, nil
+	}
+
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+	for {
+		now := time.Now()
+		if step := tf.generator.Step(now); step > tf.lastStep {
+			tf.lastStep = step
+			return tf.generator.Code(now), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Until(tf.generator.NextStep(now))):
+		}
+	}
+}
+
+// apiLoginError explains why a login to the API failed, if the server
+// required a second factor the provider couldn't give.
+func (tf *twoFactor) apiLoginError(err error) error {
+	if !webapi.IsTwoFactorRequired(err) {
+		return err
+	}
+
+	var apiErr *webapi.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	offered := []string{}
+	supported := []string{}
+	for _, provider := range apiErr.TwoFactorProviders {
+		offered = append(offered, provider.String())
+		for _, method := range twoFactorMethods {
+			if twoFactorAPIProviders[method] == provider {
+				supported = append(supported, method)
+			}
+		}
+	}
+
+	switch {
+	case len(supported) == 0:
+		return fmt.Errorf("the account requires two-step login with %s, which the provider doesn't support (supported: %s)", strings.Join(offered, ", "), strings.Join(twoFactorMethods, ", "))
+	case tf == nil:
+		return fmt.Errorf("the account requires two-step login, set '%s' to one of: %s", attributeTwoFactorMethod, strings.Join(supported, ", "))
+	case !slices.Contains(supported, tf.method):
+		return fmt.Errorf("two-step login method '%s' isn't enabled for the account, use one of: %s", tf.method, strings.Join(supported, ", "))
+	}
+	return fmt.Errorf("two-step login with '%s' failed: %w", tf.method, err)
+}
+
+// cliLoginError explains why the CLI failed to log in, if it asked for a
+// second factor.
+func (tf *twoFactor) cliLoginError(err error) error {
+	if tf != nil || err == nil {
+		return err
+	}
+
+	// Messages of the CLI when it needs to prompt for a second factor, but
+	// isn't allowed to.
+	for _, msg := range []string{"--method <method> required", "Code is required"} {
+		if strings.Contains(err.Error(), msg) {
+			return fmt.Errorf("the account requires two-step login, set '%s' to one of: %s: %w", attributeTwoFactorMethod, strings.Join(twoFactorMethods, ", "), err)
+		}
+	}
+	return err
+}
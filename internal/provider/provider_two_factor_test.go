@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/totp"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoFactorAPILoginError(t *testing.T) {
+	twoFactorRequired := func(providers ...webapi.TwoFactorProvider) error {
+		return fmt.Errorf("error logging in: %w", &webapi.APIError{StatusCode: 400, TwoFactorProviders: providers})
+	}
+
+	tests := map[string]struct {
+		twoFactor *twoFactor
+		err       error
+		expected  string
+	}{
+		"unsupported": {
+			err:      twoFactorRequired(webapi.TwoFactorProviderDuo, webapi.TwoFactorProviderWebAuthn),
+			expected: "the account requires two-step login with duo, webauthn, which the provider doesn't support (supported: authenticator, email, yubikey)",
+		},
+		"not-configured": {
+			err:      twoFactorRequired(webapi.TwoFactorProviderAuthenticator, webapi.TwoFactorProviderDuo),
+			expected: "the account requires two-step login, set 'two_factor_method' to one of: authenticator",
+		},
+		"not-enabled": {
+			twoFactor: &twoFactor{method: twoFactorMethodYubiKey, code: "123456"},
+			err:       twoFactorRequired(webapi.TwoFactorProviderEmail),
+			expected:  "two-step login method 'yubikey' isn't enabled for the account, use one of: email",
+		},
+		"wrong-code": {
+			twoFactor: &twoFactor{method: twoFactorMethodEmail, code: "123456"},
+			err:       twoFactorRequired(webapi.TwoFactorProviderEmail),
+			expected:  "two-step login with 'email' failed: error logging in: bad status code for  call: 400",
+		},
+		"other-error": {
+			err:      fmt.Errorf("connection refused"),
+			expected: "connection refused",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.EqualError(t, tc.twoFactor.apiLoginError(tc.err), tc.expected)
+		})
+	}
+}
+
+func TestTwoFactorNextCodeIsNeverReused(t *testing.T) {
+	generator, err := totp.NewGenerator("otpauth://totp/test?secret=GEZDGNBVGY3TQOJQ&period=1")
+	assert.NoError(t, err)
+
+	tf := &twoFactor{method: twoFactorMethodAuthenticator, generator: generator}
+	first, err := tf.nextCode(context.Background())
+	assert.NoError(t, err)
+	firstStep := tf.lastStep
+
+	second, err := tf.nextCode(context.Background())
+	assert.NoError(t, err)
+	assert.Greater(t, tf.lastStep, firstStep)
+	assert.Len(t, second, len(first))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	tf.lastStep = generator.Step(time.Now().Add(time.Minute))
+	_, err = tf.nextCode(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
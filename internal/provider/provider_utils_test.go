@@ -86,10 +86,14 @@ func ensureVaultwardenHasUser(t *testing.T) {
 
 	clearTestVault(t)
 
-	webapiClient := webapi.NewClient(testServerURL)
+	webapiClient, err := webapi.NewClient(testServerURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	testUsername = fmt.Sprintf("test-%s", testUniqueIdentifier)
 	testEmail = fmt.Sprintf("test-%s@laverse.net", testUniqueIdentifier)
-	err := webapiClient.RegisterUser(testUsername, testEmail, testPassword, kdfIterations)
+	err = webapiClient.RegisterUser(context.Background(), testUsername, testEmail, testPassword, kdfIterations)
 	if err != nil && !strings.Contains(strings.ToLower(err.Error()), "user already exists") {
 		t.Fatal(err)
 	}
@@ -104,21 +108,25 @@ func clearTestVault(t *testing.T) {
 }
 
 func createTestOrganization(t *testing.T) {
-	webapiClient := webapi.NewClient(testServerURL)
-	err := webapiClient.Login(testEmail, testPassword, kdfIterations)
+	webapiClient, err := webapi.NewClient(testServerURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = webapiClient.Login(context.Background(), testEmail, testPassword, kdfIterations)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	organizationName := fmt.Sprintf("org-%s", testUniqueIdentifier)
 	organizationLabel := fmt.Sprintf("coll-%s", testUniqueIdentifier)
-	testOrganizationID, err = webapiClient.CreateOrganization(organizationName, organizationLabel, testEmail)
+	testOrganizationID, err = webapiClient.CreateOrganization(context.Background(), organizationName, organizationLabel, testEmail)
 	if err != nil {
 		t.Fatal(err)
 	}
 	t.Logf("Created Organization '%s' (%s)", organizationName, testOrganizationID)
 
-	testCollectionID, err = webapiClient.GetCollections(testOrganizationID)
+	testCollectionID, err = webapiClient.GetCollections(context.Background(), testOrganizationID)
 	if err != nil {
 		t.Fatal(err)
 	}
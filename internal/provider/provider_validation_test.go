@@ -126,3 +126,29 @@ func TestProviderAuthAllMethodsMissingServerNoError(t *testing.T) {
 
 	assert.False(t, diag.HasError())
 }
+
+func TestProviderCommandTimeoutValid(t *testing.T) {
+	raw := map[string]interface{}{
+		"email":           "test@laverse.net",
+		"master_password": "master-password-9",
+		"command_timeout": "2m30s",
+	}
+
+	diag := New(versionDev)().Validate(terraform.NewResourceConfigRaw(raw))
+
+	assert.False(t, diag.HasError())
+}
+
+func TestProviderCommandTimeoutInvalidThrowsError(t *testing.T) {
+	for _, timeout := range []string{"2 minutes", "-1s", "0s"} {
+		raw := map[string]interface{}{
+			"email":           "test@laverse.net",
+			"master_password": "master-password-9",
+			"command_timeout": timeout,
+		}
+
+		diag := New(versionDev)().Validate(terraform.NewResourceConfigRaw(raw))
+
+		assert.True(t, diag.HasError(), timeout)
+	}
+}
@@ -2,12 +2,32 @@ package provider
 
 import (
 	"context"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
 )
 
+const (
+	// defaultResourceTimeout is the SDK's default for every operation.
+	defaultResourceTimeout = 20 * time.Minute
+)
+
+// resourceTimeouts makes the time allowed for each operation configurable.
+// CLI commands still running when it's reached are killed.
+func resourceTimeouts(withUpdate bool) *schema.ResourceTimeout {
+	timeouts := &schema.ResourceTimeout{
+		Create: schema.DefaultTimeout(defaultResourceTimeout),
+		Read:   schema.DefaultTimeout(defaultResourceTimeout),
+		Delete: schema.DefaultTimeout(defaultResourceTimeout),
+	}
+	if withUpdate {
+		timeouts.Update = schema.DefaultTimeout(defaultResourceTimeout)
+	}
+	return timeouts
+}
+
 func createResource(attrObject bw.ObjectType, attrType bw.ItemType) schema.CreateContextFunc {
 	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 		err := d.Set(attributeObject, attrObject)
@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+)
+
+func resourceAccountMasterPassword() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manages the master password and KDF settings of the account the provider is authenticated with. " +
+			"Changing them logs out all the existing sessions of the account. " +
+			"The provider uses the new master password for the rest of the run, but its own `master_password` must be updated before the next one, or unlocking the Vault fails. " +
+			"Only accounts using PBKDF2 are supported. " +
+			"Destroying this resource doesn't change anything on the account.",
+
+		CreateContext: accountMasterPasswordCreate,
+		ReadContext:   accountMasterPasswordRead,
+		UpdateContext: accountMasterPasswordUpdate,
+		DeleteContext: accountMasterPasswordDelete,
+		Timeouts:      resourceTimeouts(true),
+
+		Schema: map[string]*schema.Schema{
+			attributeID: {
+				Description: descriptionIdentifier,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			attributeMasterPassword: {
+				Description: descriptionAccountMasterPassword,
+				Type:        schema.TypeString,
+				Required:    true,
+				Sensitive:   true,
+			},
+			attributeKdfIterations: {
+				Description:      descriptionKdfIterations,
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Computed:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(5000)),
+			},
+		},
+	}
+}
+
+func accountMasterPasswordCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	m := meta.(*providerMeta)
+	masterPassword := m.getMasterPassword()
+	if len(masterPassword) == 0 {
+		return diag.Errorf("the provider's '%s' is required to change it", attributeMasterPassword)
+	}
+
+	err := changeAccountMasterPassword(ctx, d, m, masterPassword)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(m.email)
+	return accountMasterPasswordRead(ctx, d, meta)
+}
+
+func accountMasterPasswordRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	m := meta.(*providerMeta)
+
+	// The master password itself can't be read back, only the KDF settings
+	// can be checked for drift.
+	client, err := m.newWebAPIClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	prelogin, err := client.PreLogin(ctx, m.email)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(d.Set(attributeKdfIterations, prelogin.KdfIterations))
+}
+
+func accountMasterPasswordUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if !d.HasChanges(attributeMasterPassword, attributeKdfIterations) {
+		return accountMasterPasswordRead(ctx, d, meta)
+	}
+
+	currentPassword, _ := d.GetChange(attributeMasterPassword)
+	err := changeAccountMasterPassword(ctx, d, meta.(*providerMeta), currentPassword.(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return accountMasterPasswordRead(ctx, d, meta)
+}
+
+func accountMasterPasswordDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+func changeAccountMasterPassword(ctx context.Context, d *schema.ResourceData, m *providerMeta, currentPassword string) error {
+	apiClient, err := m.newWebAPIClient()
+	if err != nil {
+		return err
+	}
+
+	prelogin, err := apiClient.PreLogin(ctx, m.email)
+	if err != nil {
+		return err
+	}
+
+	// Keys are derived with PBKDF2 for the new master password, which would
+	// silently switch Argon2id accounts to another KDF.
+	if prelogin.Kdf != keybuilder.PBKDF2_SHA256 {
+		return fmt.Errorf("changing the master password of accounts not using PBKDF2 isn't supported (KDF type: %d)", prelogin.Kdf)
+	}
+
+	client, err := m.newLoggedInWebAPIClient(ctx, currentPassword)
+	if err != nil {
+		return fmt.Errorf("error logging in with current master password: %w", err)
+	}
+
+	kdfIterations, hasKdfIterations := d.GetOk(attributeKdfIterations)
+	if !hasKdfIterations {
+		kdfIterations = prelogin.KdfIterations
+	}
+
+	newPassword := d.Get(attributeMasterPassword).(string)
+	err = client.ChangeMasterPassword(ctx, m.email, currentPassword, newPassword, kdfIterations.(int))
+	if err != nil {
+		return err
+	}
+
+	// The API logins coming next need the new master password, and the
+	// CLI's session was invalidated by the change.
+	m.setMasterPassword(newPassword)
+	return m.relogin(ctx, newPassword)
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccResourceAccountMasterPassword(t *testing.T) {
+	// Changing the master password invalidates all the sessions of the user,
+	// which is why this test uses an account and a Vault of its own.
+	email := fmt.Sprintf("test-master-password-%s@laverse.net", testUniqueIdentifier)
+	webapiClient, err := webapi.NewClient(testServerURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = webapiClient.RegisterUser(context.Background(), "test-master-password", email, testPassword, kdfIterations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resourceName := "bitwarden_account_master_password.foo"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: tfConfigProviderForEmail(email, testPassword) + tfConfigResourceAccountMasterPassword("new-password-1234", 0),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, attributeID, email),
+					resource.TestCheckResourceAttr(resourceName, attributeKdfIterations, fmt.Sprintf("%d", kdfIterations)),
+				),
+			},
+			{
+				Config: tfConfigProviderForEmail(email, "new-password-1234") + tfConfigResourceAccountMasterPassword("new-password-5678", 2*kdfIterations),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, attributeKdfIterations, fmt.Sprintf("%d", 2*kdfIterations)),
+				),
+			},
+			{
+				Config:   tfConfigProviderForEmail(email, "new-password-5678") + tfConfigResourceAccountMasterPassword("new-password-5678", 2*kdfIterations),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccountMasterPasswordRefusesArgon2id(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/identity/accounts/prelogin", r.URL.Path)
+		w.Write([]byte(`{"kdf":1,"kdfIterations":3}`))
+	}))
+	defer server.Close()
+
+	m := &providerMeta{email: "test@example.com", masterPassword: "old-password", serverURL: server.URL}
+	d := schema.TestResourceDataRaw(t, resourceAccountMasterPassword().Schema, map[string]interface{}{
+		attributeMasterPassword: "new-password",
+	})
+
+	err := changeAccountMasterPassword(context.Background(), d, m, "old-password")
+	assert.ErrorContains(t, err, "accounts not using PBKDF2 isn't supported")
+	assert.Equal(t, "old-password", m.getMasterPassword())
+}
+
+func tfConfigProviderForEmail(email, masterPassword string) string {
+	return fmt.Sprintf(`
+	provider "bitwarden" {
+		master_password = "%s"
+		server          = "%s"
+		email           = "%s"
+		vault_path      = ".bitwarden-master-password/"
+	}
+`, masterPassword, testServerURL, email)
+}
+
+func tfConfigResourceAccountMasterPassword(masterPassword string, kdfIterations int) string {
+	kdfIterationsAttribute := ""
+	if kdfIterations > 0 {
+		kdfIterationsAttribute = fmt.Sprintf("kdf_iterations  = %d", kdfIterations)
+	}
+	return fmt.Sprintf(`
+resource "bitwarden_account_master_password" "foo" {
+	provider = bitwarden
+
+	master_password = "%s"
+	%s
+}
+`, masterPassword, kdfIterationsAttribute)
+}
@@ -33,6 +33,7 @@ func resourceAttachment() *schema.Resource {
 		CreateContext: attachmentCreate,
 		ReadContext:   attachmentRead,
 		DeleteContext: attachmentDelete,
+		Timeouts:      resourceTimeouts(false),
 		Importer:      importAttachmentResource(),
 
 		Schema: resourceAttachmentSchema,
@@ -8,6 +8,8 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	test_command "github.com/maxlaverse/terraform-provider-bitwarden/internal/command/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -47,6 +49,24 @@ func TestAccResourceAttachment(t *testing.T) {
 	})
 }
 
+func TestAttachmentDeleteWithoutMasterPassword(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"delete attachment attachment-id --itemid item-id": ``,
+	})
+	defer removeMocks(t)
+
+	// Without a master password, the API client can't log in and the CLI is
+	// used instead.
+	m := &providerMeta{Client: bw.NewClient("bw"), email: "test@laverse.net"}
+	d := resourceAttachment().TestResourceData()
+	d.SetId("attachment-id")
+	assert.NoError(t, d.Set(attributeAttachmentItemID, "item-id"))
+
+	diags := attachmentDelete(context.Background(), d, m)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, []string{"delete attachment attachment-id --itemid item-id"}, commandsExecuted())
+}
+
 func TestAccResourceItemAttachmentFields(t *testing.T) {
 	ensureVaultwardenConfigured(t)
 
@@ -0,0 +1,189 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+)
+
+var (
+	emergencyAccessTypes = map[string]webapi.EmergencyAccessType{
+		"view":     webapi.EmergencyAccessTypeView,
+		"takeover": webapi.EmergencyAccessTypeTakeover,
+	}
+	emergencyAccessStatuses = map[webapi.EmergencyAccessStatus]string{
+		webapi.EmergencyAccessStatusInvited:           "invited",
+		webapi.EmergencyAccessStatusAccepted:          "accepted",
+		webapi.EmergencyAccessStatusConfirmed:         "confirmed",
+		webapi.EmergencyAccessStatusRecoveryInitiated: "recovery_initiated",
+		webapi.EmergencyAccessStatusRecoveryApproved:  "recovery_approved",
+	}
+)
+
+func resourceEmergencyAccess() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manages an emergency access to the Vault of the account the provider is authenticated with. " +
+			"The grantee receives an invitation by email. Once accepted, the emergency access is confirmed on the next apply.",
+
+		CreateContext: emergencyAccessCreate,
+		ReadContext:   emergencyAccessRead,
+		UpdateContext: emergencyAccessUpdate,
+		DeleteContext: emergencyAccessDelete,
+		Timeouts:      resourceTimeouts(true),
+		CustomizeDiff: emergencyAccessCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			attributeID: {
+				Description: descriptionIdentifier,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			attributeEmail: {
+				Description: descriptionEmergencyAccessEmail,
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				DiffSuppressFunc: func(_, oldValue, newValue string, _ *schema.ResourceData) bool {
+					return strings.EqualFold(oldValue, newValue)
+				},
+			},
+			attributeType: {
+				Description:      descriptionEmergencyAccessType,
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "view",
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"view", "takeover"}, false)),
+			},
+			attributeWaitDays: {
+				Description:      descriptionEmergencyAccessWait,
+				Type:             schema.TypeInt,
+				Required:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntBetween(1, 90)),
+			},
+			attributeStatus: {
+				Description: descriptionEmergencyAccessStatus,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func emergencyAccessCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := newEmergencyAccessClient(ctx, meta.(*providerMeta))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	access, err := client.InviteEmergencyAccess(ctx, d.Get(attributeEmail).(string), emergencyAccessTypes[d.Get(attributeType).(string)], d.Get(attributeWaitDays).(int))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(access.Id)
+	return diag.FromErr(emergencyAccessDataFromStruct(d, *access))
+}
+
+func emergencyAccessRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := newEmergencyAccessClient(ctx, meta.(*providerMeta))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	access, err := client.GetEmergencyAccess(ctx, d.Id())
+	if webapi.IsNotFound(err) {
+		d.SetId("")
+		return diag.Diagnostics{}
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(emergencyAccessDataFromStruct(d, *access))
+}
+
+func emergencyAccessUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := newEmergencyAccessClient(ctx, meta.(*providerMeta))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if d.HasChanges(attributeType, attributeWaitDays) {
+		err = client.UpdateEmergencyAccess(ctx, d.Id(), emergencyAccessTypes[d.Get(attributeType).(string)], d.Get(attributeWaitDays).(int))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	oldStatus, _ := d.GetChange(attributeStatus)
+	if oldStatus.(string) == emergencyAccessStatuses[webapi.EmergencyAccessStatusAccepted] {
+		err = client.ConfirmEmergencyAccess(ctx, d.Id())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return emergencyAccessRead(ctx, d, meta)
+}
+
+func emergencyAccessDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := newEmergencyAccessClient(ctx, meta.(*providerMeta))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = client.DeleteEmergencyAccess(ctx, d.Id())
+	if err != nil && !webapi.IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+	return diag.Diagnostics{}
+}
+
+// emergencyAccessCustomizeDiff plans the confirmation of emergency accesses
+// their grantee accepted since the last apply.
+func emergencyAccessCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if len(d.Id()) == 0 {
+		return nil
+	}
+
+	if d.Get(attributeStatus).(string) == emergencyAccessStatuses[webapi.EmergencyAccessStatusAccepted] {
+		return d.SetNew(attributeStatus, emergencyAccessStatuses[webapi.EmergencyAccessStatusConfirmed])
+	}
+	return nil
+}
+
+func emergencyAccessDataFromStruct(d *schema.ResourceData, access webapi.EmergencyAccess) error {
+	err := d.Set(attributeEmail, access.Email)
+	if err != nil {
+		return err
+	}
+
+	for name, accessType := range emergencyAccessTypes {
+		if accessType == access.Type {
+			err = d.Set(attributeType, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = d.Set(attributeWaitDays, access.WaitTimeDays)
+	if err != nil {
+		return err
+	}
+
+	return d.Set(attributeStatus, emergencyAccessStatuses[access.Status])
+}
+
+func newEmergencyAccessClient(ctx context.Context, m *providerMeta) (webapi.Client, error) {
+	masterPassword := m.getMasterPassword()
+	if len(masterPassword) == 0 {
+		return nil, fmt.Errorf("the provider's '%s' is required to manage emergency accesses", attributeMasterPassword)
+	}
+	return m.sharedWebAPIClient(ctx)
+}
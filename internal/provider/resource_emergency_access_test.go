@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/webapi/crypto/keybuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccResourceEmergencyAccess(t *testing.T) {
+	ensureVaultwardenConfigured(t)
+
+	granteeEmail := fmt.Sprintf("test-emergency-access-%s@laverse.net", testUniqueIdentifier)
+	webapiClient, err := webapi.NewClient(testServerURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = webapiClient.RegisterUser(context.Background(), "test-emergency-access", granteeEmail, testPassword, kdfIterations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resourceName := "bitwarden_emergency_access.foo"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: tfConfigProvider() + tfConfigResourceEmergencyAccess(granteeEmail, "view", 7),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr(resourceName, attributeID, regexp.MustCompile("^[0-9a-f-]+$")),
+					resource.TestCheckResourceAttr(resourceName, attributeEmail, granteeEmail),
+					resource.TestCheckResourceAttr(resourceName, attributeType, "view"),
+					resource.TestCheckResourceAttr(resourceName, attributeWaitDays, "7"),
+					resource.TestMatchResourceAttr(resourceName, attributeStatus, regexp.MustCompile("^(invited|accepted)$")),
+				),
+			},
+			{
+				Config: tfConfigProvider() + tfConfigResourceEmergencyAccess(granteeEmail, "takeover", 14),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, attributeType, "takeover"),
+					resource.TestCheckResourceAttr(resourceName, attributeWaitDays, "14"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestEmergencyAccessClientShared(t *testing.T) {
+	preloginKey, err := keybuilder.BuildPreloginKey("master-password", "test@example.com", 1000)
+	assert.NoError(t, err)
+	encryptionKey, encryptedEncryptionKey, err := keybuilder.GenerateEncryptionKey(*preloginKey)
+	assert.NoError(t, err)
+	_, encryptedPrivateKey, err := keybuilder.GenerateKeyPair(*encryptionKey)
+	assert.NoError(t, err)
+
+	logins := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/accounts/prelogin", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(webapi.PreloginResponse{Kdf: keybuilder.PBKDF2_SHA256, KdfIterations: 1000})
+	})
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "123456", r.PostForm.Get("twoFactorToken"))
+		logins++
+		json.NewEncoder(w).Encode(webapi.TokenResponse{
+			AccessToken:   "access-token",
+			ExpireIn:      3600,
+			Key:           encryptedEncryptionKey,
+			PrivateKey:    encryptedPrivateKey,
+			KdfIterations: 1000,
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	m := &providerMeta{
+		email:          "test@example.com",
+		masterPassword: "master-password",
+		serverURL:      server.URL,
+		twoFactor:      &twoFactor{method: twoFactorMethodAuthenticator, code: "123456"},
+	}
+
+	first, err := newEmergencyAccessClient(context.Background(), m)
+	assert.NoError(t, err)
+	second, err := newEmergencyAccessClient(context.Background(), m)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, logins)
+
+	// Once the master password changed, the client logs in again, and fails
+	// to decrypt the key the test server still encrypts with the old one.
+	m.setMasterPassword("new-master-password")
+	_, err = newEmergencyAccessClient(context.Background(), m)
+	assert.Error(t, err)
+	assert.Equal(t, 2, logins)
+}
+
+func tfConfigResourceEmergencyAccess(email, accessType string, waitDays int) string {
+	return fmt.Sprintf(`
+resource "bitwarden_emergency_access" "foo" {
+	provider = bitwarden
+
+	email     = "%s"
+	type      = "%s"
+	wait_days = %d
+}
+`, email, accessType, waitDays)
+}
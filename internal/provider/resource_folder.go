@@ -16,6 +16,7 @@ func resourceFolder() *schema.Resource {
 		ReadContext:   objectReadIgnoreMissing,
 		UpdateContext: objectUpdate,
 		DeleteContext: objectDelete,
+		Timeouts:      resourceTimeouts(true),
 		Importer:      importFolderResource(),
 
 		Schema: folderSchema(Resource),
@@ -17,6 +17,7 @@ func resourceItemLogin() *schema.Resource {
 		ReadContext:   objectReadIgnoreMissing,
 		UpdateContext: objectUpdate,
 		DeleteContext: objectDelete,
+		Timeouts:      resourceTimeouts(true),
 		Importer:      importItemResource(bw.ObjectTypeItem, bw.ItemTypeLogin),
 		Schema:        dataSourceItemSecureNoteSchema,
 	}
@@ -14,6 +14,7 @@ func resourceItemSecureNote() *schema.Resource {
 		ReadContext:   objectReadIgnoreMissing,
 		UpdateContext: objectUpdate,
 		DeleteContext: objectDelete,
+		Timeouts:      resourceTimeouts(true),
 		Importer:      importItemResource(bw.ObjectTypeItem, bw.ItemTypeSecureNote),
 		Schema:        dataSourceItemSecureNoteSchema,
 	}
@@ -18,6 +18,7 @@ func resourceOrgCollection() *schema.Resource {
 		ReadContext:   objectReadIgnoreMissing,
 		UpdateContext: objectUpdate,
 		DeleteContext: objectDelete,
+		Timeouts:      resourceTimeouts(true),
 		Importer:      importOrgCollectionResource(),
 
 		Schema: orgCollectionSchema(Resource),
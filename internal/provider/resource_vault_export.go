@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+)
+
+func resourceVaultExport() *schema.Resource {
+	return &schema.Resource{
+		Description: "Exports the Vault, or an organization's Vault, to a file. " +
+			"The export is written again whenever one of its arguments or `triggers` changes, or when the file was modified or removed. " +
+			"Destroying this resource doesn't remove the file.",
+
+		CreateContext: vaultExportCreate,
+		ReadContext:   vaultExportRead,
+		DeleteContext: vaultExportDelete,
+		CustomizeDiff: vaultExportCustomizeDiff,
+		Timeouts:      resourceTimeouts(false),
+
+		Schema: map[string]*schema.Schema{
+			attributeID: {
+				Description: descriptionIdentifier,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			attributeExportOutputPath: {
+				Description: descriptionExportOutputPath,
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			attributeExportFormat: {
+				Description:      descriptionExportFormat,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Default:          string(bw.ExportFormatJSON),
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{string(bw.ExportFormatCSV), string(bw.ExportFormatJSON), string(bw.ExportFormatEncryptedJSON)}, false)),
+			},
+			attributeExportPassword: {
+				Description: descriptionExportPassword,
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Sensitive:   true,
+			},
+			attributeOrganizationID: {
+				Description: descriptionOrganizationID,
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+			},
+			attributeTriggers: {
+				Description: descriptionTriggers,
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				ForceNew:    true,
+			},
+			attributeExportChecksum: {
+				Description: descriptionExportChecksum,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func vaultExportCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	outputPath := d.Get(attributeExportOutputPath).(string)
+	format := d.Get(attributeExportFormat).(string)
+
+	options := []bw.ExportOption{}
+	if password, ok := d.GetOk(attributeExportPassword); ok {
+		options = append(options, bw.WithExportPassword(password.(string)))
+	}
+	if orgID, ok := d.GetOk(attributeOrganizationID); ok {
+		options = append(options, bw.WithExportOrganizationID(orgID.(string)))
+	}
+
+	err := meta.(bw.Client).Export(ctx, outputPath, bw.ExportFormat(format), options...)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	checksum, err := fileSha256Sum(outputPath)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(outputPath)
+	return diag.FromErr(d.Set(attributeExportChecksum, checksum))
+}
+
+// vaultExportCustomizeDiff rejects passwords for formats which can't be
+// password-protected, before anything is applied.
+func vaultExportCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	_, hasPassword := d.GetOk(attributeExportPassword)
+	if hasPassword && d.Get(attributeExportFormat).(string) != string(bw.ExportFormatEncryptedJSON) {
+		return fmt.Errorf("'%s' can only be used with the '%s' format", attributeExportPassword, bw.ExportFormatEncryptedJSON)
+	}
+	return nil
+}
+
+func vaultExportRead(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	checksum, err := fileSha256Sum(d.Id())
+	if errors.Is(err, os.ErrNotExist) {
+		d.SetId("")
+		return diag.Diagnostics{}
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// A modified export is considered gone, so that it gets written again.
+	if checksum != d.Get(attributeExportChecksum).(string) {
+		d.SetId("")
+	}
+	return diag.Diagnostics{}
+}
+
+func vaultExportDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+func fileSha256Sum(filepath string) (string, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
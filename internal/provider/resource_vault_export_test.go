@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccResourceVaultExport(t *testing.T) {
+	ensureVaultwardenConfigured(t)
+
+	resourceName := "bitwarden_vault_export.foo"
+	outputPath := filepath.Join(t.TempDir(), "export.json")
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: tfConfigProvider() + tfConfigResourceVaultExport(outputPath, "json", "", "1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, attributeID, outputPath),
+					resource.TestMatchResourceAttr(resourceName, attributeExportChecksum, regexp.MustCompile("^[0-9a-f]{64}$")),
+					checkFileExists(outputPath),
+				),
+			},
+			{
+				PreConfig: func() {
+					err := os.WriteFile(outputPath, []byte("modified"), 0600)
+					if err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config:             tfConfigProvider() + tfConfigResourceVaultExport(outputPath, "json", "", "1"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: tfConfigProvider() + tfConfigResourceVaultExport(outputPath, "encrypted_json", "export-password", "2"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, attributeExportFormat, "encrypted_json"),
+					checkFileExists(outputPath),
+				),
+			},
+			{
+				Config:      tfConfigProvider() + tfConfigResourceVaultExport(outputPath, "csv", "export-password", "2"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("can only be used with the 'encrypted_json' format"),
+			},
+		},
+	})
+}
+
+func TestVaultExportPasswordRequiresEncryptedJSON(t *testing.T) {
+	r := resourceVaultExport()
+
+	for format, expectError := range map[string]bool{"csv": true, "json": true, "encrypted_json": false} {
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			attributeExportOutputPath: "/tmp/export.json",
+			attributeExportFormat:     format,
+			attributeExportPassword:   "export-password",
+		})
+
+		_, err := r.Diff(context.Background(), nil, config, nil)
+		if expectError {
+			assert.ErrorContains(t, err, "can only be used with the 'encrypted_json' format", format)
+		} else {
+			assert.NoError(t, err, format)
+		}
+	}
+}
+
+func checkFileExists(path string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		_, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("export file not found: %w", err)
+		}
+		return nil
+	}
+}
+
+func tfConfigResourceVaultExport(outputPath, format, password, trigger string) string {
+	return fmt.Sprintf(`
+resource "bitwarden_vault_export" "foo" {
+	provider = bitwarden
+
+	output_path = "%s"
+	format      = "%s"
+	password    = "%s"
+
+	triggers = {
+		run = "%s"
+	}
+}
+`, outputPath, format, password, trigger)
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+)
+
+func resourceVaultImport() *schema.Resource {
+	return &schema.Resource{
+		Description: "Imports a file into the Vault, or into an organization's Vault. " +
+			"The file is imported once, and again whenever its content changes. " +
+			"Destroying this resource doesn't remove what was imported.",
+
+		CreateContext: vaultImportCreate,
+		ReadContext:   vaultImportRead,
+		DeleteContext: vaultImportDelete,
+		Timeouts:      resourceTimeouts(false),
+
+		Schema: map[string]*schema.Schema{
+			attributeID: {
+				Description: descriptionIdentifier,
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			attributeImportFile: {
+				Description:      descriptionImportFile,
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: fileHashComputable,
+				StateFunc:        fileHash,
+			},
+			attributeImportFormat: {
+				Description:      descriptionImportFormat,
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(bw.ImportFormats, false)),
+			},
+			attributeOrganizationID: {
+				Description: descriptionOrganizationID,
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+			},
+			attributeImportCollectionID: {
+				Description:  descriptionImportCollectionID,
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{attributeOrganizationID},
+			},
+			attributeItemsCreated: {
+				Description: descriptionItemsCreated,
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			attributeFoldersCreated: {
+				Description: descriptionFoldersCreated,
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			attributeCollectionsCreated: {
+				Description: descriptionCollectionsCreated,
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func vaultImportCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(bw.Client)
+	filePath := d.Get(attributeImportFile).(string)
+	orgID := d.Get(attributeOrganizationID).(string)
+
+	hash, err := fileSha1Sum(filePath)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	before, err := listVaultContent(ctx, client, orgID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	options := []bw.ImportOption{}
+	if len(orgID) > 0 {
+		options = append(options, bw.WithImportOrganizationID(orgID))
+	}
+
+	err = client.Import(ctx, d.Get(attributeImportFormat).(string), filePath, options...)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	after, err := listVaultContent(ctx, client, orgID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	itemsCreated := objectsOnlyInSecondList(before[bw.ObjectTypeItem], after[bw.ObjectTypeItem])
+	foldersCreated := objectsOnlyInSecondList(before[bw.ObjectTypeFolder], after[bw.ObjectTypeFolder])
+	collectionsCreated := objectsOnlyInSecondList(before[bw.ObjectTypeOrgCollection], after[bw.ObjectTypeOrgCollection])
+
+	// The CLI has no option to import into a collection, which is why the
+	// imported items are added to it afterwards.
+	if collectionID, ok := d.GetOk(attributeImportCollectionID); ok {
+		err = addItemsToCollection(ctx, client, itemsCreated, collectionID.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	tflog.Info(ctx, "Imported file into the Vault", map[string]interface{}{"items": len(itemsCreated), "folders": len(foldersCreated), "collections": len(collectionsCreated)})
+
+	d.SetId(hash)
+
+	err = d.Set(attributeItemsCreated, len(itemsCreated))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set(attributeFoldersCreated, len(foldersCreated))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diag.FromErr(d.Set(attributeCollectionsCreated, len(collectionsCreated)))
+}
+
+func vaultImportRead(_ context.Context, _ *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	// What was imported is managed from the Vault from now on, and only
+	// changes to the file's content trigger a new import.
+	return diag.Diagnostics{}
+}
+
+func vaultImportDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+// listVaultContent returns the objects an import can create, either in the
+// Vault or in an organization's Vault.
+func listVaultContent(ctx context.Context, client bw.Client, orgID string) (map[bw.ObjectType][]bw.Object, error) {
+	content := map[bw.ObjectType][]bw.Object{}
+
+	objTypes := []bw.ObjectType{bw.ObjectTypeItem, bw.ObjectTypeFolder}
+	options := []bw.ListObjectsOption{}
+	if len(orgID) > 0 {
+		objTypes = []bw.ObjectType{bw.ObjectTypeItem, bw.ObjectTypeOrgCollection}
+		options = append(options, bw.WithOrganizationID(orgID))
+	}
+
+	for _, objType := range objTypes {
+		objs, err := client.ListObjects(ctx, fmt.Sprintf("%ss", objType), options...)
+		if err != nil {
+			return nil, fmt.Errorf("error listing %ss: %w", objType, err)
+		}
+		content[objType] = objs
+	}
+	return content, nil
+}
+
+func addItemsToCollection(ctx context.Context, client bw.Client, items []bw.Object, collectionID string) error {
+	for _, item := range items {
+		found := false
+		for _, id := range item.CollectionIds {
+			found = found || id == collectionID
+		}
+		if found {
+			continue
+		}
+
+		_, err := client.EditItemCollections(ctx, item.ID, append(item.CollectionIds, collectionID))
+		if err != nil {
+			return fmt.Errorf("error adding item '%s' to collection: %w", item.ID, err)
+		}
+	}
+	return nil
+}
+
+func objectsOnlyInSecondList(firstList []bw.Object, secondList []bw.Object) []bw.Object {
+	known := map[string]bool{}
+	for _, obj := range firstList {
+		known[obj.ID] = true
+	}
+
+	result := []bw.Object{}
+	for _, obj := range secondList {
+		if !known[obj.ID] {
+			result = append(result, obj)
+		}
+	}
+	return result
+}
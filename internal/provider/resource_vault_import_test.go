@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bw"
+	test_command "github.com/maxlaverse/terraform-provider-bitwarden/internal/command/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccResourceVaultImport(t *testing.T) {
+	ensureVaultwardenConfigured(t)
+
+	resourceName := "bitwarden_vault_import.foo"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: tfConfigProvider() + tfConfigResourceVaultImport("fixtures/import.json"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, attributeID),
+					resource.TestCheckResourceAttr(resourceName, attributeItemsCreated, "2"),
+					resource.TestCheckResourceAttr(resourceName, attributeFoldersCreated, "1"),
+					resource.TestCheckResourceAttr(resourceName, attributeCollectionsCreated, "0"),
+				),
+			},
+			{
+				Config:   tfConfigProvider() + tfConfigResourceVaultImport("fixtures/import.json"),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAddItemsToCollection(t *testing.T) {
+	removeMocks, commandsExecuted := test_command.MockCommands(t, map[string]string{
+		"edit item-collections item-1": `{"id":"item-1"}`,
+		"sync":                         ``,
+	})
+	defer removeMocks(t)
+
+	items := []bw.Object{
+		{ID: "item-1", CollectionIds: []string{"other-collection"}},
+		{ID: "item-2", CollectionIds: []string{"collection-id"}},
+	}
+	err := addItemsToCollection(context.Background(), bw.NewClient("dummy"), items, "collection-id")
+	assert.NoError(t, err)
+
+	payload := base64.RawStdEncoding.EncodeToString([]byte(`["other-collection","collection-id"]`))
+	assert.Equal(t, []string{payload + ":/:edit item-collections item-1", "sync"}, commandsExecuted())
+}
+
+func tfConfigResourceVaultImport(file string) string {
+	return fmt.Sprintf(`
+resource "bitwarden_vault_import" "foo" {
+	provider = bitwarden
+
+	file   = "%s"
+	format = "bitwardenjson"
+}
+`, file)
+}
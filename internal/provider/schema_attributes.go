@@ -4,9 +4,20 @@ const (
 	// Datasource and Resource field attributes
 	attributeAttachments          = "attachments"
 	attributeCollectionIDs        = "collection_ids"
+	attributeCollectionsCreated   = "collections_created"
 	attributeCreationDate         = "creation_date"
 	attributeDeletedDate          = "deleted_date"
+	attributeExportChecksum       = "checksum"
+	attributeExportCollections    = "collections"
+	attributeExportFilePath       = "path"
+	attributeExportFolders        = "folders"
+	attributeExportFormat         = "format"
+	attributeExportLogins         = "logins"
+	attributeExportOutputPath     = "output_path"
+	attributeExportPassword       = "password"
+	attributeExportSecureNotes    = "secure_notes"
 	attributeID                   = "id"
+	attributeKdfIterations        = "kdf_iterations"
 	attributeFavorite             = "favorite"
 	attributeField                = "field"
 	attributeFieldName            = "name"
@@ -16,6 +27,11 @@ const (
 	attributeFieldText            = "text"
 	attributeFilterValues         = "values"
 	attributeFolderID             = "folder_id"
+	attributeFoldersCreated       = "folders_created"
+	attributeImportCollectionID   = "collection_id"
+	attributeImportFile           = "file"
+	attributeImportFormat         = "format"
+	attributeItemsCreated         = "items_created"
 	attributeAttachmentContent    = "content"
 	attributeAttachmentItemID     = "item_id"
 	attributeAttachmentFile       = "file"
@@ -40,13 +56,38 @@ const (
 	attributeOrganizationID       = "organization_id"
 	attributeReprompt             = "reprompt"
 	attributeRevisionDate         = "revision_date"
+	attributeSendExpirationDate   = "expiration_date"
+	attributeSendFileContent      = "content"
+	attributeSendFileName         = "file_name"
+	attributeSendPassword         = "password"
+	attributeSendText             = "text"
+	attributeSendURL              = "url"
+	attributeStatus               = "status"
+	attributeTriggers             = "triggers"
 	attributeType                 = "type"
+	attributeWaitDays             = "wait_days"
 
 	// Datasource and Resource field descriptions
+	descriptionAccountMasterPassword  = "Master password of the account. Once changed, the provider's `master_password` needs to be updated as well."
 	descriptionAttachments            = "List of item attachments."
 	descriptionCollectionIDs          = "Identifier of the collections the item belongs to."
+	descriptionCollectionsCreated     = "Number of collections created by the import."
 	descriptionCreationDate           = "Date the item was created."
 	descriptionDeletedDate            = "Date the item was deleted."
+	descriptionEmergencyAccessEmail   = "Email of the grantee."
+	descriptionEmergencyAccessStatus  = "Status of the emergency access: `invited`, `accepted`, `confirmed`, `recovery_initiated` or `recovery_approved`."
+	descriptionEmergencyAccessType    = "Access granted in an emergency: `view` or `takeover` (default: `view`)."
+	descriptionEmergencyAccessWait    = "Number of days after which an emergency request is automatically approved."
+	descriptionExportChecksum         = "SHA256 checksum of the export file."
+	descriptionExportCollections      = "Collections of the export."
+	descriptionExportFilePassword     = "Password of a password-protected export."
+	descriptionExportFilePath         = "Path of the export file."
+	descriptionExportFolders          = "Folders of the export."
+	descriptionExportFormat           = "Format of the export: `json`, `csv` or `encrypted_json` (default: `json`)."
+	descriptionExportLogins           = "Login items of the export."
+	descriptionExportOutputPath       = "Path of the export file."
+	descriptionExportPassword         = "Password protecting the export instead of the account's encryption key. Only applies to the `encrypted_json` format."
+	descriptionExportSecureNotes      = "Secure note items of the export."
 	descriptionFavorite               = "Mark as a Favorite to have item appear at the top of your Vault in the UI."
 	descriptionField                  = "Extra fields."
 	descriptionFieldBoolean           = "Value of a boolean field."
@@ -60,8 +101,14 @@ const (
 	descriptionFilterSearch           = "Search items matching the search string."
 	descriptionFilterURL              = "Filter search results by URL."
 	descriptionFolderID               = "Identifier of the folder."
+	descriptionFoldersCreated         = "Number of folders created by the import."
+	descriptionImportCollectionID     = "Identifier of the collection to add the imported items to. Requires `organization_id`."
+	descriptionImportFile             = "Path to the file to import. It's imported again whenever its content changes."
+	descriptionImportFormat           = "Format of the file, as expected by `bw import` (e.g. `bitwardenjson`, `bitwardencsv`, `keepass2xml`, `1password1pux`)."
+	descriptionItemsCreated           = "Number of items created by the import."
 	descriptionIdentifier             = "Identifier."
 	descriptionInternal               = "INTERNAL USE" // TODO: Manage to hide this from the users
+	descriptionKdfIterations          = "Number of PBKDF2 iterations used to derive keys from the master password (default: unchanged)."
 	descriptionItemIdentifier         = "Identifier of the item the attachment belongs to"
 	descriptionItemAttachmentContent  = "Content of the attachment"
 	descriptionItemAttachmentFile     = "Path to the content of the attachment."
@@ -80,24 +127,52 @@ const (
 	descriptionOrganizationID         = "Identifier of the organization."
 	descriptionReprompt               = "Require master password “re-prompt” when displaying secret in the UI."
 	descriptionRevisionDate           = "Last time the item was updated."
+	descriptionSendExpirationDate     = "Date the Send expires, if any."
+	descriptionSendFileContent        = "Content of the file of a `file` Send."
+	descriptionSendFileName           = "Name of the file of a `file` Send."
+	descriptionSendPassword           = "Password of a password protected Send."
+	descriptionSendText               = "Text of a `text` Send."
+	descriptionSendType               = "Type of the Send: `text` or `file`."
+	descriptionSendURL                = "URL of the Send, including the key after the `#`."
+	descriptionTriggers               = "Arbitrary map of values that, when changed, forces the resource to be replaced."
 
 	// Provider field attributes
-	attributeClientID         = "client_id"
-	attributeClientSecret     = "client_secret"
-	attributeEmail            = "email"
-	attributeMasterPassword   = "master_password"
-	attributeServer           = "server"
-	attributeSessionKey       = "session_key"
-	attributeVaultPath        = "vault_path"
-	attributeExtraCACertsPath = "extra_ca_certs"
+	attributeCLIPath           = "cli_path"
+	attributeClientID          = "client_id"
+	attributeClientSecret      = "client_secret"
+	attributeCommandTimeout    = "command_timeout"
+	attributeEmail             = "email"
+	attributeMasterPassword    = "master_password"
+	attributeMaxParallel       = "max_parallel_commands"
+	attributeOfflineVaultCache = "offline_vault_cache"
+	attributeRetryMaxAttempts  = "retry_max_attempts"
+	attributeRetryMaxDelay     = "retry_max_delay"
+	attributeServer            = "server"
+	attributeSessionKey        = "session_key"
+	attributeTwoFactorCode     = "two_factor_code"
+	attributeTwoFactorMethod   = "two_factor_method"
+	attributeTwoFactorTotpSeed = "two_factor_totp_seed"
+	attributeUseCLIServe       = "use_cli_serve"
+	attributeVaultPath         = "vault_path"
+	attributeExtraCACertsPath  = "extra_ca_certs"
 
 	// Provider field descriptions
-	descriptionClientSecret     = "Client Secret (env: `BW_CLIENTSECRET`). Do not commit this information in Git unless you know what you're doing. Prefer using a Terraform `variable {}` in order to inject this value from the environment."
-	descriptionClientID         = "Client ID (env: `BW_CLIENTID`)"
-	descriptionEmail            = "Login Email of the Vault (env: `BW_EMAIL`)."
-	descriptionMasterPassword   = "Master password of the Vault (env: `BW_PASSWORD`). Do not commit this information in Git unless you know what you're doing. Prefer using a Terraform `variable {}` in order to inject this value from the environment."
-	descriptionServer           = "Bitwarden Server URL (default: `https://vault.bitwarden.com`, env: `BW_URL`)."
-	descriptionSessionKey       = "A Bitwarden Session Key (env: `BW_SESSION`)"
-	descriptionVaultPath        = "Alternative directory for storing the Vault locally (default: `.bitwarden/`, env: `BITWARDENCLI_APPDATA_DIR`)."
-	descriptionExtraCACertsPath = "Extends the well known 'root' CAs (like VeriSign) with the extra certificates in file (env: `NODE_EXTRA_CA_CERTS`)."
+	descriptionCLIPath           = "Path to the Bitwarden CLI executable (default: `bw` from the `PATH`, env: `BW_CLI_PATH`). Versions older than 1.12.0 aren't supported."
+	descriptionClientSecret      = "Client Secret (env: `BW_CLIENTSECRET`). Do not commit this information in Git unless you know what you're doing. Prefer using a Terraform `variable {}` in order to inject this value from the environment."
+	descriptionClientID          = "Client ID (env: `BW_CLIENTID`)"
+	descriptionCommandTimeout    = "Maximum duration of a single CLI command, like `30s` or `2m` (default: none). Commands running longer are killed along with the processes they started. Operations of resources are also bound by their `timeouts`. Without it, detecting the version of the CLI is still limited to one minute."
+	descriptionEmail             = "Login Email of the Vault (env: `BW_EMAIL`)."
+	descriptionMasterPassword    = "Master password of the Vault (env: `BW_PASSWORD`). Do not commit this information in Git unless you know what you're doing. Prefer using a Terraform `variable {}` in order to inject this value from the environment."
+	descriptionMaxParallel       = "Maximum number of CLI commands run concurrently (default: unlimited). Regardless of this setting, commands modifying the local Vault never run concurrently with other commands using the same `vault_path`, even from other processes."
+	descriptionOfflineVaultCache = "Serve reads from an encrypted copy of the Vault kept in `vault_path`, as long as the Vault hasn't changed on the server. This avoids running the CLI when planning unchanged resources. Requires `master_password`."
+	descriptionRetryMaxAttempts  = "Maximum number of runs of a CLI command failing with transient errors, like rate limiting, server errors or network failures (default: `3`). Commands which aren't idempotent, like creations, are only run again if the server didn't process the request."
+	descriptionRetryMaxDelay     = "Maximum delay between two runs of a failing CLI command, like `10s` (default: `30s`). Delays grow exponentially and are partly randomized."
+	descriptionServer            = "Bitwarden Server URL (default: `https://vault.bitwarden.com`, env: `BW_URL`)."
+	descriptionSessionKey        = "A Bitwarden Session Key (env: `BW_SESSION`)"
+	descriptionTwoFactorCode     = "Code of the two-step login method. As codes can usually be used only once, prefer `two_factor_totp_seed` for the `authenticator` method."
+	descriptionTwoFactorMethod   = "Two-step login method of the account: `authenticator`, `email` or `yubikey`. Not needed with `client_id` and `client_secret`, as API keys bypass two-step login."
+	descriptionTwoFactorTotpSeed = "Secret of the `authenticator` method, as a base32 string or an `otpauth://` URI. The provider computes a new code for every login."
+	descriptionUseCLIServe       = "Run object operations through a `bw serve` process started once for the provider's lifetime, instead of running the CLI for every operation. Requires the Bitwarden CLI 1.22.0 or later. The process listens on a random port of the loopback interface, without authentication, which exposes the unlocked Vault to every local user while it runs. Unix sockets aren't supported by `bw serve`, so only enable it on machines without untrusted local users."
+	descriptionVaultPath         = "Alternative directory for storing the Vault locally (default: `.bitwarden/`, env: `BITWARDENCLI_APPDATA_DIR`)."
+	descriptionExtraCACertsPath  = "Extends the well known 'root' CAs (like VeriSign) with the extra certificates in file (env: `NODE_EXTRA_CA_CERTS`)."
 )
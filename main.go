@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"github.com/maxlaverse/terraform-provider-bitwarden/internal/bitwarden/bwserve"
 	"github.com/maxlaverse/terraform-provider-bitwarden/internal/provider"
 )
 
@@ -22,5 +23,8 @@ var (
 func main() {
 	opts := &plugin.ServeOpts{ProviderFunc: provider.New(version)}
 
+	// 'bw serve' processes are kept running for the provider's lifetime.
+	defer bwserve.StopAll()
+
 	plugin.Serve(opts)
 }